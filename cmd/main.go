@@ -1,18 +1,49 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"lt-app/internal/applogger"
+	"lt-app/internal/cli"
 	"lt-app/internal/constants"
+	"lt-app/internal/handlers"
+	"lt-app/internal/history"
 	"lt-app/internal/middleware"
+	"lt-app/internal/monitor"
 	"lt-app/internal/routes"
+	"lt-app/internal/services"
+	"lt-app/internal/storage"
+	"lt-app/internal/utils"
+	"lt-app/internal/webhook"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	bolt "go.etcd.io/bbolt"
 )
 
 func main() {
 	applogger.InitLogger()
+
+	// Without a command, or with serve, the server is started
+	if len(os.Args) > 1 && os.Args[1] != "serve" {
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		exitCode := cli.Run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+		stop()
+		os.Exit(exitCode)
+	}
+
+	serve()
+}
+
+func serve() {
+	if db, store := openHistory(); store != nil {
+		startMonitors(db, store, startWebhooks(db))
+	}
+
 	app := fiber.New()
 
 	middleware.SetupMiddleware(app)
@@ -25,3 +56,76 @@ func main() {
 	applogger.Logger.Info(fmt.Sprintf("Server is listening on port %d", constants.SERVER_PORT))
 	log.Fatal(app.Listen(fmt.Sprintf(":%d", constants.SERVER_PORT)))
 }
+
+// openHistory opens the embedded database and keeps every analysis in it. The
+// server still runs without a history when the database cannot be opened.
+func openHistory() (*bolt.DB, *history.Store) {
+	dbPath := filepath.Join(utils.GetProjectRoot(), constants.STORAGE_DB_PATH)
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		applogger.Logger.Error("Failed to open the database, the history is disabled", "path", dbPath, "error", err)
+		return nil, nil
+	}
+
+	store, err := history.New(db, history.Retention{
+		MaxAge:        constants.HISTORY_MAX_AGE_DAYS * 24 * time.Hour,
+		MaxRunsPerURL: constants.HISTORY_MAX_RUNS_PER_URL,
+	})
+	if err != nil {
+		applogger.Logger.Error("Failed to open the history", "error", err)
+		return nil, nil
+	}
+	handlers.SetHistoryStore(store)
+
+	// Runs of URLs that are no longer analyzed are removed once a day
+	go func() {
+		for ; ; time.Sleep(24 * time.Hour) {
+			if removed, err := store.Prune(); err != nil {
+				applogger.Logger.Error("Failed to prune the history", "error", err)
+			} else {
+				applogger.Logger.Info("Pruned the history", "removed", removed)
+			}
+		}
+	}()
+	return db, store
+}
+
+// startWebhooks resumes the deliveries that were pending when the server stopped
+// and publishes the analyses to the webhooks. It returns nil when the webhooks
+// cannot be opened.
+func startWebhooks(db *bolt.DB) *webhook.Dispatcher {
+	store, err := webhook.NewStore(db, constants.WEBHOOK_DELIVERIES_MAX_PER_SUBSCRIPTION)
+	if err != nil {
+		applogger.Logger.Error("Failed to open the webhooks, webhooks are disabled", "error", err)
+		return nil
+	}
+
+	dispatcher := webhook.NewDispatcher(store, applogger.Logger)
+	if err := dispatcher.Resume(); err != nil {
+		applogger.Logger.Error("Failed to resume the pending webhook deliveries", "error", err)
+	}
+	handlers.SetWebhookStore(store)
+	handlers.SetWebhookDispatcher(dispatcher)
+	return dispatcher
+}
+
+// startMonitors schedules the stored monitors and publishes their runs to the
+// webhooks. Their results are kept in the history, so monitoring is only
+// available along with it.
+func startMonitors(db *bolt.DB, historyStore *history.Store, dispatcher *webhook.Dispatcher) {
+	store, err := monitor.NewStore(db, constants.MONITOR_ALERTS_MAX_PER_MONITOR)
+	if err != nil {
+		applogger.Logger.Error("Failed to open the monitors, monitoring is disabled", "error", err)
+		return
+	}
+
+	scheduler := monitor.NewScheduler(store, historyStore, services.FetchWebPageStats, applogger.Logger)
+	if dispatcher != nil {
+		scheduler.OnRun(dispatcher.PublishMonitorRun)
+	}
+	if err := scheduler.Start(); err != nil {
+		applogger.Logger.Error("Failed to start the monitors, monitoring is disabled", "error", err)
+		return
+	}
+	handlers.SetMonitorScheduler(scheduler)
+}
@@ -0,0 +1,103 @@
+package analyzer
+
+import (
+	"context"
+	"log/slog"
+	"lt-app/internal/extract"
+	"lt-app/internal/pagedata"
+	"lt-app/internal/webfetch"
+	"net/http"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Inputs analyzers can depend on besides other analyzers. They are available
+// before any analyzer runs.
+const (
+	// The parsed HTML of the page
+	Document = "document"
+	// The response headers of the page
+	Headers = "headers"
+	// The page request itself: final URL, status code and timing
+	Response = "response"
+)
+
+// Analyzer is a single check of the page. Its result is a section of the stats.
+type Analyzer interface {
+	Name() string
+	// Inputs and names of the analyzers whose results this analyzer reads
+	Dependencies() []string
+	Run(ctx context.Context, input *Input) (any, *webfetch.ErrorResponse)
+}
+
+// Inliner is implemented by analyzers whose result fields are placed at the top
+// level of the stats instead of under the analyzer name
+type Inliner interface {
+	Inline() bool
+}
+
+// Input is what the analyzers of a page share
+type Input struct {
+	Page pagedata.IPageData
+	// The parsed HTML of the page and its source
+	Doc  *goquery.Document
+	HTML string
+	// The response headers of the page
+	Headers http.Header
+	// URL the page was served from, and the URL relative references resolve against
+	URL     string
+	BaseURL string
+	Source  *webfetch.PageSource
+	Fetcher webfetch.IFetcher
+	Logger  *slog.Logger
+	// Named selectors supplied with the request
+	Extractors []extract.Extractor
+
+	results *Results
+}
+
+// Result returns the result of an analyzer this analyzer depends on
+func (in *Input) Result(name string) (any, bool) {
+	return in.results.Get(name)
+}
+
+// ResultOf returns the typed result of an analyzer this analyzer depends on
+func ResultOf[T any](input *Input, name string) (T, bool) {
+	value, found := input.Result(name)
+	result, ok := value.(T)
+	return result, found && ok
+}
+
+type funcAnalyzer[T any] struct {
+	name         string
+	dependencies []string
+	inline       bool
+	run          func(ctx context.Context, input *Input) (T, *webfetch.ErrorResponse)
+}
+
+// New returns an analyzer whose result is stored under its name
+func New[T any](name string, dependencies []string, run func(ctx context.Context, input *Input) (T, *webfetch.ErrorResponse)) Analyzer {
+	return &funcAnalyzer[T]{name: name, dependencies: dependencies, run: run}
+}
+
+// NewInline returns an analyzer whose result must be a struct. Its fields are
+// placed at the top level of the stats.
+func NewInline[T any](name string, dependencies []string, run func(ctx context.Context, input *Input) (T, *webfetch.ErrorResponse)) Analyzer {
+	return &funcAnalyzer[T]{name: name, dependencies: dependencies, inline: true, run: run}
+}
+
+func (a *funcAnalyzer[T]) Name() string {
+	return a.name
+}
+
+func (a *funcAnalyzer[T]) Dependencies() []string {
+	return a.dependencies
+}
+
+func (a *funcAnalyzer[T]) Inline() bool {
+	return a.inline
+}
+
+func (a *funcAnalyzer[T]) Run(ctx context.Context, input *Input) (any, *webfetch.ErrorResponse) {
+	return a.run(ctx, input)
+}
@@ -0,0 +1,192 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"lt-app/internal/webfetch"
+	"net/http"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countSection struct {
+	Count int `json:"count"`
+}
+
+func newInput() *Input {
+	return &Input{Logger: slog.Default()}
+}
+
+func TestRunResolvesDependencies(t *testing.T) {
+	registry := NewRegistry().Register(
+		New("base", []string{Document}, func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) {
+			return 2, nil
+		}),
+		NewInline("double", []string{"base"}, func(_ context.Context, in *Input) (countSection, *webfetch.ErrorResponse) {
+			base, _ := ResultOf[int](in, "base")
+			return countSection{Count: base * 2}, nil
+		}),
+		New("other", nil, func(_ context.Context, _ *Input) (string, *webfetch.ErrorResponse) {
+			return "other", nil
+		}),
+	)
+
+	results, err := registry.Run(context.Background(), []string{"double"}, newInput())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if !reflect.DeepEqual(results.Names(), []string{"base", "double"}) {
+		t.Errorf("Expected base and double to run, got %v", results.Names())
+	}
+
+	section, found := Section[countSection](results, "double")
+	if !found || section.Count != 4 {
+		t.Errorf("Expected a count of 4, got %+v", section)
+	}
+
+	body, _ := json.Marshal(results)
+	expected := `{"analyzers":["base","double"],"base":2,"count":4}`
+	if string(body) != expected {
+		t.Errorf("Expected %s, got %s", expected, body)
+	}
+}
+
+func TestRunIsConcurrent(t *testing.T) {
+	var running, maxRunning int32
+	slow := func(_ context.Context, _ *Input) (bool, *webfetch.ErrorResponse) {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			previous := atomic.LoadInt32(&maxRunning)
+			if current <= previous || atomic.CompareAndSwapInt32(&maxRunning, previous, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return true, nil
+	}
+
+	registry := NewRegistry().Register(
+		New("a", []string{Document}, slow),
+		New("b", []string{Headers}, slow),
+		New("c", nil, slow),
+	)
+
+	if _, err := registry.Run(context.Background(), nil, newInput()); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if maxRunning != 3 {
+		t.Errorf("Expected independent analyzers to run at the same time, at most %d did", maxRunning)
+	}
+}
+
+func TestRunStopsOnError(t *testing.T) {
+	var dependentRan atomic.Bool
+	registry := NewRegistry().Register(
+		New("failing", nil, func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) {
+			return 0, webfetch.BuildErrorResponse(http.StatusBadRequest, "Too many links")
+		}),
+		New("dependent", []string{"failing"}, func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) {
+			dependentRan.Store(true)
+			return 1, nil
+		}),
+	)
+
+	_, err := registry.Run(context.Background(), nil, newInput())
+	if err == nil || err.StatusCode != http.StatusBadRequest || err.Error != "Too many links" {
+		t.Errorf("Expected the analyzer error, got %+v", err)
+	}
+	if dependentRan.Load() {
+		t.Errorf("Expected the dependent analyzer not to run")
+	}
+}
+
+func TestRunRecoversPanics(t *testing.T) {
+	registry := NewRegistry().Register(
+		New("panicking", nil, func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) {
+			panic("boom")
+		}),
+	)
+
+	_, err := registry.Run(context.Background(), nil, newInput())
+	if err == nil || err.StatusCode != http.StatusInternalServerError {
+		t.Errorf("Expected an internal error, got %+v", err)
+	}
+}
+
+func TestRunUnknownAnalyzer(t *testing.T) {
+	registry := NewRegistry().Register(
+		New("known", nil, func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) { return 1, nil }),
+	)
+
+	_, err := registry.Run(context.Background(), []string{"known", "unknown"}, newInput())
+	if err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a bad request, got %+v", err)
+	}
+}
+
+func TestRegisterPanicsOnMistakes(t *testing.T) {
+	run := func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) { return 1, nil }
+
+	tests := map[string]func(){
+		"duplicate name":     func() { NewRegistry().Register(New("a", nil, run), New("a", nil, run)) },
+		"unknown dependency": func() { NewRegistry().Register(New("a", []string{"b"}, run)) },
+		"input name":         func() { NewRegistry().Register(New(Document, nil, run)) },
+	}
+
+	for name, register := range tests {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected Register to panic")
+				}
+			}()
+			register()
+		})
+	}
+}
+
+func TestMarshalRejectsInlineNonObjects(t *testing.T) {
+	registry := NewRegistry().Register(
+		NewInline("number", nil, func(_ context.Context, _ *Input) (int, *webfetch.ErrorResponse) { return 1, nil }),
+	)
+
+	results, _ := registry.Run(context.Background(), nil, newInput())
+	if _, err := json.Marshal(results); err == nil {
+		t.Errorf("Expected an error for an inline section that is not an object")
+	}
+}
+
+func TestMarshalAttachedFields(t *testing.T) {
+	registry := NewRegistry().Register(
+		NewInline("count", nil, func(_ context.Context, _ *Input) (countSection, *webfetch.ErrorResponse) {
+			return countSection{Count: 1}, nil
+		}),
+	)
+
+	results, _ := registry.Run(context.Background(), nil, newInput())
+	results.Attach("verdict", "fail")
+	results.Attach("extra", 1)
+	results.Attach("verdict", "pass")
+
+	body, _ := json.Marshal(results)
+	expected := `{"analyzers":["count"],"count":1,"verdict":"pass","extra":1}`
+	if string(body) != expected {
+		t.Errorf("Expected %s, got %s", expected, body)
+	}
+}
+
+func TestParseNames(t *testing.T) {
+	got := ParseNames(" title, links,,content ")
+	expected := []string{"title", "links", "content"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if len(ParseNames("")) != 0 {
+		t.Errorf("Expected no names for an empty list")
+	}
+}
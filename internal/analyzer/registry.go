@@ -0,0 +1,213 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"lt-app/internal/webfetch"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Registry holds the analyzers in the order they were registered, which is also
+// the order of their sections in the stats
+type Registry struct {
+	analyzers []Analyzer
+	byName    map[string]Analyzer
+}
+
+// Description lists an analyzer and what it depends on
+type Description struct {
+	Name         string   `json:"name"`
+	Dependencies []string `json:"dependencies"`
+}
+
+func NewRegistry() *Registry {
+	return &Registry{byName: make(map[string]Analyzer)}
+}
+
+func isInput(name string) bool {
+	return name == Document || name == Headers || name == Response
+}
+
+// Register adds an analyzer. Dependencies must be registered first, which also
+// rules out cycles. Mistakes are programming errors, so Register panics on them.
+func (r *Registry) Register(analyzers ...Analyzer) *Registry {
+	for _, a := range analyzers {
+		if _, exists := r.byName[a.Name()]; exists || isInput(a.Name()) {
+			panic(fmt.Sprintf("analyzer %q is already registered", a.Name()))
+		}
+		for _, dependency := range a.Dependencies() {
+			if _, exists := r.byName[dependency]; !exists && !isInput(dependency) {
+				panic(fmt.Sprintf("analyzer %q depends on unknown analyzer %q", a.Name(), dependency))
+			}
+		}
+
+		r.analyzers = append(r.analyzers, a)
+		r.byName[a.Name()] = a
+	}
+	return r
+}
+
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.analyzers))
+	for _, a := range r.analyzers {
+		names = append(names, a.Name())
+	}
+	return names
+}
+
+func (r *Registry) Describe() []Description {
+	descriptions := make([]Description, 0, len(r.analyzers))
+	for _, a := range r.analyzers {
+		dependencies := a.Dependencies()
+		if dependencies == nil {
+			dependencies = []string{}
+		}
+		descriptions = append(descriptions, Description{Name: a.Name(), Dependencies: dependencies})
+	}
+	return descriptions
+}
+
+// ParseNames splits a comma separated list of analyzer names, e.g. from a query parameter
+func ParseNames(list string) []string {
+	names := []string{}
+	for _, name := range strings.Split(list, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// Validate reports the first name that is not a registered analyzer
+func (r *Registry) Validate(names []string) *webfetch.ErrorResponse {
+	for _, name := range names {
+		if _, exists := r.byName[name]; !exists {
+			message := fmt.Sprintf("Unknown analyzer %q. Available analyzers: %s", name, strings.Join(r.Names(), ", "))
+			return webfetch.BuildErrorResponse(http.StatusBadRequest, message)
+		}
+	}
+	return nil
+}
+
+// selection returns the named analyzers and the analyzers they depend on, in
+// registry order. No names select every analyzer.
+func (r *Registry) selection(names []string) ([]Analyzer, *webfetch.ErrorResponse) {
+	if len(names) == 0 {
+		return r.analyzers, nil
+	}
+	if err := r.Validate(names); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+	var add func(name string)
+	add = func(name string) {
+		if selected[name] || isInput(name) {
+			return
+		}
+		selected[name] = true
+		for _, dependency := range r.byName[name].Dependencies() {
+			add(dependency)
+		}
+	}
+
+	for _, name := range names {
+		add(name)
+	}
+
+	analyzers := []Analyzer{}
+	for _, a := range r.analyzers {
+		if selected[a.Name()] {
+			analyzers = append(analyzers, a)
+		}
+	}
+	return analyzers, nil
+}
+
+// Run runs the named analyzers and those they depend on. Each analyzer starts as
+// soon as its dependencies are done, so independent analyzers run concurrently.
+// The first error cancels the analyzers that have not started yet.
+func (r *Registry) Run(ctx context.Context, names []string, input *Input) (*Results, *webfetch.ErrorResponse) {
+	analyzers, errResponse := r.selection(names)
+	if errResponse != nil {
+		return nil, errResponse
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := newResults()
+	input.results = results
+
+	done := make(map[string]chan struct{}, len(analyzers))
+	for _, a := range analyzers {
+		done[a.Name()] = make(chan struct{})
+	}
+
+	var errOnce sync.Once
+	var firstErr *webfetch.ErrorResponse
+	fail := func(err *webfetch.ErrorResponse) {
+		errOnce.Do(func() {
+			firstErr = err
+			cancel()
+		})
+	}
+
+	var wg sync.WaitGroup
+	for _, a := range analyzers {
+		wg.Add(1)
+		go func(a Analyzer) {
+			defer wg.Done()
+			defer close(done[a.Name()])
+
+			for _, dependency := range a.Dependencies() {
+				if wait, isAnalyzer := done[dependency]; isAnalyzer {
+					select {
+					case <-wait:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			value, err := runSafely(ctx, a, input)
+			if err != nil {
+				input.Logger.Error("Analyzer failed", "analyzer", a.Name(), "error", err.Error)
+				fail(err)
+				return
+			}
+
+			inline := false
+			if inliner, ok := a.(Inliner); ok {
+				inline = inliner.Inline()
+			}
+			results.set(a.Name(), value, inline)
+		}(a)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if ctx.Err() != nil {
+		return nil, webfetch.BuildErrorResponse(http.StatusRequestTimeout, "The analysis was cancelled")
+	}
+
+	results.order(r.Names())
+	return results, nil
+}
+
+// runSafely turns a panic of an analyzer into an error so one analyzer cannot take down the server
+func runSafely(ctx context.Context, a Analyzer, input *Input) (value any, err *webfetch.ErrorResponse) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			err = webfetch.BuildErrorResponse(http.StatusInternalServerError, fmt.Sprintf("Analyzer %s failed: %v", a.Name(), recovered))
+		}
+	}()
+	return a.Run(ctx, input)
+}
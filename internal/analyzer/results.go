@@ -0,0 +1,170 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Results are the sections produced by the analyzers of a page
+type Results struct {
+	mu     sync.RWMutex
+	names  []string
+	values map[string]any
+	inline map[string]bool
+
+	// Fields computed from the sections once the analyzers are done
+	attachedKeys []string
+	attached     map[string]any
+}
+
+func newResults() *Results {
+	return &Results{values: make(map[string]any), inline: make(map[string]bool), attached: make(map[string]any)}
+}
+
+func (r *Results) set(name string, value any, inline bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.names = append(r.names, name)
+	r.values[name] = value
+	r.inline[name] = inline
+}
+
+// order sorts the sections by the position of their analyzer in the registry
+func (r *Results) order(registryOrder []string) {
+	position := make(map[string]int, len(registryOrder))
+	for i, name := range registryOrder {
+		position[name] = i
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sort.SliceStable(r.names, func(i, j int) bool {
+		return position[r.names[i]] < position[r.names[j]]
+	})
+}
+
+// Names returns the analyzers that ran
+func (r *Results) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return append([]string{}, r.names...)
+}
+
+func (r *Results) Get(name string) (any, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, exists := r.values[name]
+	return value, exists
+}
+
+// Attach adds a field that is written after the sections, for results computed
+// from the sections such as budget verdicts. Attaching a key again replaces it.
+func (r *Results) Attach(key string, value any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.attached[key]; !exists {
+		r.attachedKeys = append(r.attachedKeys, key)
+	}
+	r.attached[key] = value
+}
+
+// Section returns the typed result of an analyzer
+func Section[T any](results *Results, name string) (T, bool) {
+	value, found := results.Get(name)
+	section, ok := value.(T)
+	return section, found && ok
+}
+
+// MarshalJSON writes the names of the analyzers that ran followed by their
+// sections. The fields of inline sections are written at the top level.
+func (r *Results) MarshalJSON() ([]byte, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+
+	write := func(key string, value json.RawMessage) {
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		encodedKey, _ := json.Marshal(key)
+		buffer.Write(encodedKey)
+		buffer.WriteByte(':')
+		buffer.Write(value)
+	}
+
+	names, err := json.Marshal(r.names)
+	if err != nil {
+		return nil, err
+	}
+	write("analyzers", names)
+
+	for _, name := range r.names {
+		value, err := json.Marshal(r.values[name])
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", name, err)
+		}
+
+		if !r.inline[name] {
+			write(name, value)
+			continue
+		}
+
+		fields, err := objectFields(value)
+		if err != nil {
+			return nil, fmt.Errorf("inline section %s: %w", name, err)
+		}
+		for _, field := range fields {
+			write(field.key, field.value)
+		}
+	}
+
+	for _, key := range r.attachedKeys {
+		value, err := json.Marshal(r.attached[key])
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", key, err)
+		}
+		write(key, value)
+	}
+
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
+}
+
+type field struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectFields returns the fields of a JSON object in the order they were written
+func objectFields(object []byte) ([]field, error) {
+	decoder := json.NewDecoder(bytes.NewReader(object))
+
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	if delim, ok := token.(json.Delim); !ok || delim != '{' {
+		return nil, fmt.Errorf("expected an object, got %s", object)
+	}
+
+	fields := []field{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		var value json.RawMessage
+		if err := decoder.Decode(&value); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field{key: token.(string), value: value})
+	}
+	return fields, nil
+}
@@ -0,0 +1,206 @@
+package budget
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"lt-app/internal/constants"
+	"lt-app/internal/datafile"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/utils"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Names of the budgets, as written in budget files and reported in verdicts
+const (
+	MaxBrokenLinks       = "maxBrokenLinks"
+	MaxPageSize          = "maxPageSize"
+	MaxThirdPartyDomains = "maxThirdPartyDomains"
+	MinScore             = "minScore"
+	RequireTitle         = "requireTitle"
+	RequireDescription   = "requireDescription"
+	RequireH1            = "requireH1"
+)
+
+var defaultFile = datafile.New(filepath.Join(utils.GetProjectRoot(), constants.BUDGET_FILE_PATH), Parse)
+
+// Default returns the budget bundled with the application, or an empty budget when
+// it has never been read
+func Default() *Budget {
+	if budget := defaultFile.Get(); budget != nil {
+		return budget
+	}
+	return &Budget{}
+}
+
+// Budget holds the limits a page must stay within. Limits that are left out are
+// not checked.
+type Budget struct {
+	Version        string `yaml:"version" json:"version,omitempty"`
+	MaxBrokenLinks *int   `yaml:"maxBrokenLinks" json:"maxBrokenLinks,omitempty"`
+	// Size of the HTML as served, in bytes
+	MaxPageSize          *int `yaml:"maxPageSize" json:"maxPageSize,omitempty"`
+	MaxThirdPartyDomains *int `yaml:"maxThirdPartyDomains" json:"maxThirdPartyDomains,omitempty"`
+	MinScore             *int `yaml:"minScore" json:"minScore,omitempty"`
+	RequireTitle         bool `yaml:"requireTitle" json:"requireTitle,omitempty"`
+	RequireDescription   bool `yaml:"requireDescription" json:"requireDescription,omitempty"`
+	RequireH1            bool `yaml:"requireH1" json:"requireH1,omitempty"`
+}
+
+// Violation is a budget the page did not stay within
+type Violation struct {
+	Budget  string `json:"budget"`
+	Message string `json:"message"`
+	// Only set for numeric budgets
+	Limit  *int `json:"limit,omitempty"`
+	Actual *int `json:"actual,omitempty"`
+}
+
+type Verdict struct {
+	BudgetVersion string `json:"budgetVersion"`
+	// Set when no checked budget was violated
+	Passed     bool        `json:"passed"`
+	Checked    []string    `json:"checked"`
+	Violations []Violation `json:"violations"`
+	// Budgets that were not checked because their analyzer did not run
+	Skipped []string `json:"skipped"`
+}
+
+// Parse reads a budget from YAML or JSON. Unknown keys are rejected, so a misspelt
+// budget fails loudly instead of never being checked.
+func Parse(content []byte) (*Budget, error) {
+	budget := &Budget{}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(budget); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	if err := budget.Validate(); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+// Validate rejects negative limits and minimum scores above 100
+func (b *Budget) Validate() error {
+	limits := []struct {
+		name  string
+		value *int
+	}{
+		{MaxBrokenLinks, b.MaxBrokenLinks},
+		{MaxPageSize, b.MaxPageSize},
+		{MaxThirdPartyDomains, b.MaxThirdPartyDomains},
+		{MinScore, b.MinScore},
+	}
+	for _, limit := range limits {
+		if limit.value != nil && *limit.value < 0 {
+			return fmt.Errorf("%s must not be negative", limit.name)
+		}
+	}
+
+	if b.MinScore != nil && *b.MinScore > 100 {
+		return fmt.Errorf("%s must be at most 100", MinScore)
+	}
+	return nil
+}
+
+// Evaluate checks the stats of a page against the budget. Budgets whose analyzer
+// did not run are reported as skipped and do not fail the verdict.
+func (b *Budget) Evaluate(stats *pagestats.WebPageStats) *Verdict {
+	verdict := &Verdict{BudgetVersion: b.Version, Checked: []string{}, Violations: []Violation{}, Skipped: []string{}}
+
+	check := func(name, analyzerName string, evaluate func(section any) *Violation) {
+		section, ran := stats.Get(analyzerName)
+		if !ran {
+			verdict.Skipped = append(verdict.Skipped, name)
+			return
+		}
+
+		verdict.Checked = append(verdict.Checked, name)
+		if violation := evaluate(section); violation != nil {
+			verdict.Violations = append(verdict.Violations, *violation)
+		}
+	}
+
+	if b.MaxBrokenLinks != nil {
+		check(MaxBrokenLinks, pagestats.AnalyzerLinkChecks, func(section any) *Violation {
+			brokenLinks := section.(pagestats.LinkChecksSection).InaccessibleLinks
+			return atMost(MaxBrokenLinks, brokenLinks, *b.MaxBrokenLinks, "Broken links: %d, the budget allows %d")
+		})
+	}
+	if b.MaxPageSize != nil {
+		check(MaxPageSize, pagestats.AnalyzerPage, func(section any) *Violation {
+			pageSize := section.(pagestats.PageSection).PageSize
+			return atMost(MaxPageSize, pageSize, *b.MaxPageSize, "Page size: %d bytes, the budget allows %d")
+		})
+	}
+	if b.MaxThirdPartyDomains != nil {
+		check(MaxThirdPartyDomains, pagestats.AnalyzerThirdParties, func(section any) *Violation {
+			thirdParties := 0
+			if inventory := section.(pagestats.ThirdPartiesSection).ThirdParties; inventory != nil {
+				thirdParties = inventory.ThirdPartyCount
+			}
+			return atMost(MaxThirdPartyDomains, thirdParties, *b.MaxThirdPartyDomains, "Third-party domains: %d, the budget allows %d")
+		})
+	}
+	if b.MinScore != nil {
+		check(MinScore, pagestats.AnalyzerScore, func(section any) *Violation {
+			pageScore, minScore := 0, *b.MinScore
+			if report := section.(pagestats.ScoreSection).Score; report != nil {
+				pageScore = report.Score
+			}
+			if pageScore >= minScore {
+				return nil
+			}
+			return &Violation{
+				Budget:  MinScore,
+				Message: fmt.Sprintf("The page scores %d, the budget requires at least %d", pageScore, minScore),
+				Limit:   &minScore,
+				Actual:  &pageScore,
+			}
+		})
+	}
+	if b.RequireTitle {
+		check(RequireTitle, pagestats.AnalyzerTitle, func(section any) *Violation {
+			return required(RequireTitle, section.(pagestats.TitleSection).Title, "The page has no title")
+		})
+	}
+	if b.RequireDescription {
+		check(RequireDescription, pagestats.AnalyzerPage, func(section any) *Violation {
+			return required(RequireDescription, section.(pagestats.PageSection).Description, "The page has no meta description")
+		})
+	}
+	if b.RequireH1 {
+		check(RequireH1, pagestats.AnalyzerHeadings, func(section any) *Violation {
+			if section.(pagestats.HeadingsSection).Headings["h1"] > 0 {
+				return nil
+			}
+			return &Violation{Budget: RequireH1, Message: "The page has no h1 heading"}
+		})
+	}
+
+	verdict.Passed = len(verdict.Violations) == 0
+	return verdict
+}
+
+// atMost reports a violation when actual exceeds limit. The message is formatted
+// with the actual value and the limit.
+func atMost(name string, actual, limit int, message string) *Violation {
+	if actual <= limit {
+		return nil
+	}
+	return &Violation{Budget: name, Message: fmt.Sprintf(message, actual, limit), Limit: &limit, Actual: &actual}
+}
+
+func required(name, value, message string) *Violation {
+	if strings.TrimSpace(value) == "" {
+		return &Violation{Budget: name, Message: message}
+	}
+	return nil
+}
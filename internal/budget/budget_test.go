@@ -0,0 +1,153 @@
+package budget
+
+import (
+	"context"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/constants"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/score"
+	"lt-app/internal/thirdparty"
+	"lt-app/internal/utils"
+	"lt-app/internal/webfetch"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// section registers an analyzer that returns a fixed section
+func section[T any](name string, value T) analyzer.Analyzer {
+	return analyzer.NewInline(name, nil, func(_ context.Context, _ *analyzer.Input) (T, *webfetch.ErrorResponse) {
+		return value, nil
+	})
+}
+
+func buildStats(t *testing.T, analyzers ...analyzer.Analyzer) *pagestats.WebPageStats {
+	stats, err := analyzer.NewRegistry().Register(analyzers...).Run(context.Background(), nil, &analyzer.Input{})
+	if err != nil {
+		t.Fatalf("Failed to build the stats: %v", err)
+	}
+	return stats
+}
+
+func limit(value int) *int {
+	return &value
+}
+
+func TestEvaluate(t *testing.T) {
+	stats := buildStats(t,
+		section(pagestats.AnalyzerTitle, pagestats.TitleSection{Title: "Kettles"}),
+		section(pagestats.AnalyzerHeadings, pagestats.HeadingsSection{Headings: map[string]int{"h2": 3}}),
+		section(pagestats.AnalyzerPage, pagestats.PageSection{PageSize: 2048}),
+		section(pagestats.AnalyzerLinkChecks, pagestats.LinkChecksSection{InaccessibleLinks: 2}),
+		section(pagestats.AnalyzerThirdParties, pagestats.ThirdPartiesSection{ThirdParties: &thirdparty.Inventory{ThirdPartyCount: 3}}),
+		section(pagestats.AnalyzerScore, pagestats.ScoreSection{Score: &score.Report{Score: 64}}),
+	)
+
+	budget := &Budget{
+		Version:              "test",
+		MaxBrokenLinks:       limit(0),
+		MaxPageSize:          limit(4096),
+		MaxThirdPartyDomains: limit(3),
+		MinScore:             limit(70),
+		RequireTitle:         true,
+		RequireDescription:   true,
+		RequireH1:            true,
+	}
+	verdict := budget.Evaluate(stats)
+
+	if verdict.Passed || verdict.BudgetVersion != "test" || len(verdict.Skipped) != 0 || len(verdict.Checked) != 7 {
+		t.Errorf("Expected every budget to be checked and the verdict to fail, got %+v", verdict)
+	}
+
+	expected := []Violation{
+		{Budget: MaxBrokenLinks, Message: "Broken links: 2, the budget allows 0", Limit: limit(0), Actual: limit(2)},
+		{Budget: MinScore, Message: "The page scores 64, the budget requires at least 70", Limit: limit(70), Actual: limit(64)},
+		{Budget: RequireDescription, Message: "The page has no meta description"},
+		{Budget: RequireH1, Message: "The page has no h1 heading"},
+	}
+	if !reflect.DeepEqual(verdict.Violations, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, verdict.Violations)
+	}
+}
+
+func TestEvaluate_SkipsAnalyzersThatDidNotRun(t *testing.T) {
+	stats := buildStats(t, section(pagestats.AnalyzerTitle, pagestats.TitleSection{Title: "Kettles"}))
+
+	verdict := (&Budget{RequireTitle: true, MinScore: limit(90), MaxBrokenLinks: limit(0)}).Evaluate(stats)
+
+	if !verdict.Passed {
+		t.Errorf("Expected skipped budgets not to fail the verdict, got %+v", verdict)
+	}
+	if !reflect.DeepEqual(verdict.Checked, []string{RequireTitle}) || !reflect.DeepEqual(verdict.Skipped, []string{MaxBrokenLinks, MinScore}) {
+		t.Errorf("Expected only the title to be checked, got %+v", verdict)
+	}
+}
+
+func TestEvaluate_EmptyBudget(t *testing.T) {
+	verdict := (&Budget{}).Evaluate(buildStats(t))
+
+	if !verdict.Passed || len(verdict.Checked) != 0 || len(verdict.Violations) != 0 {
+		t.Errorf("Expected an empty budget to pass, got %+v", verdict)
+	}
+}
+
+func TestParse(t *testing.T) {
+	budget, err := Parse([]byte("maxBrokenLinks: 0\nminScore: 80\nrequireH1: true\n"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if *budget.MaxBrokenLinks != 0 || *budget.MinScore != 80 || !budget.RequireH1 || budget.MaxPageSize != nil || budget.RequireTitle {
+		t.Errorf("Unexpected budget %+v", budget)
+	}
+
+	// JSON is valid YAML
+	if budget, err := Parse([]byte(`{"maxPageSize": 500000}`)); err != nil || *budget.MaxPageSize != 500000 {
+		t.Errorf("Expected a JSON budget to be read, got %+v, %v", budget, err)
+	}
+
+	if budget, err := Parse(nil); err != nil || budget.MinScore != nil {
+		t.Errorf("Expected an empty budget, got %+v, %v", budget, err)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown key":     "maxBrokenLink: 0",
+		"negative limit":  "maxPageSize: -1",
+		"score above 100": "minScore: 101",
+		"not a number":    "minScore: high",
+		"malformed":       "minScore: [",
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(content)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestParse_BundledFile(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join(utils.GetProjectRoot(), constants.BUDGET_FILE_PATH))
+	if err != nil {
+		t.Fatalf("Failed to read the bundled budget: %v", err)
+	}
+
+	budget, err := Parse(content)
+	if err != nil {
+		t.Fatalf("Expected the bundled budget to be valid, got %v", err)
+	}
+	if budget.MinScore == nil || !budget.RequireTitle {
+		t.Errorf("Expected the bundled budget to set limits, got %+v", budget)
+	}
+
+	// The example in the header comment lists every budget
+	for _, name := range []string{MaxBrokenLinks, MaxPageSize, MaxThirdPartyDomains, MinScore, RequireTitle, RequireDescription, RequireH1} {
+		if !strings.Contains(string(content), "#   "+name+":") {
+			t.Errorf("Expected the bundled budget to document %s", name)
+		}
+	}
+}
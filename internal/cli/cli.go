@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/applogger"
+	"lt-app/internal/budget"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/services"
+	"lt-app/internal/utils"
+	"os"
+)
+
+// Exit codes of the commands
+const (
+	ExitOK = 0
+	// The page was analyzed but violated its budget
+	ExitBudgetFailed = 1
+	// Invalid arguments or the page could not be analyzed
+	ExitError = 2
+	// diff found changes between the analyses
+	ExitChanged = 3
+)
+
+const usage = `Usage:
+  lt-app                        start the server
+  lt-app serve                  start the server
+  lt-app analyze [flags] <url>  analyze a page and check it against a budget
+  lt-app diff [flags] <a> <b>   compare two analyses, each a JSON file of analyze
+                                or the ID of a run in the history
+`
+
+// fetchWebPageStats is replaced in tests so that no page is requested
+var fetchWebPageStats = services.FetchWebPageStats
+
+// Run runs the command named by the first argument and returns the exit code.
+// The server is started by the caller, since it does not return.
+func Run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		printUsage(stderr)
+		return ExitError
+	}
+
+	switch args[0] {
+	case "analyze":
+		return analyze(ctx, args[1:], stdout, stderr)
+	case "diff":
+		return diffCommand(args[1:], stdout, stderr)
+	case "help", "-h", "-help", "--help":
+		printUsage(stdout)
+		return ExitOK
+	default:
+		fmt.Fprintf(stderr, "Unknown command %q\n\n", args[0])
+		printUsage(stderr)
+		return ExitError
+	}
+}
+
+func printUsage(output io.Writer) {
+	fmt.Fprint(output, usage)
+	fmt.Fprint(output, "\nFlags of analyze:\n")
+	analyzeFlags, _ := newAnalyzeFlags(output)
+	analyzeFlags.PrintDefaults()
+	fmt.Fprint(output, "\nFlags of diff:\n")
+	diffFlags, _ := newDiffFlags(output)
+	diffFlags.PrintDefaults()
+}
+
+type analyzeOptions struct {
+	analyzers  string
+	budgetPath string
+	noBudget   bool
+	quiet      bool
+}
+
+func newAnalyzeFlags(output io.Writer) (*flag.FlagSet, *analyzeOptions) {
+	options := &analyzeOptions{}
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&options.analyzers, "analyzers", "", "comma separated analyzers to run, every analyzer by default")
+	flags.StringVar(&options.budgetPath, "budget", "", "YAML or JSON budget file, the bundled data/budget.yaml by default")
+	flags.BoolVar(&options.noBudget, "no-budget", false, "do not check a budget")
+	flags.BoolVar(&options.quiet, "quiet", false, "only print the verdict, not the stats")
+	return flags, options
+}
+
+// analyze prints the stats of the page as JSON on stdout and the budget verdict
+// on stderr. It fails with ExitBudgetFailed when a budget is violated.
+func analyze(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags, options := newAnalyzeFlags(stderr)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitError
+	}
+	if flags.NArg() != 1 {
+		fmt.Fprintln(stderr, "analyze expects a single url")
+		return ExitError
+	}
+
+	webPageUrl := flags.Arg(0)
+	if !utils.IsValidURL(webPageUrl) {
+		fmt.Fprintf(stderr, "Invalid url format: %s\n", webPageUrl)
+		return ExitError
+	}
+
+	analyzers := analyzer.ParseNames(options.analyzers)
+	if err := pagestats.DefaultRegistry.Validate(analyzers); err != nil {
+		fmt.Fprintln(stderr, err.Error)
+		return ExitError
+	}
+
+	// Logs go to stderr so that stdout only holds the stats
+	logger := slog.New(slog.NewJSONHandler(stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	applogger.Logger = logger
+
+	pageBudget, err := loadBudget(options)
+	if err != nil {
+		fmt.Fprintf(stderr, "Invalid budget: %v\n", err)
+		return ExitError
+	}
+
+	stats, fetchErr := fetchWebPageStats(ctx, webPageUrl, services.AnalyzeOptions{Analyzers: analyzers}, logger)
+	if fetchErr != nil {
+		fmt.Fprintf(stderr, "Failed to analyze %s: %s (status %d)\n", webPageUrl, fetchErr.Error, fetchErr.StatusCode)
+		return ExitError
+	}
+
+	var verdict *budget.Verdict
+	if pageBudget != nil {
+		verdict = pageBudget.Evaluate(stats)
+		stats.Attach("budget", verdict)
+	}
+
+	if !options.quiet {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(stats); err != nil {
+			fmt.Fprintf(stderr, "Failed to write the stats: %v\n", err)
+			return ExitError
+		}
+	}
+
+	if verdict == nil {
+		return ExitOK
+	}
+	printVerdict(stderr, verdict)
+	if !verdict.Passed {
+		return ExitBudgetFailed
+	}
+	return ExitOK
+}
+
+func loadBudget(options *analyzeOptions) (*budget.Budget, error) {
+	if options.noBudget {
+		return nil, nil
+	}
+	if options.budgetPath == "" {
+		return budget.Default(), nil
+	}
+
+	content, err := os.ReadFile(options.budgetPath)
+	if err != nil {
+		return nil, err
+	}
+	return budget.Parse(content)
+}
+
+func printVerdict(output io.Writer, verdict *budget.Verdict) {
+	if verdict.Passed {
+		fmt.Fprintf(output, "Budget passed: %d checked", len(verdict.Checked))
+	} else {
+		fmt.Fprintf(output, "Budget failed: %d of %d violated", len(verdict.Violations), len(verdict.Checked))
+	}
+	if len(verdict.Skipped) > 0 {
+		fmt.Fprintf(output, ", %d skipped", len(verdict.Skipped))
+	}
+	fmt.Fprintln(output)
+
+	for _, violation := range verdict.Violations {
+		fmt.Fprintf(output, "  %s: %s\n", violation.Budget, violation.Message)
+	}
+}
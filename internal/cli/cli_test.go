@@ -0,0 +1,153 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/applogger"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/services"
+	"lt-app/internal/webfetch"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubFetch replaces the page request with stats holding a title and headings
+func stubFetch(t *testing.T, title string, fetchErr *webfetch.ErrorResponse) {
+	original := fetchWebPageStats
+	t.Cleanup(func() { fetchWebPageStats = original })
+
+	fetchWebPageStats = func(ctx context.Context, _ string, _ services.AnalyzeOptions, _ *slog.Logger) (*pagestats.WebPageStats, *webfetch.ErrorResponse) {
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+		registry := analyzer.NewRegistry().Register(
+			analyzer.NewInline(pagestats.AnalyzerTitle, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.TitleSection, *webfetch.ErrorResponse) {
+				return pagestats.TitleSection{Title: title}, nil
+			}),
+			analyzer.NewInline(pagestats.AnalyzerHeadings, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.HeadingsSection, *webfetch.ErrorResponse) {
+				return pagestats.HeadingsSection{Headings: map[string]int{"h1": 1}}, nil
+			}),
+		)
+		return registry.Run(ctx, nil, &analyzer.Input{})
+	}
+}
+
+func writeBudget(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "budget.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write the budget: %v", err)
+	}
+	return path
+}
+
+func run(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	exitCode := Run(context.Background(), args, &stdout, &stderr)
+	return exitCode, stdout.String(), stderr.String()
+}
+
+func TestAnalyze_BudgetPassed(t *testing.T) {
+	applogger.InitLogger()
+	stubFetch(t, "Kettles", nil)
+	budgetPath := writeBudget(t, "requireTitle: true\nrequireH1: true\nminScore: 80\n")
+
+	exitCode, stdout, stderr := run("analyze", "-budget", budgetPath, "https://example.com")
+
+	if exitCode != ExitOK {
+		t.Errorf("Expected exit code %d, got %d: %s", ExitOK, exitCode, stderr)
+	}
+
+	var stats struct {
+		Title  string `json:"title"`
+		Budget struct {
+			Passed  bool     `json:"passed"`
+			Skipped []string `json:"skipped"`
+		} `json:"budget"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &stats); err != nil {
+		t.Fatalf("Expected the stats as JSON, got %s", stdout)
+	}
+	if stats.Title != "Kettles" || !stats.Budget.Passed || len(stats.Budget.Skipped) != 1 {
+		t.Errorf("Expected the stats with a passed verdict, got %+v", stats)
+	}
+
+	if !strings.Contains(stderr, "Budget passed: 2 checked, 1 skipped") {
+		t.Errorf("Expected the verdict on stderr, got %s", stderr)
+	}
+}
+
+func TestAnalyze_BudgetFailed(t *testing.T) {
+	applogger.InitLogger()
+	stubFetch(t, "", nil)
+	budgetPath := writeBudget(t, "requireTitle: true\n")
+
+	exitCode, stdout, stderr := run("analyze", "-quiet", "-budget", budgetPath, "https://example.com")
+
+	if exitCode != ExitBudgetFailed {
+		t.Errorf("Expected exit code %d, got %d", ExitBudgetFailed, exitCode)
+	}
+	if stdout != "" {
+		t.Errorf("Expected no stats with -quiet, got %s", stdout)
+	}
+	if !strings.Contains(stderr, "Budget failed: 1 of 1 violated") || !strings.Contains(stderr, "requireTitle: The page has no title") {
+		t.Errorf("Expected the violation on stderr, got %s", stderr)
+	}
+}
+
+func TestAnalyze_NoBudget(t *testing.T) {
+	applogger.InitLogger()
+	stubFetch(t, "", nil)
+
+	exitCode, stdout, stderr := run("analyze", "-no-budget", "https://example.com")
+
+	if exitCode != ExitOK || strings.Contains(stdout, `"budget"`) || stderr != "" {
+		t.Errorf("Expected no verdict, got %d %s %s", exitCode, stdout, stderr)
+	}
+}
+
+func TestAnalyze_Errors(t *testing.T) {
+	applogger.InitLogger()
+	stubFetch(t, "", webfetch.BuildErrorResponse(http.StatusNotFound, ""))
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"no command", nil, "Usage:"},
+		{"unknown command", []string{"inspect"}, `Unknown command "inspect"`},
+		{"no url", []string{"analyze"}, "analyze expects a single url"},
+		{"invalid url", []string{"analyze", "not a url"}, "Invalid url format"},
+		{"unknown flag", []string{"analyze", "-budgets", "x", "https://example.com"}, "flag provided but not defined"},
+		{"unknown analyzer", []string{"analyze", "-analyzers", "title,unknown", "https://example.com"}, `Unknown analyzer "unknown"`},
+		{"missing budget", []string{"analyze", "-budget", filepath.Join(t.TempDir(), "missing.yaml"), "https://example.com"}, "Invalid budget"},
+		{"invalid budget", []string{"analyze", "-budget", writeBudget(t, "minScore: -1"), "https://example.com"}, "Invalid budget: minScore must not be negative"},
+		{"page not found", []string{"analyze", "-no-budget", "https://example.com"}, "Failed to analyze https://example.com: Page not found"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exitCode, _, stderr := run(test.args...)
+			if exitCode != ExitError {
+				t.Errorf("Expected exit code %d, got %d", ExitError, exitCode)
+			}
+			if !strings.Contains(stderr, test.expected) {
+				t.Errorf("Expected %q in %s", test.expected, stderr)
+			}
+		})
+	}
+}
+
+func TestHelp(t *testing.T) {
+	exitCode, stdout, _ := run("help")
+
+	if exitCode != ExitOK || !strings.Contains(stdout, "lt-app analyze [flags] <url>") || !strings.Contains(stdout, "-budget") || !strings.Contains(stdout, "lt-app diff [flags] <a> <b>") {
+		t.Errorf("Expected the usage, got %d %s", exitCode, stdout)
+	}
+}
@@ -0,0 +1,190 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"lt-app/internal/constants"
+	"lt-app/internal/diff"
+	"lt-app/internal/history"
+	"lt-app/internal/pagedata"
+	"lt-app/internal/storage"
+	"lt-app/internal/utils"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+type diffOptions struct {
+	dbPath string
+	json   bool
+}
+
+func newDiffFlags(output io.Writer) (*flag.FlagSet, *diffOptions) {
+	options := &diffOptions{}
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(output)
+	flags.StringVar(&options.dbPath, "db", filepath.Join(utils.GetProjectRoot(), constants.STORAGE_DB_PATH), "database holding the history, for runs given by ID")
+	flags.BoolVar(&options.json, "json", false, "print the diff as JSON")
+	return flags, options
+}
+
+// diffCommand prints the changes from the first analysis to the second and fails
+// with ExitChanged when there are changes. Runs of the history must be of the
+// same URL; files do not record their URL, so they are not checked.
+func diffCommand(args []string, stdout, stderr io.Writer) int {
+	flags, options := newDiffFlags(stderr)
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return ExitOK
+		}
+		return ExitError
+	}
+	if flags.NArg() != 2 {
+		fmt.Fprintln(stderr, "diff expects two analyses")
+		return ExitError
+	}
+
+	loader := &snapshotLoader{dbPath: options.dbPath}
+	defer loader.close()
+
+	var snapshots [2]*diff.Snapshot
+	var urls [2]string
+	for i, source := range flags.Args() {
+		snapshot, url, err := loader.load(source)
+		if err != nil {
+			fmt.Fprintf(stderr, "Failed to read %s: %v\n", source, err)
+			return ExitError
+		}
+		snapshots[i], urls[i] = snapshot, url
+	}
+	if urls[0] != "" && urls[1] != "" && urls[0] != urls[1] {
+		fmt.Fprintf(stderr, "Runs %s and %s are analyses of different URLs: %s and %s\n", flags.Arg(0), flags.Arg(1), urls[0], urls[1])
+		return ExitError
+	}
+
+	changes := diff.Compare(snapshots[0], snapshots[1])
+	if options.json {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changes); err != nil {
+			fmt.Fprintf(stderr, "Failed to write the diff: %v\n", err)
+			return ExitError
+		}
+	} else {
+		printDiff(stdout, changes)
+	}
+
+	if changes.Changed {
+		return ExitChanged
+	}
+	return ExitOK
+}
+
+// snapshotLoader reads analyses from files, or from the history when the source
+// is an ID and no such file exists. The database is opened once, when needed.
+// The URL of an analysis is only known for runs of the history.
+type snapshotLoader struct {
+	dbPath string
+	db     *bolt.DB
+	store  *history.Store
+}
+
+func (l *snapshotLoader) load(source string) (*diff.Snapshot, string, error) {
+	var url string
+	content, err := os.ReadFile(source)
+	if errors.Is(err, os.ErrNotExist) {
+		if _, parseErr := strconv.ParseUint(source, 10, 64); parseErr == nil {
+			url, content, err = l.loadRun(source)
+		}
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	snapshot, err := diff.Parse(content)
+	return snapshot, url, err
+}
+
+func (l *snapshotLoader) loadRun(id string) (string, []byte, error) {
+	if l.store == nil {
+		// The server keeps the database locked while it runs
+		db, err := storage.Open(l.dbPath)
+		if err != nil {
+			return "", nil, fmt.Errorf("failed to open the history at %s, it is locked while the server runs: %w", l.dbPath, err)
+		}
+		store, err := history.New(db, history.Retention{})
+		if err != nil {
+			db.Close()
+			return "", nil, err
+		}
+		l.db, l.store = db, store
+	}
+
+	run, result, err := l.store.Get(id)
+	if err != nil {
+		return "", nil, err
+	}
+	return run.URL, result, nil
+}
+
+func (l *snapshotLoader) close() {
+	if l.db != nil {
+		l.db.Close()
+	}
+}
+
+func printDiff(output io.Writer, changes *diff.Diff) {
+	if changes.Title != nil && changes.Title.Changed {
+		fmt.Fprintf(output, "Title: %q -> %q\n", changes.Title.Before, changes.Title.After)
+	}
+	if changes.Description != nil && changes.Description.Changed {
+		fmt.Fprintf(output, "Description: %q -> %q\n", changes.Description.Before, changes.Description.After)
+	}
+	if changes.Score != nil && (changes.Score.Delta != 0 || changes.Score.GradeBefore != changes.Score.GradeAfter) {
+		fmt.Fprintf(output, "Score: %d (%s) -> %d (%s), %+d\n", changes.Score.Before, changes.Score.GradeBefore, changes.Score.After, changes.Score.GradeAfter, changes.Score.Delta)
+		for _, category := range changes.Score.Categories {
+			if category.Delta != 0 {
+				fmt.Fprintf(output, "  %s: %d -> %d, %+d\n", category.Category, category.Before, category.After, category.Delta)
+			}
+		}
+	}
+
+	printHeadings(output, "Headings added", "+", changes.HeadingsAdded)
+	printHeadings(output, "Headings removed", "-", changes.HeadingsRemoved)
+	printLinks(output, "Links added", "+", changes.LinksAdded)
+	printLinks(output, "Links removed", "-", changes.LinksRemoved)
+	printLinks(output, "Newly broken links", "+", changes.NewlyBroken)
+	printLinks(output, "Newly fixed links", "-", changes.NewlyFixed)
+
+	if !changes.Changed {
+		fmt.Fprintln(output, "No changes")
+	}
+	if len(changes.NotCompared) > 0 {
+		fmt.Fprintf(output, "Not compared: %s\n", strings.Join(changes.NotCompared, ", "))
+	}
+}
+
+func printHeadings(output io.Writer, label, marker string, headings []pagedata.Heading) {
+	if len(headings) == 0 {
+		return
+	}
+	fmt.Fprintf(output, "%s:\n", label)
+	for _, heading := range headings {
+		fmt.Fprintf(output, "  %s %s %s\n", marker, heading.Tag, heading.Text)
+	}
+}
+
+func printLinks(output io.Writer, label, marker string, links []string) {
+	if len(links) == 0 {
+		return
+	}
+	fmt.Fprintf(output, "%s:\n", label)
+	for _, link := range links {
+		fmt.Fprintf(output, "  %s %s\n", marker, link)
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"encoding/json"
+	"lt-app/internal/history"
+	"lt-app/internal/storage"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeStats(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "stats.json")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write the stats: %v", err)
+	}
+	return path
+}
+
+const statsBefore = `{"title": "Kettles", "outline": [{"tag": "h1", "text": "Kettles"}], "links": ["https://example.com/a"], "brokenLinks": ["https://example.com/a"]}`
+const statsAfter = `{"title": "Teapots", "outline": [{"tag": "h1", "text": "Kettles"}, {"tag": "h2", "text": "Glass"}], "links": ["https://example.com/a"], "brokenLinks": []}`
+
+func TestDiff_Files(t *testing.T) {
+	a, b := writeStats(t, statsBefore), writeStats(t, statsAfter)
+
+	exitCode, stdout, stderr := run("diff", a, b)
+
+	if exitCode != ExitChanged {
+		t.Errorf("Expected exit code %d, got %d: %s", ExitChanged, exitCode, stderr)
+	}
+	for _, expected := range []string{
+		`Title: "Kettles" -> "Teapots"`,
+		"Headings added:\n  + h2 Glass\n",
+		"Newly fixed links:\n  - https://example.com/a\n",
+		"Not compared: description, score",
+	} {
+		if !strings.Contains(stdout, expected) {
+			t.Errorf("Expected %q in %s", expected, stdout)
+		}
+	}
+}
+
+func TestDiff_Unchanged(t *testing.T) {
+	a := writeStats(t, statsBefore)
+
+	exitCode, stdout, _ := run("diff", "-json", a, a)
+
+	var changes struct {
+		Changed bool `json:"changed"`
+	}
+	if exitCode != ExitOK || json.Unmarshal([]byte(stdout), &changes) != nil || changes.Changed {
+		t.Errorf("Expected no changes as JSON, got %d %s", exitCode, stdout)
+	}
+}
+
+func TestDiff_History(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.Open(dbPath)
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	store, _ := history.New(db, history.Retention{})
+	store.Save("https://example.com", []byte(statsBefore))
+	store.Save("https://example.com", []byte(statsAfter))
+	store.Save("https://example.org", []byte(statsAfter))
+	db.Close()
+
+	// A run of the history compared with a file
+	exitCode, stdout, stderr := run("diff", "-db", dbPath, "1", writeStats(t, statsAfter))
+
+	if exitCode != ExitChanged || !strings.Contains(stdout, `Title: "Kettles" -> "Teapots"`) {
+		t.Errorf("Expected the run to be compared, got %d %s %s", exitCode, stdout, stderr)
+	}
+
+	if exitCode, stdout, stderr := run("diff", "-db", dbPath, "1", "2"); exitCode != ExitChanged {
+		t.Errorf("Expected the runs to be compared, got %d %s %s", exitCode, stdout, stderr)
+	}
+
+	// Runs of different URLs are not compared
+	exitCode, _, stderr = run("diff", "-db", dbPath, "1", "3")
+	if exitCode != ExitError || !strings.Contains(stderr, "Runs 1 and 3 are analyses of different URLs: https://example.com and https://example.org") {
+		t.Errorf("Expected runs of different URLs to be rejected, got %d %s", exitCode, stderr)
+	}
+}
+
+func TestDiff_Errors(t *testing.T) {
+	dbPath := filepath.Join(t.TempDir(), "test.db")
+	a := writeStats(t, statsBefore)
+
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"one analysis", []string{"diff", a}, "diff expects two analyses"},
+		{"missing file", []string{"diff", a, "missing.json"}, "Failed to read missing.json"},
+		{"unknown run", []string{"diff", "-db", dbPath, a, "7"}, "Failed to read 7: run not found"},
+		{"invalid stats", []string{"diff", a, writeStats(t, "{")}, "unexpected end of JSON input"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exitCode, _, stderr := run(test.args...)
+			if exitCode != ExitError {
+				t.Errorf("Expected exit code %d, got %d", ExitError, exitCode)
+			}
+			if !strings.Contains(stderr, test.expected) {
+				t.Errorf("Expected %q in %s", test.expected, stderr)
+			}
+		})
+	}
+}
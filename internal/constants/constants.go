@@ -3,8 +3,85 @@ package constants
 const INACC_LINKS_MAX_CAP = 300
 const INACC_LINKS_INIT_CAP = 20
 const CONCURRENT_GOROUTINE_LIMIT = 20
+const ANCHOR_PAGES_MAX_CAP = 20
+const ASSETS_CHECK_MAX_CAP = 300
+const TLS_EXPIRY_WARNING_DAYS = 30
 const REQUEST_TIMEOUT_SECONDS = 5
 const MB_IN_BYTES = 1048576
 const MAX_ALLOWED_HTML_SIZE = 5 * MB_IN_BYTES
 
+// Link checks read at most this much of a response to time the start of its transfer
+const LINK_CHECK_MAX_BODY_BYTES = 16 * 1024
+
 const SERVER_PORT = 3000
+const VALIDATION_MAX_ISSUES = 200
+
+// Classification of third-party domains, relative to the project root
+const TRACKERS_FILE_PATH = "data/trackers.json"
+
+// Technology fingerprinting signatures, relative to the project root
+const TECHNOLOGIES_FILE_PATH = "data/technologies.json"
+
+// User-defined page rules (YAML or JSON), relative to the project root
+const RULES_FILE_PATH = "data/rules.yaml"
+
+// Named CSS selectors and XPath expressions accepted per request, and values returned per extractor
+const EXTRACTORS_MAX_CAP = 50
+const EXTRACT_VALUES_MAX_CAP = 100
+
+// Starlark scripts of custom analyzers, relative to the project root
+const SCRIPTS_DIR_PATH = "scripts"
+
+// Limits of a single script run against a page
+const SCRIPT_TIMEOUT_MS = 2000
+const SCRIPT_MAX_EXECUTION_STEPS = 10_000_000
+const SCRIPT_SELECT_MAX_ELEMENTS = 1000
+const SCRIPT_ELEMENT_CONTENT_MAX_BYTES = 100_000
+const SCRIPT_FINDINGS_MAX_CAP = 100
+const SCRIPT_OUTPUT_MAX_LINES = 50
+
+// Weights of the page quality score, relative to the project root
+const SCORE_WEIGHTS_FILE_PATH = "data/score.yaml"
+
+// Targets listed per finding that contributed to the score
+const SCORE_TARGETS_MAX_CAP = 10
+
+// Budget checked against every analyzed page unless the request supplies its own
+const BUDGET_FILE_PATH = "data/budget.yaml"
+
+// Embedded database holding the analysis history, relative to the project root
+const STORAGE_DB_PATH = "storage/lt-app.db"
+
+// Retention of the analysis history. Older runs and runs beyond the count are removed.
+const HISTORY_MAX_AGE_DAYS = 90
+const HISTORY_MAX_RUNS_PER_URL = 100
+
+// Runs listed by GET /api/history
+const HISTORY_LIST_DEFAULT_LIMIT = 20
+const HISTORY_LIST_MAX_LIMIT = 200
+
+// Limits of scheduled monitoring. Schedules may not run more often than the interval.
+const MONITOR_MAX_CAP = 100
+const MONITOR_MIN_INTERVAL_SECONDS = 60
+
+// Runs of a cron expression compared to find its shortest interval, at least a week
+// of runs for schedules that run up to once a minute
+const MONITOR_SCHEDULE_CHECK_RUNS = 10000
+const MONITOR_RUN_TIMEOUT_SECONDS = 120
+
+// Days before a certificate expires from which monitors raise an alert, unless set per monitor
+const MONITOR_TLS_EXPIRY_DAYS = 14
+
+// Alerts kept per monitor and listed by GET /api/monitors/:id/alerts
+const MONITOR_ALERTS_MAX_PER_MONITOR = 100
+const MONITOR_ALERTS_LIST_DEFAULT_LIMIT = 20
+
+// Limits of outbound webhooks. Failed deliveries are retried after the delay, doubled for every attempt.
+const WEBHOOK_MAX_CAP = 20
+const WEBHOOK_MAX_ATTEMPTS = 5
+const WEBHOOK_RETRY_DELAY_SECONDS = 10
+const WEBHOOK_TIMEOUT_SECONDS = 10
+
+// Deliveries kept per subscription and listed by GET /api/webhooks/:id/deliveries
+const WEBHOOK_DELIVERIES_MAX_PER_SUBSCRIPTION = 100
+const WEBHOOK_DELIVERIES_LIST_DEFAULT_LIMIT = 20
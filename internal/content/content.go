@@ -0,0 +1,305 @@
+package content
+
+import (
+	"lt-app/internal/language"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	// Average silent reading speed of adults
+	wordsPerMinute = 238
+	topTermsCount  = 10
+	excerptLength  = 300
+)
+
+type Term struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+	// Share of the words of the main content, in percent
+	Density float64 `json:"density"`
+}
+
+// Readability is only computed for English, the formulas are calibrated for it
+type Readability struct {
+	FleschReadingEase  float64 `json:"fleschReadingEase"`
+	FleschKincaidGrade float64 `json:"fleschKincaidGrade"`
+	Level              string  `json:"level"`
+}
+
+type Report struct {
+	// Element the main content was taken from, e.g. article or div#content
+	Source string `json:"source"`
+	// Language of the html lang attribute, else the reliably detected language of the text
+	Language string `json:"language"`
+	Excerpt  string `json:"excerpt"`
+	// The whole text is only kept for the language analyzer, the excerpt is returned instead
+	Text               string       `json:"-"`
+	WordCount          int          `json:"wordCount"`
+	SentenceCount      int          `json:"sentenceCount"`
+	ParagraphCount     int          `json:"paragraphCount"`
+	ReadingTimeMinutes int          `json:"readingTimeMinutes"`
+	Readability        *Readability `json:"readability"`
+	// Visible text of the whole page compared to the size of its HTML, in percent
+	TextToHTMLRatio float64 `json:"textToHtmlRatio"`
+	Keywords        []Term  `json:"keywords"`
+	Phrases         []Term  `json:"phrases"`
+}
+
+// Analyze extracts the main content of the page and measures it
+func Analyze(doc *goquery.Document, rawHTML string) *Report {
+	report := &Report{Keywords: []Term{}, Phrases: []Term{}}
+	if doc == nil {
+		return report
+	}
+
+	report.Language = strings.ToLower(strings.TrimSpace(doc.Find("html").AttrOr("lang", "")))
+
+	main, source := extractMainContent(doc)
+	report.Source = source
+	report.Text = extractText(main)
+	report.Excerpt = excerpt(report.Text)
+	if report.Language == "" {
+		if detection := language.Detect(report.Text); detection.Reliable {
+			report.Language = detection.Language
+		}
+	}
+
+	paragraphs := []string{}
+	if report.Text != "" {
+		paragraphs = strings.Split(report.Text, "\n\n")
+	}
+	report.ParagraphCount = countParagraphs(main, len(paragraphs))
+
+	var sentences [][]string
+	for _, paragraph := range paragraphs {
+		for _, sentence := range splitSentences(paragraph) {
+			if words := splitWords(sentence); len(words) > 0 {
+				sentences = append(sentences, words)
+			}
+		}
+	}
+	report.SentenceCount = len(sentences)
+
+	syllables := 0
+	for _, sentence := range sentences {
+		report.WordCount += len(sentence)
+		for _, word := range sentence {
+			syllables += countSyllables(word)
+		}
+	}
+
+	if report.WordCount > 0 {
+		report.ReadingTimeMinutes = int(math.Ceil(float64(report.WordCount) / wordsPerMinute))
+	}
+
+	if isEnglish(report.Language) && report.WordCount > 0 {
+		report.Readability = readability(report.WordCount, report.SentenceCount, syllables)
+	}
+
+	if len(rawHTML) > 0 {
+		pageText := extractText(doc.Find("body"))
+		report.TextToHTMLRatio = round(float64(len(pageText)) / float64(len(rawHTML)) * 100)
+	}
+
+	report.Keywords, report.Phrases = topTerms(sentences, report.WordCount)
+
+	return report
+}
+
+// countParagraphs counts the <p> elements with text. Content without any is counted
+// by its text blocks instead.
+func countParagraphs(main *goquery.Selection, blocks int) int {
+	count := 0
+	main.Find("p").Each(func(_ int, s *goquery.Selection) {
+		if strings.TrimSpace(s.Text()) != "" {
+			count++
+		}
+	})
+	if count == 0 {
+		return blocks
+	}
+	return count
+}
+
+func isEnglish(language string) bool {
+	return language == "en" || strings.HasPrefix(language, "en-")
+}
+
+func readability(words int, sentences int, syllables int) *Readability {
+	wordsPerSentence := float64(words) / float64(max(sentences, 1))
+	syllablesPerWord := float64(syllables) / float64(words)
+
+	ease := 206.835 - 1.015*wordsPerSentence - 84.6*syllablesPerWord
+	grade := 0.39*wordsPerSentence + 11.8*syllablesPerWord - 15.59
+
+	return &Readability{
+		FleschReadingEase:  round(ease),
+		FleschKincaidGrade: round(max(grade, 0)),
+		Level:              readingLevel(ease),
+	}
+}
+
+// readingLevel follows the bands of the Flesch reading ease table
+func readingLevel(ease float64) string {
+	switch {
+	case ease >= 90:
+		return "very easy"
+	case ease >= 80:
+		return "easy"
+	case ease >= 70:
+		return "fairly easy"
+	case ease >= 60:
+		return "standard"
+	case ease >= 50:
+		return "fairly difficult"
+	case ease >= 30:
+		return "difficult"
+	default:
+		return "very difficult"
+	}
+}
+
+// A sentence ends with terminal punctuation followed by a space or the end of the text
+var sentenceEnd = regexp.MustCompile(`[.!?。！？]+["'”’)\]]*(\s+|$)`)
+
+func splitSentences(paragraph string) []string {
+	sentences := []string{}
+	start := 0
+	for _, match := range sentenceEnd.FindAllStringIndex(paragraph, -1) {
+		sentences = append(sentences, paragraph[start:match[1]])
+		start = match[1]
+	}
+	if rest := strings.TrimSpace(paragraph[start:]); rest != "" {
+		sentences = append(sentences, rest)
+	}
+	return sentences
+}
+
+// splitWords returns the lower case words of the text. Apostrophes and hyphens
+// inside a word are kept, e.g. "don't" and "e-mail".
+func splitWords(text string) []string {
+	words := []string{}
+	for _, field := range strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\'' && r != '’' && r != '-'
+	}) {
+		field = strings.Trim(field, "'’-")
+		if field != "" {
+			words = append(words, strings.ToLower(field))
+		}
+	}
+	return words
+}
+
+// countSyllables estimates the syllables of an English word from its vowel groups
+func countSyllables(word string) int {
+	word = strings.ToLower(word)
+	if utf8.RuneCountInString(word) <= 3 {
+		return 1
+	}
+
+	count := 0
+	previousVowel := false
+	for _, r := range word {
+		vowel := strings.ContainsRune("aeiouy", r)
+		if vowel && !previousVowel {
+			count++
+		}
+		previousVowel = vowel
+	}
+
+	// A final silent e, as in "make", but not "-le" as in "table" or "-ee" as in "coffee"
+	if strings.HasSuffix(word, "e") && !strings.HasSuffix(word, "le") && !strings.HasSuffix(word, "ee") && count > 1 {
+		count--
+	}
+	if strings.HasSuffix(word, "ed") && !strings.HasSuffix(word, "ted") && !strings.HasSuffix(word, "ded") && count > 1 {
+		count--
+	}
+
+	return max(count, 1)
+}
+
+// topTerms returns the most frequent keywords and the most frequent phrases of two
+// or three words. Phrases do not cross sentences and do not start or end with a stop word.
+func topTerms(sentences [][]string, wordCount int) ([]Term, []Term) {
+	keywords := make(map[string]int)
+	phrases := make(map[string]int)
+
+	for _, words := range sentences {
+		for i, word := range words {
+			if isKeyword(word) {
+				keywords[word]++
+			}
+
+			for size := 2; size <= 3 && i+size <= len(words); size++ {
+				gram := words[i : i+size]
+				if isKeyword(gram[0]) && isKeyword(gram[size-1]) {
+					phrases[strings.Join(gram, " ")]++
+				}
+			}
+		}
+	}
+
+	// A phrase seen once is not a phrase of the page
+	for phrase, count := range phrases {
+		if count < 2 {
+			delete(phrases, phrase)
+		}
+	}
+
+	return rank(keywords, wordCount), rank(phrases, wordCount)
+}
+
+func isKeyword(word string) bool {
+	if utf8.RuneCountInString(word) < 3 || stopWords[word] {
+		return false
+	}
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func rank(counts map[string]int, wordCount int) []Term {
+	terms := make([]Term, 0, len(counts))
+	for term, count := range counts {
+		terms = append(terms, Term{Term: term, Count: count, Density: round(float64(count) / float64(wordCount) * 100)})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Term < terms[j].Term
+	})
+
+	if len(terms) > topTermsCount {
+		terms = terms[:topTermsCount]
+	}
+	return terms
+}
+
+func excerpt(text string) string {
+	text = strings.ReplaceAll(text, "\n\n", " ")
+	if utf8.RuneCountInString(text) <= excerptLength {
+		return text
+	}
+
+	runes := []rune(text)[:excerptLength]
+	if space := strings.LastIndexFunc(string(runes), unicode.IsSpace); space > 0 {
+		return string(runes)[:space] + "…"
+	}
+	return string(runes) + "…"
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
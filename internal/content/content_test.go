@@ -0,0 +1,301 @@
+package content
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func parse(t *testing.T, page string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatalf("Failed to parse the page: %v", err)
+	}
+	return doc
+}
+
+func TestExtractMainContent(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedSource string
+		contains       string
+		excludes       []string
+	}{
+		{
+			name: "main element",
+			body: `<nav><a href="/">Home</a></nav>
+				<main><h1>Title</h1><p>The main text of the page.</p></main>
+				<footer>Copyright</footer>`,
+			expectedSource: "main",
+			contains:       "The main text of the page.",
+			excludes:       []string{"Home", "Copyright"},
+		},
+		{
+			name: "longest article",
+			body: `<article id="teaser"><p>Short teaser.</p></article>
+				<article id="story"><p>The full story, which is much longer than the teaser.</p></article>`,
+			expectedSource: "article#story",
+			contains:       "The full story",
+			excludes:       []string{"Short teaser"},
+		},
+		{
+			name: "densest container",
+			body: `<div class="menu-wrapper"><ul><li><a href="/a">A link to a page of the site</a></li></ul></div>
+				<div class="sidebar"><p>Sidebar text that is long enough to be scored.</p></div>
+				<div id="content">
+					<p>First paragraph of the content, with a comma, and enough text.</p>
+					<p>Second paragraph of the content, again long enough to count.</p>
+				</div>
+				<div class="cookie-banner"><p>We use cookies to improve your experience here.</p></div>`,
+			expectedSource: "div#content",
+			contains:       "Second paragraph",
+			excludes:       []string{"Sidebar", "cookies", "A link"},
+		},
+		{
+			name:           "body fallback",
+			body:           `<div>Hi</div><script>var x = 1;</script>`,
+			expectedSource: "body",
+			contains:       "Hi",
+			excludes:       []string{"var x"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc := parse(t, "<html><body>"+test.body+"</body></html>")
+			main, source := extractMainContent(doc)
+			text := extractText(main)
+
+			if source != test.expectedSource {
+				t.Errorf("Expected source %q, got %q", test.expectedSource, source)
+			}
+			if !strings.Contains(text, test.contains) {
+				t.Errorf("Expected the text to contain %q, got %q", test.contains, text)
+			}
+			for _, excluded := range test.excludes {
+				if strings.Contains(text, excluded) {
+					t.Errorf("Expected the text not to contain %q, got %q", excluded, text)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractMainContentKeepsOriginalDocument(t *testing.T) {
+	doc := parse(t, `<html><body><nav>Menu</nav><main><p>Text</p></main></body></html>`)
+	extractMainContent(doc)
+
+	if doc.Find("nav").Length() != 1 {
+		t.Errorf("Expected the boilerplate to be removed from a copy of the document")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	page := `<html lang="en"><head><title>Coffee</title><style>body { color: red }</style></head><body>
+		<header><nav><a href="/">Home</a> <a href="/shop">Shop</a></nav></header>
+		<article>
+			<h1>Brewing coffee</h1>
+			<p>Fresh coffee beans make great coffee. Grind the coffee beans just before brewing.</p>
+			<p>Water temperature matters! Use water just below boiling.</p>
+			<p>Is cold brew coffee better? Cold brew coffee is smoother.</p>
+		</article>
+		<footer>Copyright 2024</footer>
+	</body></html>`
+
+	report := Analyze(parse(t, page), page)
+
+	if report.Source != "article" {
+		t.Errorf("Expected the content to come from the article, got %q", report.Source)
+	}
+	if report.Language != "en" {
+		t.Errorf("Expected language en, got %q", report.Language)
+	}
+	if report.WordCount != 33 {
+		t.Errorf("Expected 33 words, got %d", report.WordCount)
+	}
+	// The heading counts as a sentence of its own
+	if report.SentenceCount != 7 {
+		t.Errorf("Expected 7 sentences, got %d", report.SentenceCount)
+	}
+	if report.ParagraphCount != 3 {
+		t.Errorf("Expected 3 paragraphs, got %d", report.ParagraphCount)
+	}
+	if report.ReadingTimeMinutes != 1 {
+		t.Errorf("Expected a reading time of 1 minute, got %d", report.ReadingTimeMinutes)
+	}
+	if report.Readability == nil {
+		t.Fatalf("Expected readability for an English page")
+	}
+	if report.Readability.FleschReadingEase < 60 || report.Readability.FleschReadingEase > 100 {
+		t.Errorf("Expected simple text to be easy to read, got %v", report.Readability.FleschReadingEase)
+	}
+	if report.TextToHTMLRatio <= 0 || report.TextToHTMLRatio >= 100 {
+		t.Errorf("Expected a text to HTML ratio between 0 and 100, got %v", report.TextToHTMLRatio)
+	}
+
+	if len(report.Keywords) == 0 || report.Keywords[0] != (Term{Term: "coffee", Count: 6, Density: 18.18}) {
+		t.Errorf("Expected coffee to be the top keyword, got %+v", report.Keywords)
+	}
+
+	expectedPhrases := []Term{
+		{Term: "coffee beans", Count: 2, Density: 6.06},
+		{Term: "cold brew", Count: 2, Density: 6.06},
+		{Term: "cold brew coffee", Count: 2, Density: 6.06},
+		{Term: "brew coffee", Count: 2, Density: 6.06},
+	}
+	for _, expected := range expectedPhrases {
+		found := false
+		for _, phrase := range report.Phrases {
+			if phrase == expected {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("Expected phrase %+v in %+v", expected, report.Phrases)
+		}
+	}
+	for _, phrase := range report.Phrases {
+		if phrase.Count < 2 {
+			t.Errorf("Expected only repeated phrases, got %+v", phrase)
+		}
+	}
+}
+
+func TestAnalyzeSkipsReadabilityForOtherLanguages(t *testing.T) {
+	page := `<html lang="de"><body><main><p>Das ist ein kurzer Text.</p></main></body></html>`
+	report := Analyze(parse(t, page), page)
+
+	if report.Readability != nil {
+		t.Errorf("Expected no readability for a German page, got %+v", report.Readability)
+	}
+	if report.WordCount != 5 {
+		t.Errorf("Expected 5 words, got %d", report.WordCount)
+	}
+}
+
+func TestAnalyzeDetectsMissingLanguage(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		language    string
+		readability bool
+	}{
+		{
+			name:        "English",
+			text:        "The quick brown fox jumps over the lazy dog. It was the best of times and the worst of times.",
+			language:    "en",
+			readability: true,
+		},
+		{
+			name:     "German",
+			text:     "Der schnelle braune Fuchs springt über den faulen Hund. Es war die beste und die schlechteste aller Zeiten.",
+			language: "de",
+		},
+		{
+			name: "Too short to detect",
+			text: "Hello there.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			page := "<html><body><main><p>" + test.text + "</p></main></body></html>"
+			report := Analyze(parse(t, page), page)
+
+			if report.Language != test.language {
+				t.Errorf("Expected language %q, got %q", test.language, report.Language)
+			}
+			if (report.Readability != nil) != test.readability {
+				t.Errorf("Expected readability %v, got %+v", test.readability, report.Readability)
+			}
+		})
+	}
+}
+
+func TestAnalyzeEmptyPage(t *testing.T) {
+	report := Analyze(parse(t, "<html><body></body></html>"), "<html><body></body></html>")
+
+	if report.WordCount != 0 || report.ReadingTimeMinutes != 0 || report.Readability != nil {
+		t.Errorf("Expected no metrics for an empty page, got %+v", report)
+	}
+	if report.Keywords == nil || report.Phrases == nil {
+		t.Errorf("Expected empty keyword lists rather than nil")
+	}
+}
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected []string
+	}{
+		{"One. Two! Three?", []string{"One. ", "Two! ", "Three?"}},
+		{"Version 1.2 is out. Really", []string{"Version 1.2 is out. ", "Really"}},
+		{`He said "stop." Then left.`, []string{`He said "stop." `, "Then left."}},
+		{"No punctuation", []string{"No punctuation"}},
+	}
+
+	for _, test := range tests {
+		if got := splitSentences(test.text); !reflect.DeepEqual(got, test.expected) {
+			t.Errorf("splitSentences(%q) = %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
+
+func TestSplitWords(t *testing.T) {
+	got := splitWords("Don't use e-mail -- it's 2024, 'quoted' words.")
+	expected := []string{"don't", "use", "e-mail", "it's", "2024", "quoted", "words"}
+
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
+
+func TestCountSyllables(t *testing.T) {
+	tests := map[string]int{
+		"the":         1,
+		"coffee":      2,
+		"make":        1,
+		"table":       2,
+		"beautiful":   3,
+		"readability": 5,
+		"jumped":      1,
+		"wanted":      2,
+		"rhythm":      1,
+	}
+
+	for word, expected := range tests {
+		if got := countSyllables(word); got != expected {
+			t.Errorf("countSyllables(%q) = %d, expected %d", word, got, expected)
+		}
+	}
+}
+
+func TestReadingLevel(t *testing.T) {
+	tests := map[float64]string{
+		95: "very easy",
+		65: "standard",
+		45: "difficult",
+		10: "very difficult",
+	}
+
+	for ease, expected := range tests {
+		if got := readingLevel(ease); got != expected {
+			t.Errorf("readingLevel(%v) = %q, expected %q", ease, got, expected)
+		}
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	long := strings.Repeat("word ", 100)
+	got := excerpt(long)
+
+	if !strings.HasSuffix(got, "…") || len([]rune(got)) > excerptLength+1 {
+		t.Errorf("Expected a shortened excerpt, got %q", got)
+	}
+	if excerpt("Short\n\ntext") != "Short text" {
+		t.Errorf("Expected paragraphs to be joined, got %q", excerpt("Short\n\ntext"))
+	}
+}
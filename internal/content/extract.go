@@ -0,0 +1,193 @@
+package content
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html"
+)
+
+// Elements that never hold the main content
+const boilerplateElements = "script, style, noscript, template, nav, header, footer, aside, form, iframe, svg, button, select, dialog"
+
+// Class and id fragments of navigation, sidebars, ads and other page chrome
+var boilerplatePattern = regexp.MustCompile(`(?i)(^|[-_ ])(nav|navbar|menu|footer|header|sidebar|side-bar|comment|comments|cookie|consent|banner|share|social|breadcrumbs?|advert|ads?|promo|related|popup|modal|newsletter|subscribe|widget|masthead|skip)([-_ ]|$)`)
+
+// Class and id fragments of containers that usually hold the content
+var contentPattern = regexp.MustCompile(`(?i)(article|content|main|post|entry|story|body|text|blog)`)
+
+var blockElements = map[string]bool{
+	"address": true, "article": true, "blockquote": true, "br": true, "dd": true, "div": true,
+	"dl": true, "dt": true, "figcaption": true, "figure": true, "h1": true, "h2": true,
+	"h3": true, "h4": true, "h5": true, "h6": true, "hr": true, "li": true, "main": true,
+	"ol": true, "p": true, "pre": true, "section": true, "table": true, "td": true,
+	"th": true, "tr": true, "ul": true,
+}
+
+// extractMainContent returns the element that holds the main content of the page.
+// Explicit <main> and <article> elements win. Otherwise, after the boilerplate is
+// removed, the container whose paragraphs carry the most text is picked.
+func extractMainContent(doc *goquery.Document) (*goquery.Selection, string) {
+	clone := goquery.NewDocumentFromNode(doc.Selection.Clone().Nodes[0])
+	clone.Find(boilerplateElements).Remove()
+
+	clone.Find("[class], [id]").Each(func(_ int, s *goquery.Selection) {
+		if goquery.NodeName(s) == "body" || goquery.NodeName(s) == "html" {
+			return
+		}
+		marker := s.AttrOr("class", "") + " " + s.AttrOr("id", "")
+		if boilerplatePattern.MatchString(marker) && !contentPattern.MatchString(marker) {
+			s.Remove()
+		}
+	})
+
+	if main := clone.Find("main, [role=main]").First(); main.Length() > 0 && textLength(main) > 0 {
+		return main, describe(main)
+	}
+
+	if article := longest(clone.Find("article")); article != nil {
+		return article, describe(article)
+	}
+
+	// Candidates are kept in document order so that ties are broken the same way every time
+	scores := make(map[*html.Node]float64)
+	var candidates []*html.Node
+	addScore := func(node *html.Node, score float64) {
+		if _, exists := scores[node]; !exists {
+			candidates = append(candidates, node)
+		}
+		scores[node] += score
+	}
+
+	clone.Find("p, pre, blockquote, li").Each(func(_ int, s *goquery.Selection) {
+		text := strings.TrimSpace(s.Text())
+		length := utf8.RuneCountInString(text)
+		if length < 25 {
+			return
+		}
+
+		score := 1 + float64(strings.Count(text, ",")) + min(float64(length)/100, 3)
+		if parent := s.Parent(); parent.Length() > 0 {
+			addScore(parent.Nodes[0], score)
+			if grandparent := parent.Parent(); grandparent.Length() > 0 {
+				addScore(grandparent.Nodes[0], score/2)
+			}
+		}
+	})
+
+	var best *html.Node
+	bestScore := 0.0
+	for _, node := range candidates {
+		score := scores[node]
+		selection := clone.FindNodes(node)
+		marker := selection.AttrOr("class", "") + " " + selection.AttrOr("id", "")
+		if contentPattern.MatchString(marker) {
+			score *= 1.25
+		}
+		// Containers that are mostly links are navigation rather than content
+		score *= 1 - linkDensity(selection)
+
+		if score > bestScore {
+			best, bestScore = node, score
+		}
+	}
+
+	if best != nil {
+		selection := clone.FindNodes(best)
+		return selection, describe(selection)
+	}
+
+	body := clone.Find("body")
+	return body, "body"
+}
+
+func longest(selection *goquery.Selection) *goquery.Selection {
+	var result *goquery.Selection
+	bestLength := 0
+	selection.Each(func(_ int, s *goquery.Selection) {
+		if length := textLength(s); length > bestLength {
+			result, bestLength = s, length
+		}
+	})
+	return result
+}
+
+func textLength(s *goquery.Selection) int {
+	return utf8.RuneCountInString(strings.TrimSpace(s.Text()))
+}
+
+func linkDensity(s *goquery.Selection) float64 {
+	total := textLength(s)
+	if total == 0 {
+		return 0
+	}
+	linkText := 0
+	s.Find("a").Each(func(_ int, a *goquery.Selection) {
+		linkText += textLength(a)
+	})
+	return min(float64(linkText)/float64(total), 1)
+}
+
+// describe returns a CSS like description of the element, such as div#content
+func describe(s *goquery.Selection) string {
+	description := goquery.NodeName(s)
+	if id := s.AttrOr("id", ""); id != "" {
+		return description + "#" + id
+	}
+	if class := strings.Fields(s.AttrOr("class", "")); len(class) > 0 {
+		return description + "." + class[0]
+	}
+	return description
+}
+
+// extractText returns the text of the selection with blank lines between blocks
+func extractText(s *goquery.Selection) string {
+	var builder strings.Builder
+
+	var walk func(node *html.Node)
+	walk = func(node *html.Node) {
+		switch node.Type {
+		case html.TextNode:
+			builder.WriteString(node.Data)
+			return
+		case html.ElementNode:
+			if node.Data == "script" || node.Data == "style" || node.Data == "noscript" || node.Data == "template" {
+				return
+			}
+		}
+
+		block := node.Type == html.ElementNode && blockElements[node.Data]
+		if block {
+			builder.WriteString("\n\n")
+		}
+		for child := node.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+		if block {
+			builder.WriteString("\n\n")
+		}
+	}
+
+	for _, node := range s.Nodes {
+		walk(node)
+	}
+
+	return normalizeWhitespace(builder.String())
+}
+
+var blankLines = regexp.MustCompile(`\n\s*\n`)
+var spaces = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+
+// normalizeWhitespace collapses runs of spaces and keeps a single blank line between paragraphs
+func normalizeWhitespace(text string) string {
+	paragraphs := []string{}
+	for _, paragraph := range blankLines.Split(text, -1) {
+		paragraph = strings.TrimSpace(spaces.ReplaceAllString(strings.ReplaceAll(paragraph, "\n", " "), " "))
+		if paragraph != "" {
+			paragraphs = append(paragraphs, paragraph)
+		}
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
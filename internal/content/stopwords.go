@@ -0,0 +1,29 @@
+package content
+
+// English stop words left out of the keywords
+var stopWords = toSet(
+	"a", "about", "above", "after", "again", "against", "all", "also", "am", "an", "and", "any",
+	"are", "aren't", "as", "at", "be", "because", "been", "before", "being", "below", "between",
+	"both", "but", "by", "can", "can't", "cannot", "could", "couldn't", "did", "didn't", "do",
+	"does", "doesn't", "doing", "don't", "down", "during", "each", "even", "ever", "every", "few",
+	"for", "from", "further", "get", "gets", "got", "had", "hadn't", "has", "hasn't", "have",
+	"haven't", "having", "he", "her", "here", "hers", "herself", "him", "himself", "his", "how",
+	"however", "i", "if", "in", "into", "is", "isn't", "it", "it's", "its", "itself", "just",
+	"let's", "like", "made", "make", "many", "may", "me", "might", "more", "most", "much", "must",
+	"my", "myself", "new", "no", "nor", "not", "now", "of", "off", "on", "once", "one", "only",
+	"or", "other", "our", "ours", "ourselves", "out", "over", "own", "same", "she", "should",
+	"shouldn't", "so", "some", "such", "than", "that", "that's", "the", "their", "theirs", "them",
+	"themselves", "then", "there", "there's", "these", "they", "this", "those", "through", "to",
+	"too", "under", "until", "up", "us", "use", "used", "using", "very", "was", "wasn't", "way",
+	"we", "well", "were", "weren't", "what", "when", "where", "which", "while", "who", "whom",
+	"why", "will", "with", "won't", "would", "wouldn't", "yet", "you", "your", "yours",
+	"yourself", "yourselves",
+)
+
+func toSet(words ...string) map[string]bool {
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
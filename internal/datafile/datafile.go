@@ -0,0 +1,67 @@
+package datafile
+
+import (
+	"lt-app/internal/applogger"
+	"os"
+	"sync"
+	"time"
+)
+
+// File is a data file that is parsed on first use and again whenever its modification
+// time changes, so the data can be updated without restarting the server
+type File[T any] struct {
+	path    string
+	parse   func(content []byte) (T, error)
+	mu      sync.Mutex
+	modTime time.Time
+	value   T
+	// Modification time of a version that could not be read or parsed, which is
+	// not tried again
+	failedModTime time.Time
+	// Set while the file is missing, so that is only logged once
+	missing bool
+}
+
+func New[T any](path string, parse func(content []byte) (T, error)) *File[T] {
+	return &File[T]{path: path, parse: parse}
+}
+
+// Get returns the parsed content of the file. When the file cannot be read or parsed
+// the previous content is kept, which is the zero value if it has never been read,
+// and the file is only read again once it changes.
+func (f *File[T]) Get() T {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	info, err := os.Stat(f.path)
+	if err != nil {
+		if !f.missing {
+			applogger.Logger.Error("Failed to read data file", "path", f.path, "error", err)
+		}
+		f.missing = true
+		return f.value
+	}
+	f.missing = false
+
+	if info.ModTime().Equal(f.modTime) || info.ModTime().Equal(f.failedModTime) {
+		return f.value
+	}
+
+	content, err := os.ReadFile(f.path)
+	if err != nil {
+		applogger.Logger.Error("Failed to read data file", "path", f.path, "error", err)
+		f.failedModTime = info.ModTime()
+		return f.value
+	}
+
+	value, err := f.parse(content)
+	if err != nil {
+		applogger.Logger.Error("Failed to parse data file", "path", f.path, "error", err)
+		f.failedModTime = info.ModTime()
+		return f.value
+	}
+
+	f.value = value
+	f.modTime = info.ModTime()
+	return f.value
+}
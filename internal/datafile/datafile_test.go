@@ -0,0 +1,52 @@
+package datafile
+
+import (
+	"errors"
+	"lt-app/internal/applogger"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGet(t *testing.T) {
+	applogger.InitLogger()
+	path := filepath.Join(t.TempDir(), "data.txt")
+	parsed := 0
+	file := New(path, func(content []byte) (string, error) {
+		parsed++
+		if string(content) == "invalid" {
+			return "", errors.New("invalid content")
+		}
+		return string(content), nil
+	})
+
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write the file: %v", err)
+		}
+		os.Chtimes(path, modTime, modTime)
+	}
+	start := time.Now().Add(-time.Hour)
+
+	steps := []struct {
+		name     string
+		change   func()
+		expected string
+		parsed   int
+	}{
+		{"missing", func() {}, "", 0},
+		{"created", func() { write("first", start) }, "first", 1},
+		{"unchanged", func() {}, "first", 1},
+		{"invalid", func() { write("invalid", start.Add(time.Minute)) }, "first", 2},
+		{"still invalid", func() {}, "first", 2},
+		{"fixed", func() { write("second", start.Add(2*time.Minute)) }, "second", 3},
+	}
+
+	for _, step := range steps {
+		step.change()
+		if got := file.Get(); got != step.expected || parsed != step.parsed {
+			t.Errorf("%s: expected %q after %d parses, got %q after %d", step.name, step.expected, step.parsed, got, parsed)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package diff
+
+import (
+	"encoding/json"
+	"lt-app/internal/findings"
+	"lt-app/internal/pagedata"
+	"lt-app/internal/score"
+)
+
+// Names of the parts of an analysis that are compared
+const (
+	PartTitle       = "title"
+	PartDescription = "description"
+	PartHeadings    = "headings"
+	PartLinks       = "links"
+	PartBrokenLinks = "brokenLinks"
+	PartScore       = "score"
+)
+
+// Snapshot holds the parts of a stored analysis that are compared. A part is nil
+// when the analyzer that reports it did not run.
+type Snapshot struct {
+	Title       *string            `json:"title"`
+	Description *string            `json:"description"`
+	Outline     []pagedata.Heading `json:"outline"`
+	Links       []string           `json:"links"`
+	BrokenLinks []string           `json:"brokenLinks"`
+	Score       *score.Report      `json:"score"`
+}
+
+// TextChange holds a text of both analyses
+type TextChange struct {
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Changed bool   `json:"changed"`
+}
+
+type CategoryChange struct {
+	Category findings.Category `json:"category"`
+	Before   int               `json:"before"`
+	After    int               `json:"after"`
+	Delta    int               `json:"delta"`
+}
+
+type ScoreChange struct {
+	Before      int    `json:"before"`
+	After       int    `json:"after"`
+	Delta       int    `json:"delta"`
+	GradeBefore string `json:"gradeBefore"`
+	GradeAfter  string `json:"gradeAfter"`
+	// Categories scored in both analyses
+	Categories []CategoryChange `json:"categories"`
+}
+
+// Diff lists what changed from the first analysis to the second
+type Diff struct {
+	Changed bool `json:"changed"`
+	// Only set when the part was compared
+	Title           *TextChange        `json:"title,omitempty"`
+	Description     *TextChange        `json:"description,omitempty"`
+	Score           *ScoreChange       `json:"score,omitempty"`
+	HeadingsAdded   []pagedata.Heading `json:"headingsAdded"`
+	HeadingsRemoved []pagedata.Heading `json:"headingsRemoved"`
+	LinksAdded      []string           `json:"linksAdded"`
+	LinksRemoved    []string           `json:"linksRemoved"`
+	// Links that are broken now but were not before
+	NewlyBroken []string `json:"newlyBroken"`
+	// Links that were broken before and are on the page and accessible now
+	NewlyFixed []string `json:"newlyFixed"`
+	// Parts missing from either analysis, as their analyzers did not run
+	NotCompared []string `json:"notCompared"`
+}
+
+// Parse reads the parts that are compared from the JSON stats of an analysis
+func Parse(stats []byte) (*Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(stats, &snapshot); err != nil {
+		return nil, err
+	}
+	return &snapshot, nil
+}
+
+// Compare lists the changes from a to b
+func Compare(a, b *Snapshot) *Diff {
+	diff := &Diff{
+		HeadingsAdded:   []pagedata.Heading{},
+		HeadingsRemoved: []pagedata.Heading{},
+		LinksAdded:      []string{},
+		LinksRemoved:    []string{},
+		NewlyBroken:     []string{},
+		NewlyFixed:      []string{},
+		NotCompared:     []string{},
+	}
+
+	if a.Title != nil && b.Title != nil {
+		diff.Title = compareText(*a.Title, *b.Title)
+		diff.Changed = diff.Changed || diff.Title.Changed
+	} else {
+		diff.NotCompared = append(diff.NotCompared, PartTitle)
+	}
+
+	if a.Description != nil && b.Description != nil {
+		diff.Description = compareText(*a.Description, *b.Description)
+		diff.Changed = diff.Changed || diff.Description.Changed
+	} else {
+		diff.NotCompared = append(diff.NotCompared, PartDescription)
+	}
+
+	if a.Score != nil && b.Score != nil {
+		diff.Score = compareScores(a.Score, b.Score)
+		diff.Changed = diff.Changed || diff.Score.Delta != 0 || diff.Score.GradeBefore != diff.Score.GradeAfter
+	} else {
+		diff.NotCompared = append(diff.NotCompared, PartScore)
+	}
+
+	if a.Outline != nil && b.Outline != nil {
+		diff.HeadingsAdded = subtractHeadings(b.Outline, a.Outline)
+		diff.HeadingsRemoved = subtractHeadings(a.Outline, b.Outline)
+		diff.Changed = diff.Changed || len(diff.HeadingsAdded) > 0 || len(diff.HeadingsRemoved) > 0
+	} else {
+		diff.NotCompared = append(diff.NotCompared, PartHeadings)
+	}
+
+	if a.Links != nil && b.Links != nil {
+		diff.LinksAdded = subtract(b.Links, a.Links)
+		diff.LinksRemoved = subtract(a.Links, b.Links)
+		diff.Changed = diff.Changed || len(diff.LinksAdded) > 0 || len(diff.LinksRemoved) > 0
+	} else {
+		diff.NotCompared = append(diff.NotCompared, PartLinks)
+	}
+
+	// The links of b are known whenever its links were checked
+	if a.BrokenLinks != nil && b.BrokenLinks != nil {
+		diff.NewlyBroken = subtract(b.BrokenLinks, a.BrokenLinks)
+		onPage := toSet(b.Links)
+		for _, link := range subtract(a.BrokenLinks, b.BrokenLinks) {
+			if onPage[link] {
+				diff.NewlyFixed = append(diff.NewlyFixed, link)
+			}
+		}
+		diff.Changed = diff.Changed || len(diff.NewlyBroken) > 0 || len(diff.NewlyFixed) > 0
+	} else {
+		diff.NotCompared = append(diff.NotCompared, PartBrokenLinks)
+	}
+
+	return diff
+}
+
+func compareText(before, after string) *TextChange {
+	return &TextChange{Before: before, After: after, Changed: before != after}
+}
+
+func compareScores(a, b *score.Report) *ScoreChange {
+	change := &ScoreChange{
+		Before:      a.Score,
+		After:       b.Score,
+		Delta:       b.Score - a.Score,
+		GradeBefore: a.Grade,
+		GradeAfter:  b.Grade,
+		Categories:  []CategoryChange{},
+	}
+
+	before := make(map[findings.Category]int)
+	for _, category := range a.Categories {
+		before[category.Category] = category.Score
+	}
+	for _, category := range b.Categories {
+		if beforeScore, found := before[category.Category]; found {
+			change.Categories = append(change.Categories, CategoryChange{
+				Category: category.Category,
+				Before:   beforeScore,
+				After:    category.Score,
+				Delta:    category.Score - beforeScore,
+			})
+		}
+	}
+	return change
+}
+
+// subtract returns the unique values of a that are not in b, in the order of a
+func subtract(a, b []string) []string {
+	exclude := toSet(b)
+	result := []string{}
+	for _, value := range a {
+		if !exclude[value] {
+			exclude[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+func toSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// subtractHeadings returns the headings of a that are not in b. Headings are
+// counted, so a heading that appears once more than before is added once.
+func subtractHeadings(a, b []pagedata.Heading) []pagedata.Heading {
+	remaining := make(map[pagedata.Heading]int)
+	for _, heading := range b {
+		remaining[heading]++
+	}
+
+	result := []pagedata.Heading{}
+	for _, heading := range a {
+		if remaining[heading] > 0 {
+			remaining[heading]--
+			continue
+		}
+		result = append(result, heading)
+	}
+	return result
+}
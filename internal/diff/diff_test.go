@@ -0,0 +1,103 @@
+package diff
+
+import (
+	"lt-app/internal/pagedata"
+	"reflect"
+	"testing"
+)
+
+const before = `{
+	"analyzers": ["title", "page", "links", "link-checks", "score"],
+	"title": "Kettles",
+	"description": "Kettles and teapots",
+	"outline": [{"tag": "h1", "text": "Kettles"}, {"tag": "h2", "text": "Electric"}, {"tag": "h2", "text": "Glass"}],
+	"links": ["https://example.com/a", "https://example.com/b", "https://example.com/c"],
+	"brokenLinks": ["https://example.com/b", "https://example.com/c"],
+	"score": {"score": 80, "grade": "B", "categories": [{"category": "seo", "score": 90}, {"category": "links", "score": 60}]}
+}`
+
+const after = `{
+	"analyzers": ["title", "page", "links", "link-checks", "score"],
+	"title": "Kettles and teapots",
+	"description": "Kettles and teapots",
+	"outline": [{"tag": "h1", "text": "Kettles"}, {"tag": "h2", "text": "Glass"}, {"tag": "h2", "text": "Glass"}],
+	"links": ["https://example.com/a", "https://example.com/b", "https://example.com/d"],
+	"brokenLinks": ["https://example.com/a", "https://example.com/a"],
+	"score": {"score": 84, "grade": "B", "categories": [{"category": "seo", "score": 90}, {"category": "links", "score": 75}, {"category": "security", "score": 100}]}
+}`
+
+func compare(t *testing.T, a, b string) *Diff {
+	snapshotA, err := Parse([]byte(a))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	snapshotB, err := Parse([]byte(b))
+	if err != nil {
+		t.Fatalf("Failed to parse: %v", err)
+	}
+	return Compare(snapshotA, snapshotB)
+}
+
+func TestCompare(t *testing.T) {
+	diff := compare(t, before, after)
+
+	expected := &Diff{
+		Changed:     true,
+		Title:       &TextChange{Before: "Kettles", After: "Kettles and teapots", Changed: true},
+		Description: &TextChange{Before: "Kettles and teapots", After: "Kettles and teapots"},
+		Score: &ScoreChange{
+			Before: 80, After: 84, Delta: 4, GradeBefore: "B", GradeAfter: "B",
+			Categories: []CategoryChange{{Category: "seo", Before: 90, After: 90}, {Category: "links", Before: 60, After: 75, Delta: 15}},
+		},
+		HeadingsAdded:   []pagedata.Heading{{Tag: "h2", Text: "Glass"}},
+		HeadingsRemoved: []pagedata.Heading{{Tag: "h2", Text: "Electric"}},
+		LinksAdded:      []string{"https://example.com/d"},
+		LinksRemoved:    []string{"https://example.com/c"},
+		NewlyBroken:     []string{"https://example.com/a"},
+		// c was broken but is no longer on the page
+		NewlyFixed:  []string{"https://example.com/b"},
+		NotCompared: []string{},
+	}
+
+	if !reflect.DeepEqual(diff, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, diff)
+	}
+}
+
+func TestCompare_Unchanged(t *testing.T) {
+	diff := compare(t, before, before)
+
+	if diff.Changed || diff.Title.Changed || diff.Score.Delta != 0 || len(diff.HeadingsAdded)+len(diff.LinksRemoved)+len(diff.NewlyBroken) != 0 {
+		t.Errorf("Expected no changes, got %+v", diff)
+	}
+}
+
+func TestCompare_NotCompared(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected []string
+	}{
+		{"only title", `{"title": "Kettles"}`, `{"title": "Kettles"}`, []string{PartDescription, PartScore, PartHeadings, PartLinks, PartBrokenLinks}},
+		{"missing from one", before, `{"title": "Kettles", "links": []}`, []string{PartDescription, PartScore, PartHeadings, PartBrokenLinks}},
+		{"empty page", `{"title": "", "description": "", "outline": [], "links": [], "brokenLinks": []}`, `{"title": "", "description": "", "outline": [], "links": [], "brokenLinks": []}`, []string{PartScore}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			diff := compare(t, test.a, test.b)
+			if !reflect.DeepEqual(diff.NotCompared, test.expected) {
+				t.Errorf("Expected %v not to be compared, got %v", test.expected, diff.NotCompared)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, stats := range []string{"{", `{"title": 1}`} {
+		if _, err := Parse([]byte(stats)); err == nil {
+			t.Errorf("%s: expected an error", stats)
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package doctype
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Version identifies the HTML specification a document type declaration refers to
+type Version string
+
+const (
+	VersionNone                Version = "none"
+	VersionUnknown             Version = "unknown"
+	VersionHTML5               Version = "html5"
+	VersionHTML401Strict       Version = "html4.01-strict"
+	VersionHTML401Transitional Version = "html4.01-transitional"
+	VersionHTML401Frameset     Version = "html4.01-frameset"
+	VersionHTML40Strict        Version = "html4.0-strict"
+	VersionHTML40Transitional  Version = "html4.0-transitional"
+	VersionHTML40Frameset      Version = "html4.0-frameset"
+	VersionHTML32              Version = "html3.2"
+	VersionHTML20              Version = "html2.0"
+	VersionXHTML10Strict       Version = "xhtml1.0-strict"
+	VersionXHTML10Transitional Version = "xhtml1.0-transitional"
+	VersionXHTML10Frameset     Version = "xhtml1.0-frameset"
+	VersionXHTML11             Version = "xhtml1.1"
+	VersionXHTMLBasic10        Version = "xhtml-basic1.0"
+	VersionXHTMLBasic11        Version = "xhtml-basic1.1"
+	VersionXHTMLMobile         Version = "xhtml-mobile"
+	VersionXHTMLRDFa           Version = "xhtml-rdfa"
+)
+
+// Mode is the document compatibility mode a browser selects for the page
+type Mode string
+
+const (
+	ModeNoQuirks      Mode = "no-quirks"
+	ModeLimitedQuirks Mode = "limited-quirks"
+	ModeQuirks        Mode = "quirks"
+)
+
+const legacyCompatSystemID = "about:legacy-compat"
+
+type Doctype struct {
+	Present      bool    `json:"present"`
+	Name         string  `json:"name"`
+	PublicID     string  `json:"publicId"`
+	SystemID     string  `json:"systemId"`
+	Version      Version `json:"version"`
+	Mode         Mode    `json:"mode"`
+	IsFirstToken bool    `json:"isFirstToken"`
+}
+
+// Public identifier prefixes mapped to the version they declare. Order matters
+// as some prefixes are prefixes of others (HTML 4.01 vs HTML 4.01 Transitional).
+var versionPrefixes = []struct {
+	prefix  string
+	version Version
+}{
+	{"-//w3c//dtd html 4.01 transitional//", VersionHTML401Transitional},
+	{"-//w3c//dtd html 4.01 frameset//", VersionHTML401Frameset},
+	{"-//w3c//dtd html 4.01//", VersionHTML401Strict},
+	{"-//w3c//dtd html 4.0 transitional//", VersionHTML40Transitional},
+	{"-//w3c//dtd html 4.0 frameset//", VersionHTML40Frameset},
+	{"-//w3c//dtd html 4.0//", VersionHTML40Strict},
+	{"-//w3c//dtd html 3.2", VersionHTML32},
+	{"-//ietf//dtd html 2.0", VersionHTML20},
+	{"-//ietf//dtd html//", VersionHTML20},
+	{"-//w3c//dtd xhtml 1.0 strict//", VersionXHTML10Strict},
+	{"-//w3c//dtd xhtml 1.0 transitional//", VersionXHTML10Transitional},
+	{"-//w3c//dtd xhtml 1.0 frameset//", VersionXHTML10Frameset},
+	{"-//w3c//dtd xhtml 1.1//", VersionXHTML11},
+	{"-//w3c//dtd xhtml basic 1.0//", VersionXHTMLBasic10},
+	{"-//w3c//dtd xhtml basic 1.1//", VersionXHTMLBasic11},
+	{"-//wapforum//dtd xhtml mobile", VersionXHTMLMobile},
+	{"-//openmobilealliance//dtd xhtml mobile", VersionXHTMLMobile},
+	{"-//w3c//dtd xhtml+rdfa", VersionXHTMLRDFa},
+}
+
+// Public identifiers that put a document in quirks mode when matched exactly.
+// See https://html.spec.whatwg.org/multipage/parsing.html#the-initial-insertion-mode
+var quirksPublicIDs = []string{
+	"-//w3o//dtd w3 html strict 3.0//en//",
+	"-/w3c/dtd html 4.0 transitional/en",
+	"html",
+}
+
+// Public identifier prefixes that put a document in quirks mode
+var quirksPublicIDPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+const quirksSystemID = "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd"
+
+// Prefixes whose mode depends on whether a system identifier is present
+var html401LooseIDPrefixes = []string{
+	"-//w3c//dtd html 4.01 frameset//",
+	"-//w3c//dtd html 4.01 transitional//",
+}
+
+var limitedQuirksPublicIDPrefixes = []string{
+	"-//w3c//dtd xhtml 1.0 frameset//",
+	"-//w3c//dtd xhtml 1.0 transitional//",
+}
+
+// Parse finds the document type declaration in the HTML source and describes it.
+// A doctype preceded by anything other than whitespace is flagged as not being
+// the first token. If it follows an element or text, browsers ignore it and
+// render the page in quirks mode.
+func Parse(htmlSource string) *Doctype {
+	tokenizer := html.NewTokenizer(strings.NewReader(strings.TrimPrefix(htmlSource, "\ufeff")))
+	isFirstToken := true
+	precededByContent := false
+
+	for {
+		tokenType := tokenizer.Next()
+		switch tokenType {
+		case html.ErrorToken:
+			// Reached the end of the document without finding a doctype
+			return &Doctype{Version: VersionNone, Mode: ModeQuirks}
+		case html.DoctypeToken:
+			dt := parseDoctypeData(string(tokenizer.Text()))
+			dt.IsFirstToken = isFirstToken
+			if precededByContent {
+				dt.Mode = ModeQuirks
+			}
+			return dt
+		case html.TextToken:
+			if strings.TrimSpace(string(tokenizer.Text())) == "" {
+				continue
+			}
+			isFirstToken = false
+			precededByContent = true
+		case html.CommentToken:
+			isFirstToken = false
+		default:
+			isFirstToken = false
+			precededByContent = true
+		}
+	}
+}
+
+// parseDoctypeData splits the doctype token data into name, public and system
+// identifiers the same way an HTML5 tokenizer does
+func parseDoctypeData(data string) *Doctype {
+	dt := &Doctype{Present: true}
+	forceQuirks := false
+
+	data = strings.TrimSpace(data)
+	nameEnd := strings.IndexAny(data, " \t\n\f\r")
+	if nameEnd == -1 {
+		nameEnd = len(data)
+	}
+	dt.Name = strings.ToLower(data[:nameEnd])
+	rest := strings.TrimSpace(data[nameEnd:])
+
+	if dt.Name == "" {
+		forceQuirks = true
+	}
+
+	if len(rest) >= 6 {
+		keyword := strings.ToLower(rest[:6])
+		rest = rest[6:]
+		hasPublicID, hasSystemID := false, false
+
+		for keyword == "public" || keyword == "system" {
+			rest = strings.TrimSpace(rest)
+			if rest == "" {
+				// The system identifier is optional after a public identifier
+				forceQuirks = forceQuirks || !hasPublicID
+				break
+			}
+			if rest[0] != '"' && rest[0] != '\'' {
+				forceQuirks = true
+				break
+			}
+			quote := rest[0]
+			rest = rest[1:]
+			id := rest
+			end := strings.IndexByte(rest, quote)
+			if end == -1 {
+				// Unterminated identifiers set the force-quirks flag
+				forceQuirks = true
+				rest = ""
+			} else {
+				id = rest[:end]
+				rest = rest[end+1:]
+			}
+
+			if keyword == "public" {
+				dt.PublicID = id
+				hasPublicID = true
+				keyword = "system"
+			} else {
+				dt.SystemID = id
+				hasSystemID = true
+				keyword = ""
+			}
+		}
+
+		if !hasPublicID && !hasSystemID {
+			forceQuirks = true
+		}
+	} else if rest != "" {
+		forceQuirks = true
+	}
+
+	dt.Version = detectVersion(dt)
+	dt.Mode = detectMode(dt, forceQuirks)
+
+	return dt
+}
+
+func detectVersion(dt *Doctype) Version {
+	if dt.Name != "html" {
+		return VersionUnknown
+	}
+
+	publicID := strings.ToLower(dt.PublicID)
+	if publicID == "" {
+		if dt.SystemID == "" || dt.SystemID == legacyCompatSystemID {
+			return VersionHTML5
+		}
+		return VersionUnknown
+	}
+
+	for _, vp := range versionPrefixes {
+		if strings.HasPrefix(publicID, vp.prefix) {
+			return vp.version
+		}
+	}
+
+	return VersionUnknown
+}
+
+func detectMode(dt *Doctype, forceQuirks bool) Mode {
+	if forceQuirks || dt.Name != "html" {
+		return ModeQuirks
+	}
+
+	publicID := strings.ToLower(dt.PublicID)
+	systemID := strings.ToLower(dt.SystemID)
+
+	if systemID == quirksSystemID {
+		return ModeQuirks
+	}
+
+	for _, id := range quirksPublicIDs {
+		if publicID == id {
+			return ModeQuirks
+		}
+	}
+
+	for _, prefix := range quirksPublicIDPrefixes {
+		if strings.HasPrefix(publicID, prefix) {
+			return ModeQuirks
+		}
+	}
+
+	for _, prefix := range html401LooseIDPrefixes {
+		if strings.HasPrefix(publicID, prefix) {
+			if dt.SystemID == "" {
+				return ModeQuirks
+			}
+			return ModeLimitedQuirks
+		}
+	}
+
+	for _, prefix := range limitedQuirksPublicIDPrefixes {
+		if strings.HasPrefix(publicID, prefix) {
+			return ModeLimitedQuirks
+		}
+	}
+
+	return ModeNoQuirks
+}
@@ -0,0 +1,67 @@
+package doctype
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name            string
+		htmlSource      string
+		expectedVersion Version
+		expectedMode    Mode
+		expectedFirst   bool
+	}{
+		{"HTML5", "<!DOCTYPE html><html></html>", VersionHTML5, ModeNoQuirks, true},
+		{"HTML5 uppercase", "<!DOCTYPE HTML>", VersionHTML5, ModeNoQuirks, true},
+		{"HTML5 legacy compat", `<!DOCTYPE html SYSTEM "about:legacy-compat">`, VersionHTML5, ModeNoQuirks, true},
+		{"HTML5 leading whitespace", "\n  <!DOCTYPE html>", VersionHTML5, ModeNoQuirks, true},
+		{"HTML 4.01 Strict", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" "http://www.w3.org/TR/html4/strict.dtd">`, VersionHTML401Strict, ModeNoQuirks, true},
+		{"HTML 4.01 Transitional", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN" "http://www.w3.org/TR/html4/loose.dtd">`, VersionHTML401Transitional, ModeLimitedQuirks, true},
+		{"HTML 4.01 Transitional without system id", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Transitional//EN">`, VersionHTML401Transitional, ModeQuirks, true},
+		{"HTML 4.01 Frameset", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01 Frameset//EN" "http://www.w3.org/TR/html4/frameset.dtd">`, VersionHTML401Frameset, ModeLimitedQuirks, true},
+		{"HTML 3.2", `<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 3.2 Final//EN">`, VersionHTML32, ModeQuirks, true},
+		{"XHTML 1.0 Strict", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Strict//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-strict.dtd">`, VersionXHTML10Strict, ModeNoQuirks, true},
+		{"XHTML 1.0 Transitional", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Transitional//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd">`, VersionXHTML10Transitional, ModeLimitedQuirks, true},
+		{"XHTML 1.0 Frameset", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.0 Frameset//EN" "http://www.w3.org/TR/xhtml1/DTD/xhtml1-frameset.dtd">`, VersionXHTML10Frameset, ModeLimitedQuirks, true},
+		{"XHTML 1.1", `<!DOCTYPE html PUBLIC "-//W3C//DTD XHTML 1.1//EN" "http://www.w3.org/TR/xhtml11/DTD/xhtml11.dtd">`, VersionXHTML11, ModeNoQuirks, true},
+		{"SVG", `<!DOCTYPE svg PUBLIC "-//W3C//DTD SVG 1.1//EN" "http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd">`, VersionUnknown, ModeQuirks, true},
+		{"Bogus doctype", "<!DOCTYPE html bogus>", VersionHTML5, ModeQuirks, true},
+		{"Missing doctype", "<html><body></body></html>", VersionNone, ModeQuirks, false},
+		{"Empty document", "", VersionNone, ModeQuirks, false},
+		{"Preceded by comment", "<!-- generated --><!DOCTYPE html>", VersionHTML5, ModeNoQuirks, false},
+		{"Preceded by element", "<p>hi</p><!DOCTYPE html>", VersionHTML5, ModeQuirks, false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result := Parse(test.htmlSource)
+			if result.Version != test.expectedVersion {
+				t.Errorf("Parse(%q).Version = %v; want %v", test.htmlSource, result.Version, test.expectedVersion)
+			}
+			if result.Mode != test.expectedMode {
+				t.Errorf("Parse(%q).Mode = %v; want %v", test.htmlSource, result.Mode, test.expectedMode)
+			}
+			if result.IsFirstToken != test.expectedFirst {
+				t.Errorf("Parse(%q).IsFirstToken = %v; want %v", test.htmlSource, result.IsFirstToken, test.expectedFirst)
+			}
+		})
+	}
+}
+
+func TestParse_Identifiers(t *testing.T) {
+	result := Parse(`<!DOCTYPE HTML PUBLIC "-//W3C//DTD HTML 4.01//EN" 'http://www.w3.org/TR/html4/strict.dtd'>`)
+
+	if !result.Present {
+		t.Fatalf("Expected doctype to be present")
+	}
+	if result.Name != "html" {
+		t.Errorf("Expected name %q, got %q", "html", result.Name)
+	}
+	if result.PublicID != "-//W3C//DTD HTML 4.01//EN" {
+		t.Errorf("Expected public id %q, got %q", "-//W3C//DTD HTML 4.01//EN", result.PublicID)
+	}
+	if result.SystemID != "http://www.w3.org/TR/html4/strict.dtd" {
+		t.Errorf("Expected system id %q, got %q", "http://www.w3.org/TR/html4/strict.dtd", result.SystemID)
+	}
+}
@@ -0,0 +1,197 @@
+package extract
+
+import (
+	"errors"
+	"fmt"
+	"lt-app/internal/constants"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xpath"
+	"golang.org/x/net/html"
+)
+
+// What an extractor returns for each match
+const (
+	// The text of the match with whitespace collapsed
+	ValueText = "text"
+	// The outer HTML of the match
+	ValueHTML = "html"
+	// The value of an attribute of the match
+	ValueAttribute = "attribute"
+)
+
+// Extractor names a CSS selector or an XPath expression whose matches are returned
+type Extractor struct {
+	Name  string `json:"name"`
+	CSS   string `json:"css,omitempty"`
+	XPath string `json:"xpath,omitempty"`
+	// text, html or attribute. Defaults to attribute when Attribute is set, else text.
+	Value     string `json:"value,omitempty"`
+	Attribute string `json:"attribute,omitempty"`
+}
+
+type Result struct {
+	Name string `json:"name"`
+	// Number of matches, which can be more than the values returned
+	Count  int      `json:"count"`
+	Values []string `json:"values"`
+}
+
+type compiled struct {
+	Extractor
+	css   cascadia.Selector
+	xpath *xpath.Expr
+}
+
+// Validate reports the first extractor that cannot be run
+func Validate(extractors []Extractor) error {
+	_, err := compile(extractors)
+	return err
+}
+
+func compile(extractors []Extractor) ([]compiled, error) {
+	if len(extractors) > constants.EXTRACTORS_MAX_CAP {
+		return nil, fmt.Errorf("too many extractors, at most %d are allowed", constants.EXTRACTORS_MAX_CAP)
+	}
+
+	names := make(map[string]bool, len(extractors))
+	result := make([]compiled, 0, len(extractors))
+
+	for i, extractor := range extractors {
+		if extractor.Name == "" {
+			return nil, fmt.Errorf("extractor #%d has no name", i+1)
+		}
+		if names[extractor.Name] {
+			return nil, fmt.Errorf("extractor %s is defined twice", extractor.Name)
+		}
+		names[extractor.Name] = true
+
+		c, err := compileOne(extractor)
+		if err != nil {
+			return nil, fmt.Errorf("extractor %s: %w", extractor.Name, err)
+		}
+		result = append(result, c)
+	}
+
+	return result, nil
+}
+
+func compileOne(extractor Extractor) (compiled, error) {
+	c := compiled{Extractor: extractor}
+
+	if c.Value == "" {
+		c.Value = ValueText
+		if c.Attribute != "" {
+			c.Value = ValueAttribute
+		}
+	}
+	switch c.Value {
+	case ValueText, ValueHTML:
+	case ValueAttribute:
+		if c.Attribute == "" {
+			return c, errors.New("attribute values need an attribute name")
+		}
+	default:
+		return c, fmt.Errorf("unknown value %q, expected text, html or attribute", c.Value)
+	}
+
+	var err error
+	switch {
+	case c.CSS != "" && c.XPath != "":
+		return c, errors.New("use either css or xpath, not both")
+	case c.CSS != "":
+		if c.css, err = cascadia.Compile(c.CSS); err != nil {
+			return c, fmt.Errorf("invalid css selector %q: %w", c.CSS, err)
+		}
+	case c.XPath != "":
+		if c.xpath, err = xpath.Compile(c.XPath); err != nil {
+			return c, fmt.Errorf("invalid xpath %q: %w", c.XPath, err)
+		}
+	default:
+		return c, errors.New("a css selector or an xpath expression is required")
+	}
+
+	return c, nil
+}
+
+// Extract runs the extractors against the document, returning their results in
+// the order they were given
+func Extract(doc *goquery.Document, extractors []Extractor) ([]Result, error) {
+	compiledExtractors, err := compile(extractors)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]Result, 0, len(compiledExtractors))
+	for _, c := range compiledExtractors {
+		var values []string
+		if c.css != nil {
+			values = c.extractNodes(doc.FindMatcher(c.css).Nodes)
+		} else {
+			values = c.evaluateXPath(doc)
+		}
+
+		result := Result{Name: c.Name, Count: len(values), Values: values}
+		if len(result.Values) > constants.EXTRACT_VALUES_MAX_CAP {
+			result.Values = result.Values[:constants.EXTRACT_VALUES_MAX_CAP]
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// evaluateXPath also supports expressions that return a number, a string or a
+// boolean, such as count(//a) or string(//title)
+func (c *compiled) evaluateXPath(doc *goquery.Document) []string {
+	if len(doc.Nodes) == 0 {
+		return []string{}
+	}
+	root := doc.Nodes[0]
+
+	switch value := c.xpath.Evaluate(htmlquery.CreateXPathNavigator(root)).(type) {
+	case float64:
+		return []string{strconv.FormatFloat(value, 'f', -1, 64)}
+	case string:
+		return []string{value}
+	case bool:
+		return []string{strconv.FormatBool(value)}
+	default:
+		return c.extractNodes(htmlquery.QuerySelectorAll(root, c.xpath))
+	}
+}
+
+func (c *compiled) extractNodes(nodes []*html.Node) []string {
+	values := []string{}
+	for _, node := range nodes {
+		if value, exists := c.nodeValue(node); exists {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// nodeValue returns the value of a match. Nodes without the attribute are skipped.
+func (c *compiled) nodeValue(node *html.Node) (string, bool) {
+	// XPath attribute matches such as //meta/@content are detached element nodes
+	// holding the attribute value as their text
+	attributeMatch := node.Type == html.ElementNode && node.Parent == nil
+
+	switch {
+	case c.Value == ValueAttribute && !attributeMatch:
+		for _, attr := range node.Attr {
+			if attr.Key == c.Attribute {
+				return attr.Val, true
+			}
+		}
+		return "", false
+	case c.Value == ValueHTML && !attributeMatch:
+		return htmlquery.OutputHTML(node, true), true
+	default:
+		return strings.Join(strings.Fields(htmlquery.InnerText(node)), " "), true
+	}
+}
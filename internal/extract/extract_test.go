@@ -0,0 +1,157 @@
+package extract
+
+import (
+	"lt-app/internal/constants"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const productPage = `<html><head>
+	<title>Blue Kettle</title>
+	<meta property="og:price:amount" content="39.90">
+</head><body>
+	<nav class="breadcrumbs"><a href="/">Home</a> &rsaquo; <a href="/kitchen">Kitchen</a> &rsaquo; <span>Kettles</span></nav>
+	<div class="product">
+		<h1>Blue   Kettle</h1>
+		<span class="price" data-currency="EUR">€ 39.90</span>
+		<span itemprop="sku">KT-1001</span>
+		<a href="/manual.pdf">Manual</a>
+		<a>No link</a>
+	</div>
+</body></html>`
+
+func parseDoc(t *testing.T, htmlSource string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlSource))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	return doc
+}
+
+func TestExtract(t *testing.T) {
+	doc := parseDoc(t, productPage)
+
+	tests := []struct {
+		extractor Extractor
+		expected  Result
+	}{
+		{
+			Extractor{Name: "title", CSS: "h1"},
+			Result{Name: "title", Count: 1, Values: []string{"Blue Kettle"}},
+		},
+		{
+			Extractor{Name: "breadcrumbs", CSS: ".breadcrumbs a, .breadcrumbs span"},
+			Result{Name: "breadcrumbs", Count: 3, Values: []string{"Home", "Kitchen", "Kettles"}},
+		},
+		{
+			Extractor{Name: "currency", CSS: ".price", Attribute: "data-currency"},
+			Result{Name: "currency", Count: 1, Values: []string{"EUR"}},
+		},
+		{
+			Extractor{Name: "links", CSS: ".product a", Value: ValueAttribute, Attribute: "href"},
+			Result{Name: "links", Count: 1, Values: []string{"/manual.pdf"}},
+		},
+		{
+			Extractor{Name: "price-html", CSS: ".price", Value: ValueHTML},
+			Result{Name: "price-html", Count: 1, Values: []string{`<span class="price" data-currency="EUR">€ 39.90</span>`}},
+		},
+		{
+			Extractor{Name: "missing", CSS: ".discount"},
+			Result{Name: "missing", Count: 0, Values: []string{}},
+		},
+		{
+			Extractor{Name: "sku", XPath: `//span[@itemprop="sku"]`},
+			Result{Name: "sku", Count: 1, Values: []string{"KT-1001"}},
+		},
+		{
+			Extractor{Name: "price", XPath: `//meta[@property="og:price:amount"]/@content`},
+			Result{Name: "price", Count: 1, Values: []string{"39.90"}},
+		},
+		{
+			Extractor{Name: "sku-attribute", XPath: `//span[@itemprop]`, Attribute: "itemprop"},
+			Result{Name: "sku-attribute", Count: 1, Values: []string{"sku"}},
+		},
+		{
+			Extractor{Name: "text-nodes", XPath: `//nav/a/text()`},
+			Result{Name: "text-nodes", Count: 2, Values: []string{"Home", "Kitchen"}},
+		},
+		{
+			Extractor{Name: "link-count", XPath: `count(//a)`},
+			Result{Name: "link-count", Count: 1, Values: []string{"4"}},
+		},
+		{
+			Extractor{Name: "page-title", XPath: `string(//title)`},
+			Result{Name: "page-title", Count: 1, Values: []string{"Blue Kettle"}},
+		},
+		{
+			Extractor{Name: "has-sku", XPath: `boolean(//*[@itemprop="sku"])`},
+			Result{Name: "has-sku", Count: 1, Values: []string{"true"}},
+		},
+	}
+
+	extractors := []Extractor{}
+	for _, test := range tests {
+		extractors = append(extractors, test.extractor)
+	}
+
+	results, err := Extract(doc, extractors)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != len(tests) {
+		t.Fatalf("Expected %d results, got %d", len(tests), len(results))
+	}
+
+	for i, test := range tests {
+		if !reflect.DeepEqual(results[i], test.expected) {
+			t.Errorf("%s: expected %+v, got %+v", test.extractor.Name, test.expected, results[i])
+		}
+	}
+}
+
+func TestExtract_CapsValues(t *testing.T) {
+	doc := parseDoc(t, "<ul>"+strings.Repeat("<li>item</li>", constants.EXTRACT_VALUES_MAX_CAP+5)+"</ul>")
+
+	results, err := Extract(doc, []Extractor{{Name: "items", CSS: "li"}})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if results[0].Count != constants.EXTRACT_VALUES_MAX_CAP+5 || len(results[0].Values) != constants.EXTRACT_VALUES_MAX_CAP {
+		t.Errorf("Expected %d matches and %d values, got %d and %d",
+			constants.EXTRACT_VALUES_MAX_CAP+5, constants.EXTRACT_VALUES_MAX_CAP, results[0].Count, len(results[0].Values))
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tooMany := make([]Extractor, constants.EXTRACTORS_MAX_CAP+1)
+	for i := range tooMany {
+		tooMany[i] = Extractor{Name: strings.Repeat("x", i+1), CSS: "a"}
+	}
+
+	tests := map[string][]Extractor{
+		"missing name":         {{CSS: "a"}},
+		"duplicate name":       {{Name: "a", CSS: "a"}, {Name: "a", CSS: "b"}},
+		"no selector":          {{Name: "a"}},
+		"css and xpath":        {{Name: "a", CSS: "a", XPath: "//a"}},
+		"invalid css":          {{Name: "a", CSS: "a["}},
+		"invalid xpath":        {{Name: "a", XPath: "//a["}},
+		"unknown value":        {{Name: "a", CSS: "a", Value: "json"}},
+		"attribute without it": {{Name: "a", CSS: "a", Value: ValueAttribute}},
+		"too many extractors":  tooMany,
+	}
+
+	for name, extractors := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := Validate(extractors); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+
+	if err := Validate([]Extractor{{Name: "a", CSS: "a"}, {Name: "b", XPath: "//b", Value: ValueHTML}}); err != nil {
+		t.Errorf("Expected valid extractors, got %v", err)
+	}
+}
@@ -0,0 +1,48 @@
+package findings
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Category groups findings by the area of page quality they affect
+type Category string
+
+const (
+	CategorySEO           Category = "seo"
+	CategoryAccessibility Category = "accessibility"
+	CategoryLinks         Category = "links"
+	CategorySecurity      Category = "security"
+	CategoryBestPractices Category = "best-practices"
+)
+
+// Finding is a single problem detected on the page. ID identifies the check
+// that produced it and stays stable so findings can be compared across runs.
+type Finding struct {
+	ID       string   `json:"id"`
+	Category Category `json:"category"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Target   string   `json:"target,omitempty"`
+}
+
+// ParseSeverity returns the severity with the given name, as written in rule files and scripts
+func ParseSeverity(name string) (Severity, bool) {
+	switch severity := Severity(name); severity {
+	case SeverityInfo, SeverityWarning, SeverityError:
+		return severity, true
+	}
+	return "", false
+}
+
+// ParseCategory returns the category with the given name, as written in rule files and scripts
+func ParseCategory(name string) (Category, bool) {
+	switch category := Category(name); category {
+	case CategorySEO, CategoryAccessibility, CategoryLinks, CategorySecurity, CategoryBestPractices:
+		return category, true
+	}
+	return "", false
+}
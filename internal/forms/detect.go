@@ -0,0 +1,403 @@
+package forms
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type FormType string
+
+const (
+	FormTypeLogin         FormType = "login"
+	FormTypeSignup        FormType = "signup"
+	FormTypePasswordReset FormType = "password-reset"
+	FormTypeSearch        FormType = "search"
+	FormTypeNewsletter    FormType = "newsletter"
+	FormTypeOther         FormType = "other"
+)
+
+// Minimum score a form needs before it is classified as anything but "other"
+const minClassificationScore = 4
+
+// Classification order used to break ties between equal scores
+var formTypes = []FormType{FormTypeLogin, FormTypeSignup, FormTypePasswordReset, FormTypeSearch, FormTypeNewsletter}
+
+type FormDetection struct {
+	// Index of the form in document order. Inputs that are not inside a form are
+	// grouped into a single pseudo form with index -1.
+	Index   int              `json:"index"`
+	ID      string           `json:"id"`
+	Name    string           `json:"name"`
+	Action  string           `json:"action"`
+	Method  string           `json:"method"`
+	Type    FormType         `json:"type"`
+	Score   int              `json:"score"`
+	Scores  map[FormType]int `json:"scores"`
+	Signals []string         `json:"signals"`
+}
+
+type Detection struct {
+	HasLoginForm bool            `json:"hasLoginForm"`
+	HasSSOLogin  bool            `json:"hasSsoLogin"`
+	SSOProviders []string        `json:"ssoProviders"`
+	Forms        []FormDetection `json:"forms"`
+}
+
+// HasLogin reports whether the page offers any way to sign in, either through a
+// login form or through a single sign-on button
+func (d *Detection) HasLogin() bool {
+	return d.HasLoginForm || d.HasSSOLogin
+}
+
+type scorer struct {
+	scores  map[FormType]int
+	signals []string
+}
+
+func (s *scorer) add(formType FormType, points int, signal string) {
+	s.scores[formType] += points
+	s.signals = append(s.signals, fmt.Sprintf("%s %+d: %s", formType, points, signal))
+}
+
+// Detect classifies every form of the document. The keyword sets of the given
+// languages are used to read button labels and form text. English is always
+// included, and all known languages are used when none are given.
+func Detect(doc *goquery.Document, languages ...string) *Detection {
+	keywords := selectKeywordSets(languages)
+	detection := &Detection{Forms: []FormDetection{}, SSOProviders: []string{}}
+
+	doc.Find("form").Each(func(i int, form *goquery.Selection) {
+		formDetection := classify(form, form.Find("input, select, textarea, button"), keywords)
+		formDetection.Index = i
+		formDetection.ID, _ = form.Attr("id")
+		formDetection.Name, _ = form.Attr("name")
+		formDetection.Action, _ = form.Attr("action")
+		formDetection.Method = strings.ToLower(form.AttrOr("method", "get"))
+		detection.Forms = append(detection.Forms, formDetection)
+	})
+
+	// Single page apps often render inputs without a form element
+	orphanControls := doc.Find("input, select, textarea, button").FilterFunction(func(_ int, s *goquery.Selection) bool {
+		return s.Closest("form").Length() == 0
+	})
+	if orphanControls.Filter("input[type='password']").Length() > 0 {
+		formDetection := classify(doc.Find("body"), orphanControls, keywords)
+		formDetection.Index = -1
+		detection.Forms = append(detection.Forms, formDetection)
+	}
+
+	for _, form := range detection.Forms {
+		if form.Type == FormTypeLogin {
+			detection.HasLoginForm = true
+		}
+	}
+
+	detection.SSOProviders = detectSSOProviders(doc)
+	detection.HasSSOLogin = len(detection.SSOProviders) > 0
+
+	return detection
+}
+
+func selectKeywordSets(languages []string) []map[FormType][]string {
+	selected := []map[FormType][]string{}
+	seen := map[string]bool{}
+
+	for _, lang := range append(append([]string{}, languages...), "en") {
+		// Only the primary subtag is relevant, e.g. "de" of "de-AT"
+		primary := strings.ToLower(strings.SplitN(strings.ReplaceAll(lang, "_", "-"), "-", 2)[0])
+		if set, ok := keywordSets[primary]; ok && !seen[primary] {
+			seen[primary] = true
+			selected = append(selected, set)
+		}
+	}
+
+	if len(languages) == 0 {
+		langs := make([]string, 0, len(keywordSets))
+		for lang := range keywordSets {
+			langs = append(langs, lang)
+		}
+		sort.Strings(langs)
+
+		for _, lang := range langs {
+			if !seen[lang] {
+				selected = append(selected, keywordSets[lang])
+			}
+		}
+	}
+
+	return selected
+}
+
+func classify(scope *goquery.Selection, controls *goquery.Selection, keywords []map[FormType][]string) FormDetection {
+	s := &scorer{scores: map[FormType]int{}}
+
+	inputs := controls.Filter("input")
+	passwords := inputs.Filter("[type='password']")
+	visibleInputs := inputs.FilterFunction(func(_ int, input *goquery.Selection) bool {
+		inputType := strings.ToLower(input.AttrOr("type", "text"))
+		return inputType != "hidden" && inputType != "submit" && inputType != "button" && inputType != "image" && inputType != "reset"
+	})
+
+	switch passwords.Length() {
+	case 0:
+		s.add(FormTypeLogin, -4, "no password field")
+	case 1:
+		s.add(FormTypeLogin, 3, "one password field")
+		s.add(FormTypeSignup, 1, "one password field")
+	default:
+		s.add(FormTypeSignup, 3, "password confirmation field")
+		s.add(FormTypePasswordReset, 2, "password confirmation field")
+	}
+
+	scoreAutocomplete(s, controls)
+	scoreInputs(s, visibleInputs, passwords.Length())
+
+	if goquery.NodeName(scope) == "form" {
+		if role, _ := scope.Attr("role"); role == "search" {
+			s.add(FormTypeSearch, 4, `role="search"`)
+		}
+
+		attrText := strings.ToLower(strings.Join([]string{
+			scope.AttrOr("id", ""), scope.AttrOr("name", ""), scope.AttrOr("class", ""), scope.AttrOr("action", ""),
+		}, " "))
+		for _, formType := range formTypes {
+			for _, keyword := range attributeKeywords[formType] {
+				if strings.Contains(attrText, keyword) {
+					s.add(formType, 2, fmt.Sprintf("form attributes contain %q", keyword))
+					break
+				}
+			}
+		}
+	}
+
+	for _, label := range submitLabels(controls) {
+		for _, formType := range formTypes {
+			if keyword := matchKeyword(label, formType, keywords); keyword != "" {
+				s.add(formType, 3, fmt.Sprintf("submit label %q", label))
+			}
+		}
+	}
+
+	// Links such as "Forgot password?" sit next to login forms, not inside reset forms
+	scope.Find("a").Each(func(_ int, link *goquery.Selection) {
+		text := strings.ToLower(strings.TrimSpace(link.Text()))
+		if matchKeyword(text, FormTypePasswordReset, keywords) != "" && passwords.Length() > 0 {
+			s.add(FormTypeLogin, 2, fmt.Sprintf("link %q", text))
+		}
+	})
+
+	if goquery.NodeName(scope) == "form" {
+		formText := strings.ToLower(scope.Clone().Find("a, button, script, style").Remove().End().Text())
+		for _, formType := range formTypes {
+			if keyword := matchKeyword(formText, formType, keywords); keyword != "" {
+				s.add(formType, 1, fmt.Sprintf("form text contains %q", keyword))
+			}
+		}
+	}
+
+	detection := FormDetection{Type: FormTypeOther, Scores: s.scores, Signals: s.signals}
+	for _, formType := range formTypes {
+		if s.scores[formType] > detection.Score {
+			detection.Score = s.scores[formType]
+			detection.Type = formType
+		}
+	}
+	if detection.Score < minClassificationScore {
+		detection.Type = FormTypeOther
+	}
+
+	return detection
+}
+
+func scoreAutocomplete(s *scorer, controls *goquery.Selection) {
+	tokens := map[string]bool{}
+	controls.Each(func(_ int, control *goquery.Selection) {
+		for _, token := range strings.Fields(strings.ToLower(control.AttrOr("autocomplete", ""))) {
+			tokens[token] = true
+		}
+	})
+
+	if tokens["current-password"] {
+		s.add(FormTypeLogin, 4, `autocomplete="current-password"`)
+	}
+	if tokens["new-password"] {
+		s.add(FormTypeSignup, 2, `autocomplete="new-password"`)
+		s.add(FormTypePasswordReset, 2, `autocomplete="new-password"`)
+	}
+	if tokens["one-time-code"] {
+		s.add(FormTypeLogin, 1, `autocomplete="one-time-code"`)
+	}
+	if tokens["username"] && tokens["current-password"] {
+		s.add(FormTypeLogin, 1, `autocomplete="username"`)
+	}
+	for _, token := range []string{"name", "given-name", "family-name", "tel", "bday", "street-address", "postal-code"} {
+		if tokens[token] {
+			s.add(FormTypeSignup, 2, fmt.Sprintf("autocomplete=%q", token))
+			break
+		}
+	}
+}
+
+func scoreInputs(s *scorer, visibleInputs *goquery.Selection, passwordCount int) {
+	emailInputs := 0
+	visibleInputs.Each(func(_ int, input *goquery.Selection) {
+		inputType := strings.ToLower(input.AttrOr("type", "text"))
+		name := strings.ToLower(input.AttrOr("name", "") + " " + input.AttrOr("id", ""))
+
+		switch {
+		case inputType == "search":
+			s.add(FormTypeSearch, 4, `input type="search"`)
+		case inputType == "email" || strings.Contains(name, "email"):
+			emailInputs++
+		case inputType == "checkbox" && strings.Contains(name, "remember"):
+			s.add(FormTypeLogin, 2, "remember me checkbox")
+		case inputType == "checkbox" && containsAny(name, "terms", "agree", "consent", "tos", "privacy"):
+			s.add(FormTypeSignup, 2, "terms checkbox")
+		}
+
+		if inputType == "text" || inputType == "search" {
+			for _, field := range strings.Fields(name) {
+				if field == "q" || field == "s" || field == "query" || field == "search" || field == "keywords" {
+					s.add(FormTypeSearch, 3, fmt.Sprintf("query field %q", field))
+					break
+				}
+			}
+		}
+	})
+
+	if passwordCount == 0 && emailInputs == 1 {
+		s.add(FormTypeNewsletter, 2, "single email field")
+		s.add(FormTypePasswordReset, 1, "single email field")
+	}
+
+	// Registration forms ask for more than credentials
+	if passwordCount > 0 && visibleInputs.Length()-passwordCount >= 3 {
+		s.add(FormTypeSignup, 2, "many fields besides the password")
+	}
+}
+
+// submitLabels returns the lower-cased labels of everything that can submit the form
+func submitLabels(controls *goquery.Selection) []string {
+	labels := []string{}
+
+	controls.Each(func(_ int, control *goquery.Selection) {
+		var label string
+		switch goquery.NodeName(control) {
+		case "button":
+			buttonType := strings.ToLower(control.AttrOr("type", "submit"))
+			if buttonType == "submit" || buttonType == "button" {
+				label = control.Text()
+				if strings.TrimSpace(label) == "" {
+					label = control.AttrOr("aria-label", "")
+				}
+			}
+		case "input":
+			switch strings.ToLower(control.AttrOr("type", "")) {
+			case "submit":
+				label = control.AttrOr("value", "submit")
+			case "image":
+				label = control.AttrOr("alt", "")
+			}
+		}
+
+		if label = strings.ToLower(strings.TrimSpace(label)); label != "" {
+			labels = append(labels, label)
+		}
+	})
+
+	return labels
+}
+
+// matchKeyword returns the first keyword of the form type found in the text
+func matchKeyword(text string, formType FormType, keywords []map[FormType][]string) string {
+	for _, set := range keywords {
+		for _, keyword := range set[formType] {
+			if containsWord(text, keyword) {
+				return keyword
+			}
+		}
+	}
+	return ""
+}
+
+// containsWord reports whether keyword occurs in text without being part of a
+// longer word. Scripts written without spaces (CJK) only need a substring match.
+func containsWord(text string, keyword string) bool {
+	for offset := 0; ; {
+		index := strings.Index(text[offset:], keyword)
+		if index == -1 {
+			return false
+		}
+		start := offset + index
+		end := start + len(keyword)
+
+		before, after := ' ', ' '
+		if start > 0 {
+			before, _ = utf8.DecodeLastRuneInString(text[:start])
+		}
+		if end < len(text) {
+			after, _ = utf8.DecodeRuneInString(text[end:])
+		}
+
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		offset = end
+	}
+}
+
+func isWordRune(r rune) bool {
+	if unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) {
+		return false
+	}
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func containsAny(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
+
+func detectSSOProviders(doc *goquery.Document) []string {
+	found := map[string]bool{}
+
+	doc.Find("a[href], form[action], button, [role='button'], script[src]").Each(func(_ int, s *goquery.Selection) {
+		target := strings.ToLower(s.AttrOr("href", "") + " " + s.AttrOr("action", "") + " " + s.AttrOr("src", ""))
+		label := strings.ToLower(strings.Join(strings.Fields(s.Text()+" "+s.AttrOr("aria-label", "")), " "))
+
+		for _, provider := range ssoProviders {
+			for _, url := range provider.urls {
+				if strings.Contains(target, url) {
+					found[provider.name] = true
+				}
+			}
+			for _, template := range ssoLabelTemplates {
+				if containsWord(label, fmt.Sprintf(template, provider.name)) {
+					found[provider.name] = true
+				}
+			}
+		}
+	})
+
+	// Google Identity Services renders its button into these containers
+	if doc.Find("#g_id_onload, .g_id_signin").Length() > 0 {
+		found["google"] = true
+	}
+
+	providers := make([]string, 0, len(found))
+	for provider := range found {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+
+	return providers
+}
@@ -0,0 +1,190 @@
+package forms
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func newDocument(t *testing.T, htmlSource string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlSource))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	return doc
+}
+
+func TestDetect_FormTypes(t *testing.T) {
+	tests := []struct {
+		name       string
+		htmlSource string
+		expected   FormType
+	}{
+		{
+			name: "Login with button",
+			htmlSource: `<form action="/login" method="post">
+				<input type="text" name="username"><input type="password" name="password">
+				<button type="submit">Sign In</button></form>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name: "Login with submit input",
+			htmlSource: `<form method="post"><input type="email" name="email">
+				<input type="password" name="pwd"><input type="submit" value="Log in"></form>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name: "Login with autocomplete only",
+			htmlSource: `<form method="post"><input name="u" autocomplete="username">
+				<input type="password" name="p" autocomplete="current-password"><button>Go</button></form>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name: "German login",
+			htmlSource: `<form method="post"><input type="text" name="benutzer">
+				<input type="password" name="kennwort"><button>Anmelden</button></form>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name: "Japanese login",
+			htmlSource: `<form method="post"><input type="text" name="id">
+				<input type="password" name="pw"><button>ログイン</button></form>`,
+			expected: FormTypeLogin,
+		},
+		{
+			name: "Registration",
+			htmlSource: `<form action="/users" method="post"><input name="name"><input type="email" name="email">
+				<input type="password" name="password"><input type="password" name="password_confirmation">
+				<input type="checkbox" name="accept_terms"><button>Create account</button></form>`,
+			expected: FormTypeSignup,
+		},
+		{
+			name: "Password reset request",
+			htmlSource: `<form action="/password/forgot" method="post"><input type="email" name="email">
+				<button>Send reset link</button></form>`,
+			expected: FormTypePasswordReset,
+		},
+		{
+			name:       "Search",
+			htmlSource: `<form action="/search" role="search"><input type="text" name="q"><button>Search</button></form>`,
+			expected:   FormTypeSearch,
+		},
+		{
+			name: "Newsletter",
+			htmlSource: `<form action="https://list.example.com/subscribe" method="post">
+				<input type="email" name="EMAIL"><input type="submit" value="Subscribe"></form>`,
+			expected: FormTypeNewsletter,
+		},
+		{
+			name:       "Contact form",
+			htmlSource: `<form method="post"><input name="subject"><textarea name="message"></textarea><button>Send</button></form>`,
+			expected:   FormTypeOther,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			detection := Detect(newDocument(t, test.htmlSource))
+
+			if len(detection.Forms) != 1 {
+				t.Fatalf("Expected 1 form, got %d", len(detection.Forms))
+			}
+
+			form := detection.Forms[0]
+			if form.Type != test.expected {
+				t.Errorf("Expected form type %q, got %q (scores %v, signals %v)", test.expected, form.Type, form.Scores, form.Signals)
+			}
+
+			if detection.HasLoginForm != (test.expected == FormTypeLogin) {
+				t.Errorf("Expected HasLoginForm %v, got %v", test.expected == FormTypeLogin, detection.HasLoginForm)
+			}
+		})
+	}
+}
+
+func TestDetect_FormDetails(t *testing.T) {
+	detection := Detect(newDocument(t, `<form id="search-form" action="/find"><input type="search" name="q"></form>
+		<form id="login" name="login" action="/session" method="POST"><input type="password"><button>Login</button></form>`))
+
+	if len(detection.Forms) != 2 {
+		t.Fatalf("Expected 2 forms, got %d", len(detection.Forms))
+	}
+
+	login := detection.Forms[1]
+	if login.Index != 1 || login.ID != "login" || login.Name != "login" || login.Action != "/session" || login.Method != "post" {
+		t.Errorf("Unexpected form details %+v", login)
+	}
+	if len(login.Signals) == 0 {
+		t.Errorf("Expected signals to be reported")
+	}
+	if login.Score != login.Scores[FormTypeLogin] {
+		t.Errorf("Expected score %d to match the login score %d", login.Score, login.Scores[FormTypeLogin])
+	}
+}
+
+func TestDetect_FormlessLogin(t *testing.T) {
+	detection := Detect(newDocument(t, `<div class="app"><input type="email" autocomplete="username">
+		<input type="password" autocomplete="current-password"><button>Sign in</button></div>`))
+
+	if !detection.HasLoginForm {
+		t.Errorf("Expected formless login to be detected")
+	}
+	if len(detection.Forms) != 1 || detection.Forms[0].Index != -1 {
+		t.Errorf("Expected a single pseudo form, got %+v", detection.Forms)
+	}
+}
+
+func TestDetect_SSOProviders(t *testing.T) {
+	detection := Detect(newDocument(t, `<div>
+		<a href="https://accounts.google.com/o/oauth2/v2/auth?client_id=1">Google</a>
+		<button>Continue with GitHub</button>
+		<a href="/auth/microsoft">Mit Microsoft anmelden</a>
+		<a href="https://www.facebook.com/example">Follow us on Facebook</a></div>`))
+
+	expected := []string{"github", "google", "microsoft"}
+	if !reflect.DeepEqual(detection.SSOProviders, expected) {
+		t.Errorf("Expected SSO providers %v, got %v", expected, detection.SSOProviders)
+	}
+	if !detection.HasSSOLogin || !detection.HasLogin() {
+		t.Errorf("Expected SSO-only page to offer a login")
+	}
+	if detection.HasLoginForm {
+		t.Errorf("Expected no login form")
+	}
+}
+
+func TestDetect_LanguageSelection(t *testing.T) {
+	htmlSource := `<form method="post"><input type="text" name="u"><input type="password" name="p"><button>Accedi</button></form>`
+
+	if got := Detect(newDocument(t, htmlSource), "it").Forms[0].Scores[FormTypeLogin]; got <= Detect(newDocument(t, htmlSource), "de").Forms[0].Scores[FormTypeLogin] {
+		t.Errorf("Expected the Italian keyword set to raise the login score, got %d", got)
+	}
+}
+
+func TestContainsWord(t *testing.T) {
+	tests := []struct {
+		text     string
+		keyword  string
+		expected bool
+	}{
+		{"sign in", "sign in", true},
+		{"please log in now", "log in", true},
+		{"besuchen sie uns", "suche", false},
+		{"suche", "suche", true},
+		{"ログインする", "ログイン", true},
+		{"über login", "login", true},
+		{"loginé", "login", false},
+		{"élogin", "login", false},
+		{"", "login", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			if result := containsWord(test.text, test.keyword); result != test.expected {
+				t.Errorf("containsWord(%q, %q) = %v; want %v", test.text, test.keyword, result, test.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,183 @@
+package forms
+
+import (
+	"fmt"
+	"lt-app/internal/findings"
+	"lt-app/internal/utils"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type Input struct {
+	Tag          string `json:"tag"`
+	Type         string `json:"type"`
+	Name         string `json:"name"`
+	ID           string `json:"id"`
+	Required     bool   `json:"required"`
+	Autocomplete string `json:"autocomplete"`
+}
+
+type Form struct {
+	Index    int                `json:"index"`
+	ID       string             `json:"id"`
+	Name     string             `json:"name"`
+	Action   string             `json:"action"`
+	Method   string             `json:"method"`
+	Enctype  string             `json:"enctype"`
+	Inputs   []Input            `json:"inputs"`
+	Findings []findings.Finding `json:"findings"`
+}
+
+type Inventory struct {
+	Forms    []Form             `json:"forms"`
+	Findings []findings.Finding `json:"findings"`
+}
+
+// Names of hidden fields frameworks use to carry anti-CSRF tokens
+var csrfFieldPattern = regexp.MustCompile(`(?i)(csrf|xsrf|authenticity_token|verificationtoken|antiforgery|_token|nonce)`)
+
+// BuildInventory describes every form of the document. Form actions are resolved
+// against baseUrl, which is the page URL or the href of its <base> element.
+func BuildInventory(doc *goquery.Document, webPageUrl string, baseUrl string) *Inventory {
+	inventory := &Inventory{Forms: []Form{}, Findings: []findings.Finding{}}
+	pageUrl, _ := url.Parse(webPageUrl)
+
+	doc.Find("form").Each(func(i int, s *goquery.Selection) {
+		form := Form{
+			Index:    i,
+			ID:       s.AttrOr("id", ""),
+			Name:     s.AttrOr("name", ""),
+			Method:   strings.ToLower(strings.TrimSpace(s.AttrOr("method", "get"))),
+			Enctype:  s.AttrOr("enctype", "application/x-www-form-urlencoded"),
+			Inputs:   []Input{},
+			Findings: []findings.Finding{},
+		}
+
+		action, err := utils.ResolveURL(baseUrl, s.AttrOr("action", ""))
+		if err != nil {
+			action = s.AttrOr("action", "")
+		}
+		form.Action = action
+
+		s.Find("input, select, textarea").Each(func(_ int, control *goquery.Selection) {
+			tag := goquery.NodeName(control)
+			inputType := strings.ToLower(control.AttrOr("type", ""))
+			if tag == "input" && inputType == "" {
+				inputType = "text"
+			}
+			_, required := control.Attr("required")
+
+			form.Inputs = append(form.Inputs, Input{
+				Tag:          tag,
+				Type:         inputType,
+				Name:         control.AttrOr("name", ""),
+				ID:           control.AttrOr("id", ""),
+				Required:     required,
+				Autocomplete: strings.ToLower(control.AttrOr("autocomplete", "")),
+			})
+		})
+
+		form.Findings = checkFormSecurity(s, &form, pageUrl)
+		inventory.Findings = append(inventory.Findings, form.Findings...)
+		inventory.Forms = append(inventory.Forms, form)
+	})
+
+	return inventory
+}
+
+func checkFormSecurity(s *goquery.Selection, form *Form, pageUrl *url.URL) []findings.Finding {
+	result := []findings.Finding{}
+	target := formTarget(form)
+
+	hasPassword := false
+	for _, input := range form.Inputs {
+		if input.Type == "password" {
+			hasPassword = true
+		}
+	}
+
+	actionUrl, err := url.Parse(form.Action)
+	if err != nil {
+		return result
+	}
+
+	if hasPassword && actionUrl.Scheme == "http" {
+		result = append(result, findings.Finding{
+			ID:       "form-password-insecure-action",
+			Category: findings.CategorySecurity,
+			Severity: findings.SeverityError,
+			Message:  fmt.Sprintf("Form with a password field submits over unencrypted HTTP to %s", form.Action),
+			Target:   target,
+		})
+	}
+
+	isPost := form.Method == "post"
+
+	if pageUrl != nil && (actionUrl.Scheme == "http" || actionUrl.Scheme == "https") &&
+		(actionUrl.Scheme != pageUrl.Scheme || !strings.EqualFold(actionUrl.Host, pageUrl.Host)) {
+		severity := findings.SeverityInfo
+		if isPost {
+			severity = findings.SeverityWarning
+		}
+		result = append(result, findings.Finding{
+			ID:       "form-cross-origin-action",
+			Category: findings.CategorySecurity,
+			Severity: severity,
+			Message:  fmt.Sprintf("Form submits to a different origin: %s", actionUrl.Scheme+"://"+actionUrl.Host),
+			Target:   target,
+		})
+	}
+
+	if isPost && !hasCSRFToken(form) {
+		result = append(result, findings.Finding{
+			ID:       "form-missing-csrf-token",
+			Category: findings.CategorySecurity,
+			Severity: findings.SeverityWarning,
+			Message:  "POST form has no hidden field that looks like an anti-CSRF token",
+			Target:   target,
+		})
+	}
+
+	formAutocompleteOff := strings.EqualFold(strings.TrimSpace(s.AttrOr("autocomplete", "")), "off")
+	for _, input := range form.Inputs {
+		if input.Type != "password" {
+			continue
+		}
+		if input.Autocomplete == "off" || (input.Autocomplete == "" && formAutocompleteOff) {
+			result = append(result, findings.Finding{
+				ID:       "form-password-autocomplete-off",
+				Category: findings.CategorySecurity,
+				Severity: findings.SeverityWarning,
+				Message:  "Password field disables autocomplete, which prevents password managers from filling it",
+				Target:   target,
+			})
+			break
+		}
+	}
+
+	return result
+}
+
+func hasCSRFToken(form *Form) bool {
+	for _, input := range form.Inputs {
+		if input.Type == "hidden" && csrfFieldPattern.MatchString(input.Name) {
+			return true
+		}
+	}
+	return false
+}
+
+// formTarget returns a short description of the form used in findings
+func formTarget(form *Form) string {
+	switch {
+	case form.ID != "":
+		return "form#" + form.ID
+	case form.Name != "":
+		return fmt.Sprintf("form[name=%q]", form.Name)
+	default:
+		return fmt.Sprintf("form #%d", form.Index+1)
+	}
+}
@@ -0,0 +1,105 @@
+package forms
+
+import (
+	"lt-app/internal/findings"
+	"testing"
+)
+
+func findingIDs(list []findings.Finding) []string {
+	ids := []string{}
+	for _, finding := range list {
+		ids = append(ids, finding.ID)
+	}
+	return ids
+}
+
+func TestBuildInventory(t *testing.T) {
+	doc := newDocument(t, `<form id="login" action="/session" method="POST">
+		<input type="hidden" name="authenticity_token" value="x">
+		<input type="email" name="email" required>
+		<input type="password" name="password" autocomplete="current-password" required>
+		<select name="lang"><option>en</option></select>
+		<button>Log in</button></form>`)
+
+	inventory := BuildInventory(doc, "https://example.com/account/", "https://example.com/account/")
+
+	if len(inventory.Forms) != 1 {
+		t.Fatalf("Expected 1 form, got %d", len(inventory.Forms))
+	}
+
+	form := inventory.Forms[0]
+	if form.Action != "https://example.com/session" {
+		t.Errorf("Expected action to be resolved, got %q", form.Action)
+	}
+	if form.Method != "post" {
+		t.Errorf("Expected method post, got %q", form.Method)
+	}
+	if len(form.Inputs) != 4 {
+		t.Fatalf("Expected 4 inputs, got %d", len(form.Inputs))
+	}
+	if email := form.Inputs[1]; email.Type != "email" || email.Name != "email" || !email.Required {
+		t.Errorf("Unexpected email input %+v", email)
+	}
+	if lang := form.Inputs[3]; lang.Tag != "select" || lang.Required {
+		t.Errorf("Unexpected select input %+v", lang)
+	}
+	if len(inventory.Findings) != 0 {
+		t.Errorf("Expected no findings, got %v", findingIDs(inventory.Findings))
+	}
+}
+
+func TestBuildInventory_SecurityFindings(t *testing.T) {
+	tests := []struct {
+		name       string
+		pageUrl    string
+		htmlSource string
+		expected   []string
+	}{
+		{
+			name:       "Password over HTTP",
+			pageUrl:    "http://example.com/",
+			htmlSource: `<form action="/login" method="post"><input type="hidden" name="csrf_token"><input type="password"></form>`,
+			expected:   []string{"form-password-insecure-action"},
+		},
+		{
+			name:       "Cross origin post without token",
+			pageUrl:    "https://example.com/",
+			htmlSource: `<form action="https://crm.example.net/lead" method="post"><input name="email"></form>`,
+			expected:   []string{"form-cross-origin-action", "form-missing-csrf-token"},
+		},
+		{
+			name:       "Cross origin search",
+			pageUrl:    "https://example.com/",
+			htmlSource: `<form action="https://www.google.com/search"><input name="q"></form>`,
+			expected:   []string{"form-cross-origin-action"},
+		},
+		{
+			name:       "Autocomplete off on form",
+			pageUrl:    "https://example.com/",
+			htmlSource: `<form autocomplete="off"><input type="password"></form>`,
+			expected:   []string{"form-password-autocomplete-off"},
+		},
+		{
+			name:       "Autocomplete off on field",
+			pageUrl:    "https://example.com/",
+			htmlSource: `<form><input type="password" autocomplete="off"></form>`,
+			expected:   []string{"form-password-autocomplete-off"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inventory := BuildInventory(newDocument(t, test.htmlSource), test.pageUrl, test.pageUrl)
+			ids := findingIDs(inventory.Findings)
+
+			if len(ids) != len(test.expected) {
+				t.Fatalf("Expected findings %v, got %v", test.expected, ids)
+			}
+			for i := range ids {
+				if ids[i] != test.expected[i] {
+					t.Errorf("Expected findings %v, got %v", test.expected, ids)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package forms
+
+// Keyword sets used to recognise the purpose of a form, keyed by language code.
+// Keywords are matched case-insensitively against button labels and form text.
+var keywordSets = map[string]map[FormType][]string{
+	"en": {
+		FormTypeLogin:         {"log in", "login", "sign in", "signin", "log on", "logon"},
+		FormTypeSignup:        {"sign up", "signup", "register", "create account", "create an account", "join now"},
+		FormTypePasswordReset: {"forgot password", "forgot your password", "reset password", "reset your password", "recover password", "send reset link"},
+		FormTypeSearch:        {"search"},
+		FormTypeNewsletter:    {"subscribe", "newsletter", "mailing list", "stay updated"},
+	},
+	"de": {
+		FormTypeLogin:         {"anmelden", "einloggen"},
+		FormTypeSignup:        {"registrieren", "konto erstellen", "jetzt registrieren"},
+		FormTypePasswordReset: {"passwort vergessen", "passwort zurücksetzen"},
+		FormTypeSearch:        {"suche", "suchen"},
+		FormTypeNewsletter:    {"abonnieren", "newsletter"},
+	},
+	"fr": {
+		FormTypeLogin:         {"se connecter", "connexion", "s'identifier"},
+		FormTypeSignup:        {"s'inscrire", "inscription", "créer un compte"},
+		FormTypePasswordReset: {"mot de passe oublié", "réinitialiser le mot de passe"},
+		FormTypeSearch:        {"rechercher", "recherche"},
+		FormTypeNewsletter:    {"s'abonner", "abonnez-vous", "lettre d'information"},
+	},
+	"es": {
+		FormTypeLogin:         {"iniciar sesión", "acceder", "ingresar"},
+		FormTypeSignup:        {"registrarse", "regístrate", "crear cuenta", "crear una cuenta"},
+		FormTypePasswordReset: {"olvidaste tu contraseña", "restablecer contraseña", "recuperar contraseña"},
+		FormTypeSearch:        {"buscar", "búsqueda"},
+		FormTypeNewsletter:    {"suscribirse", "suscríbete", "boletín"},
+	},
+	"pt": {
+		FormTypeLogin:         {"entrar", "iniciar sessão", "fazer login"},
+		FormTypeSignup:        {"cadastre-se", "cadastrar", "criar conta"},
+		FormTypePasswordReset: {"esqueceu a senha", "esqueci a senha", "redefinir senha"},
+		FormTypeSearch:        {"pesquisar", "buscar"},
+		FormTypeNewsletter:    {"inscrever-se", "assinar", "boletim"},
+	},
+	"it": {
+		FormTypeLogin:         {"accedi", "accesso"},
+		FormTypeSignup:        {"registrati", "crea account", "crea un account"},
+		FormTypePasswordReset: {"password dimenticata", "reimposta password"},
+		FormTypeSearch:        {"cerca"},
+		FormTypeNewsletter:    {"iscriviti", "newsletter"},
+	},
+	"nl": {
+		FormTypeLogin:         {"inloggen", "aanmelden"},
+		FormTypeSignup:        {"registreren", "account aanmaken"},
+		FormTypePasswordReset: {"wachtwoord vergeten", "wachtwoord herstellen"},
+		FormTypeSearch:        {"zoeken"},
+		FormTypeNewsletter:    {"abonneren", "nieuwsbrief"},
+	},
+	"ru": {
+		FormTypeLogin:         {"войти", "вход"},
+		FormTypeSignup:        {"регистрация", "зарегистрироваться", "создать аккаунт"},
+		FormTypePasswordReset: {"забыли пароль", "восстановить пароль"},
+		FormTypeSearch:        {"поиск", "найти"},
+		FormTypeNewsletter:    {"подписаться", "рассылка"},
+	},
+	"ja": {
+		FormTypeLogin:         {"ログイン", "サインイン"},
+		FormTypeSignup:        {"新規登録", "会員登録", "アカウント作成"},
+		FormTypePasswordReset: {"パスワードを忘れ", "パスワードの再設定"},
+		FormTypeSearch:        {"検索"},
+		FormTypeNewsletter:    {"購読", "ニュースレター"},
+	},
+	"zh": {
+		FormTypeLogin:         {"登录", "登入"},
+		FormTypeSignup:        {"注册", "註冊"},
+		FormTypePasswordReset: {"忘记密码", "忘記密碼", "重置密码"},
+		FormTypeSearch:        {"搜索", "搜尋"},
+		FormTypeNewsletter:    {"订阅", "訂閱"},
+	},
+}
+
+// Language independent tokens matched against the id, name, class and action of a form
+var attributeKeywords = map[FormType][]string{
+	FormTypeLogin:         {"login", "log-in", "log_in", "signin", "sign-in", "sign_in", "logon", "session", "auth"},
+	FormTypeSignup:        {"signup", "sign-up", "sign_up", "register", "registration", "join", "create-account", "create_account"},
+	FormTypePasswordReset: {"forgot", "reset", "recover", "lost-password", "lost_password", "lostpassword"},
+	FormTypeSearch:        {"search", "query", "find"},
+	FormTypeNewsletter:    {"newsletter", "subscribe", "subscription", "mailing", "mailchimp"},
+}
+
+// Well known single sign-on providers and the URL fragments of their authorization endpoints
+var ssoProviders = []struct {
+	name string
+	urls []string
+}{
+	{"google", []string{"accounts.google.com/o/oauth2", "accounts.google.com/signin", "accounts.google.com/gsi"}},
+	{"microsoft", []string{"login.microsoftonline.com", "login.live.com"}},
+	{"apple", []string{"appleid.apple.com/auth"}},
+	{"github", []string{"github.com/login/oauth"}},
+	{"facebook", []string{"facebook.com/dialog/oauth", "/dialog/oauth"}},
+	{"twitter", []string{"api.twitter.com/oauth", "twitter.com/i/oauth2"}},
+	{"x", []string{"x.com/i/oauth2"}},
+	{"linkedin", []string{"linkedin.com/oauth"}},
+	{"gitlab", []string{"gitlab.com/oauth"}},
+	{"okta", []string{".okta.com/oauth2", ".okta.com/app"}},
+	{"auth0", []string{".auth0.com/authorize"}},
+}
+
+// Button label templates of SSO entry points, e.g. "Continue with Google".
+// The %s is replaced with the provider label.
+var ssoLabelTemplates = []string{
+	"sign in with %s", "log in with %s", "login with %s", "continue with %s", "sign up with %s",
+	"mit %s anmelden", "mit %s fortfahren", "se connecter avec %s", "continuer avec %s",
+	"iniciar sesión con %s", "continuar con %s", "entrar com %s", "continuar com %s",
+	"accedi con %s", "inloggen met %s", "войти через %s",
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"errors"
+	appLogger "lt-app/internal/applogger"
+	"lt-app/internal/diff"
+	"lt-app/internal/history"
+	"lt-app/internal/webfetch"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// DiffResponse compares run a with the later run b
+type DiffResponse struct {
+	A    *history.Run `json:"a"`
+	B    *history.Run `json:"b"`
+	Diff *diff.Diff   `json:"diff"`
+}
+
+// DiffRuns compares two runs of the same URL in the history, e.g. /api/diff?a=41&b=42
+func DiffRuns(c *fiber.Ctx) error {
+	if historyStore == nil {
+		return historyUnavailable(c)
+	}
+	if c.Query("a") == "" || c.Query("b") == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Both a and b are required"))
+	}
+
+	var runs [2]*history.Run
+	var snapshots [2]*diff.Snapshot
+	for i, id := range []string{c.Query("a"), c.Query("b")} {
+		run, result, err := historyStore.Get(id)
+		if errors.Is(err, history.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(webfetch.BuildErrorResponse(fiber.StatusNotFound, "Run "+id+" not found"))
+		}
+		if err == nil {
+			snapshots[i], err = diff.Parse(result)
+		}
+		if err != nil {
+			appLogger.RLoggerBuilder(c).Error("Failed to read the history", "id", id, "error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(webfetch.BuildErrorResponse(fiber.StatusInternalServerError, "Failed to read the history"))
+		}
+		runs[i] = run
+	}
+
+	if runs[0].URL != runs[1].URL {
+		message := "Runs " + runs[0].ID + " and " + runs[1].ID + " are analyses of different URLs"
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, message))
+	}
+
+	return c.JSON(DiffResponse{A: runs[0], B: runs[1], Diff: diff.Compare(snapshots[0], snapshots[1])})
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func TestDiffRuns(t *testing.T) {
+	app, store := setupHistoryApp(t)
+	store.Save("https://example.com", []byte(`{"analyzers":["title","links"],"title":"Kettles","links":["https://example.com/a"]}`))
+	store.Save("https://example.com", []byte(`{"analyzers":["title","links"],"title":"Teapots","links":["https://example.com/b"]}`))
+
+	status, body := get(t, app, "/api/diff?a=1&b=2")
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, status, body)
+	}
+
+	var response struct {
+		A    struct{ ID string } `json:"a"`
+		B    struct{ ID string } `json:"b"`
+		Diff struct {
+			Changed bool `json:"changed"`
+			Title   struct {
+				Before string `json:"before"`
+				After  string `json:"after"`
+			} `json:"title"`
+			LinksAdded  []string `json:"linksAdded"`
+			NotCompared []string `json:"notCompared"`
+		} `json:"diff"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		t.Fatalf("Expected a diff, got %s", body)
+	}
+	if response.A.ID != "1" || response.B.ID != "2" || !response.Diff.Changed || response.Diff.Title.Before != "Kettles" || response.Diff.Title.After != "Teapots" {
+		t.Errorf("Unexpected diff %s", body)
+	}
+	if len(response.Diff.LinksAdded) != 1 || len(response.Diff.NotCompared) != 4 {
+		t.Errorf("Unexpected diff %s", body)
+	}
+}
+
+func TestDiffRuns_Errors(t *testing.T) {
+	app, store := setupHistoryApp(t)
+	store.Save("https://example.com", []byte(`{"analyzers":["title"],"title":"Kettles"}`))
+	store.Save("https://example.org", []byte(`{"analyzers":["title"],"title":"Teapots"}`))
+
+	tests := []struct {
+		target   string
+		expected int
+	}{
+		{"/api/diff?a=1", http.StatusBadRequest},
+		{"/api/diff?b=1", http.StatusBadRequest},
+		{"/api/diff?a=1&b=3", http.StatusNotFound},
+		{"/api/diff?a=1&b=2", http.StatusBadRequest},
+		{"/api/diff?a=abc&b=1", http.StatusNotFound},
+	}
+
+	for _, test := range tests {
+		if status, _ := get(t, app, test.target); status != test.expected {
+			t.Errorf("%s: expected status %d, got %d", test.target, test.expected, status)
+		}
+	}
+}
+
+func TestDiffRuns_Unavailable(t *testing.T) {
+	app := setupTestApp()
+	app.Get("/api/diff", DiffRuns)
+
+	if status, _ := get(t, app, "/api/diff?a=1&b=2"); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d without a store, got %d", http.StatusServiceUnavailable, status)
+	}
+}
@@ -1,11 +1,17 @@
 package handlers
 
 import (
+	"encoding/json"
 	"log/slog"
+	"lt-app/internal/analyzer"
 	appLogger "lt-app/internal/applogger"
+	"lt-app/internal/budget"
+	"lt-app/internal/extract"
+	"lt-app/internal/pagestats"
 	"lt-app/internal/services"
 	"lt-app/internal/utils"
 	"path/filepath"
+	"slices"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,6 +19,14 @@ import (
 // RequestBody represents the expected structure of the request body
 type RequestBody struct {
 	WebPageUrl string `json:"webPageUrl"`
+	// Optional named CSS selectors and XPath expressions whose matches are returned
+	Extract []extract.Extractor `json:"extract"`
+	// Optional budget checked instead of the bundled one. It is parsed like the budget
+	// file, so unknown keys are rejected.
+	Budget json.RawMessage `json:"budget"`
+
+	// The parsed budget, nil when none was given
+	parsedBudget *budget.Budget
 }
 
 type RequestBodyValidationErr struct {
@@ -41,6 +55,20 @@ func validateRequestBody(c *fiber.Ctx, RLogger *slog.Logger) (RequestBody, *Requ
 		return body, &RequestBodyValidationErr{StatusCode: fiber.StatusBadRequest, Error: "Invalid url format"}
 	}
 
+	if err := extract.Validate(body.Extract); err != nil {
+		RLogger.Warn("Invalid extractors", slog.String("error", err.Error()))
+		return body, &RequestBodyValidationErr{StatusCode: fiber.StatusBadRequest, Error: "Invalid extract: " + err.Error()}
+	}
+
+	if len(body.Budget) > 0 && string(body.Budget) != "null" {
+		parsed, err := budget.Parse(body.Budget)
+		if err != nil {
+			RLogger.Warn("Invalid budget", slog.String("error", err.Error()))
+			return body, &RequestBodyValidationErr{StatusCode: fiber.StatusBadRequest, Error: "Invalid budget: " + err.Error()}
+		}
+		body.parsedBudget = parsed
+	}
+
 	return body, nil
 }
 
@@ -54,14 +82,47 @@ func AnalyzeWebPage(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusBadRequest).JSON(validationErr)
 	}
 
-	stats, err := services.FetchWebPageStats(reqBody.WebPageUrl, RLogger)
+	// Callers may pick analyzers, e.g. ?analyzers=title,links
+	analyzers := analyzer.ParseNames(c.Query("analyzers"))
+	if err := pagestats.DefaultRegistry.Validate(analyzers); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(err)
+	}
+
+	// Extractors are run even when only other analyzers were picked
+	if len(reqBody.Extract) > 0 && len(analyzers) > 0 && !slices.Contains(analyzers, pagestats.AnalyzerExtract) {
+		analyzers = append(analyzers, pagestats.AnalyzerExtract)
+	}
+
+	options := services.AnalyzeOptions{Analyzers: analyzers, Extractors: reqBody.Extract}
+	stats, err := services.FetchWebPageStats(c.UserContext(), reqBody.WebPageUrl, options, RLogger)
 
 	if err != nil {
 		return c.JSON(err)
 	}
 
+	// Budgets whose analyzers were not picked are skipped
+	pageBudget := reqBody.parsedBudget
+	if pageBudget == nil {
+		pageBudget = budget.Default()
+	}
+	stats.Attach("budget", pageBudget.Evaluate(stats))
+
+	// The ID is returned with the stats but is not part of the stored result
+	if runID := saveToHistory(reqBody.WebPageUrl, stats, RLogger); runID != "" {
+		stats.Attach("historyId", runID)
+	}
+
 	RLogger.Info("ExtractedInfo", "webPageUrl", reqBody.WebPageUrl, "Stats", stats)
 
+	if webhookDispatcher != nil {
+		webhookDispatcher.PublishAnalysis(reqBody.WebPageUrl, stats)
+	}
+
 	// Send JSON response
 	return c.JSON(stats)
 }
+
+// ListAnalyzers returns the analyzers that can be picked and what they depend on
+func ListAnalyzers(c *fiber.Ctx) error {
+	return c.JSON(pagestats.DefaultRegistry.Describe())
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 )
 
 func setupTestApp() *fiber.App {
@@ -54,3 +55,45 @@ func TestGetHome(t *testing.T) {
 		t.Errorf("Unexpected response body: %s", pageContent)
 	}
 }
+
+func TestAnalyzeWebPage_InvalidRequest(t *testing.T) {
+	app := setupTestApp()
+	app.Use(requestid.New())
+	app.Post("/api/analyze", AnalyzeWebPage)
+
+	tests := []struct {
+		name     string
+		target   string
+		body     string
+		expected string
+	}{
+		{"invalid body", "/api/analyze", `{`, "Invalid request body"},
+		{"invalid url", "/api/analyze", `{"webPageUrl": "not a url"}`, "Invalid url format"},
+		{"invalid extractor", "/api/analyze", `{"webPageUrl": "https://example.com", "extract": [{"name": "price", "css": ".price["}]}`, "Invalid extract: extractor price"},
+		{"extractor without selector", "/api/analyze", `{"webPageUrl": "https://example.com", "extract": [{"name": "price"}]}`, "a css selector or an xpath expression is required"},
+		{"invalid budget", "/api/analyze", `{"webPageUrl": "https://example.com", "budget": {"minScore": 120}}`, "Invalid budget: minScore must be at most 100"},
+		{"unknown budget", "/api/analyze", `{"webPageUrl": "https://example.com", "budget": {"maxBrokenLink": 0}}`, `Invalid budget: yaml: unmarshal errors:\n  line 1: field maxBrokenLink not found`},
+		{"budget of the wrong type", "/api/analyze", `{"webPageUrl": "https://example.com", "budget": {"minScore": "high"}}`, "Invalid budget"},
+		{"unknown analyzer", "/api/analyze?analyzers=title,unknown", `{"webPageUrl": "https://example.com"}`, `Unknown analyzer \"unknown\"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", test.target, strings.NewReader(test.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp, err := app.Test(req)
+			if err != nil {
+				t.Fatalf("Error testing the analyze route: %v", err)
+			}
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Errorf("Expected status code %d, got %d", http.StatusBadRequest, resp.StatusCode)
+			}
+
+			body, _ := io.ReadAll(resp.Body)
+			if !strings.Contains(string(body), test.expected) {
+				t.Errorf("Expected %s in %s", test.expected, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	appLogger "lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/history"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/webfetch"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// historyStore keeps every analysis. It is opened by main; results are not kept
+// and the history endpoints are unavailable without it.
+var historyStore *history.Store
+
+func SetHistoryStore(store *history.Store) {
+	historyStore = store
+}
+
+// HistoryRun is a stored analysis with its result
+type HistoryRun struct {
+	Run    *history.Run    `json:"run"`
+	Result json.RawMessage `json:"result"`
+}
+
+// saveToHistory stores the stats and returns the ID of the run, or an empty ID
+// when they could not be stored. A failure does not fail the analysis.
+func saveToHistory(webPageUrl string, stats *pagestats.WebPageStats, RLogger *slog.Logger) string {
+	if historyStore == nil {
+		return ""
+	}
+
+	result, err := json.Marshal(stats)
+	if err == nil {
+		var run *history.Run
+		if run, err = historyStore.Save(webPageUrl, result); err == nil {
+			return run.ID
+		}
+	}
+
+	RLogger.Error("Failed to save the analysis to the history", "url", webPageUrl, "error", err)
+	return ""
+}
+
+func historyUnavailable(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(webfetch.BuildErrorResponse(fiber.StatusServiceUnavailable, "History is not available"))
+}
+
+// ListHistory lists past runs, newest first, e.g. /api/history?url=https://example.com&limit=10.
+// Without url the runs of every URL are listed.
+func ListHistory(c *fiber.Ctx) error {
+	if historyStore == nil {
+		return historyUnavailable(c)
+	}
+
+	limit := c.QueryInt("limit", constants.HISTORY_LIST_DEFAULT_LIMIT)
+	if limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid limit"))
+	}
+	limit = min(limit, constants.HISTORY_LIST_MAX_LIMIT)
+
+	runs, err := historyStore.List(c.Query("url"), limit)
+	if err != nil {
+		appLogger.RLoggerBuilder(c).Error("Failed to list the history", "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(webfetch.BuildErrorResponse(fiber.StatusInternalServerError, "Failed to list the history"))
+	}
+	return c.JSON(runs)
+}
+
+// GetHistoryRun returns a past run with its full result
+func GetHistoryRun(c *fiber.Ctx) error {
+	if historyStore == nil {
+		return historyUnavailable(c)
+	}
+
+	run, result, err := historyStore.Get(c.Params("id"))
+	if errors.Is(err, history.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(webfetch.BuildErrorResponse(fiber.StatusNotFound, "Run not found"))
+	}
+	if err != nil {
+		appLogger.RLoggerBuilder(c).Error("Failed to read the history", "id", c.Params("id"), "error", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(webfetch.BuildErrorResponse(fiber.StatusInternalServerError, "Failed to read the history"))
+	}
+	return c.JSON(HistoryRun{Run: run, Result: result})
+}
@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"lt-app/internal/history"
+	"lt-app/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+func setupHistoryApp(t *testing.T) (*fiber.App, *history.Store) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	store, err := history.New(db, history.Retention{})
+	if err != nil {
+		t.Fatalf("Failed to create the history: %v", err)
+	}
+
+	SetHistoryStore(store)
+	t.Cleanup(func() {
+		SetHistoryStore(nil)
+		db.Close()
+	})
+
+	app := setupTestApp()
+	app.Use(requestid.New())
+	app.Get("/api/history", ListHistory)
+	app.Get("/api/history/:id", GetHistoryRun)
+	app.Get("/api/diff", DiffRuns)
+	return app, store
+}
+
+func get(t *testing.T, app *fiber.App, target string) (int, []byte) {
+	resp, err := app.Test(httptest.NewRequest("GET", target, nil))
+	if err != nil {
+		t.Fatalf("Error testing %s: %v", target, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, body
+}
+
+func TestListHistory(t *testing.T) {
+	app, store := setupHistoryApp(t)
+	store.Save("https://example.com", []byte(`{"analyzers":["title"],"title":"First"}`))
+	store.Save("https://example.org", []byte(`{"analyzers":["title"],"title":"Other"}`))
+	store.Save("https://example.com", []byte(`{"analyzers":["title"],"title":"Second"}`))
+
+	tests := []struct {
+		target   string
+		expected []string
+	}{
+		{"/api/history?url=https://example.com", []string{"3", "1"}},
+		{"/api/history?url=https://example.com&limit=1", []string{"3"}},
+		{"/api/history", []string{"3", "2", "1"}},
+		{"/api/history?url=https://example.net", []string{}},
+	}
+
+	for _, test := range tests {
+		status, body := get(t, app, test.target)
+		if status != http.StatusOK {
+			t.Errorf("%s: expected status %d, got %d", test.target, http.StatusOK, status)
+		}
+
+		var runs []history.Run
+		json.Unmarshal(body, &runs)
+		ids := []string{}
+		for _, run := range runs {
+			ids = append(ids, run.ID)
+		}
+		if !reflect.DeepEqual(ids, test.expected) {
+			t.Errorf("%s: expected runs %v, got %s", test.target, test.expected, body)
+		}
+	}
+
+	if status, _ := get(t, app, "/api/history?limit=0"); status != http.StatusBadRequest {
+		t.Errorf("Expected an invalid limit to be rejected, got %d", status)
+	}
+}
+
+func TestGetHistoryRun(t *testing.T) {
+	app, store := setupHistoryApp(t)
+	store.Save("https://example.com", []byte(`{"analyzers":["title"],"title":"Kettles"}`))
+
+	status, body := get(t, app, "/api/history/1")
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d", http.StatusOK, status)
+	}
+
+	var run struct {
+		Run    history.Run `json:"run"`
+		Result struct {
+			Title string `json:"title"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(body, &run); err != nil || run.Run.URL != "https://example.com" || run.Result.Title != "Kettles" {
+		t.Errorf("Expected the run and its result, got %s", body)
+	}
+
+	for _, target := range []string{"/api/history/2", "/api/history/abc"} {
+		if status, _ := get(t, app, target); status != http.StatusNotFound {
+			t.Errorf("%s: expected status %d, got %d", target, http.StatusNotFound, status)
+		}
+	}
+}
+
+func TestHistory_Unavailable(t *testing.T) {
+	app := setupTestApp()
+	app.Get("/api/history", ListHistory)
+	app.Get("/api/history/:id", GetHistoryRun)
+
+	for _, target := range []string{"/api/history", "/api/history/1"} {
+		if status, _ := get(t, app, target); status != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status %d without a store, got %d", target, http.StatusServiceUnavailable, status)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"errors"
+	appLogger "lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/monitor"
+	"lt-app/internal/webfetch"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// monitorScheduler runs the monitors. It is started by main along with the
+// history; the monitor endpoints are unavailable without it.
+var monitorScheduler *monitor.Scheduler
+
+func SetMonitorScheduler(scheduler *monitor.Scheduler) {
+	monitorScheduler = scheduler
+}
+
+// MonitorRequest holds the settings of a monitor
+type MonitorRequest struct {
+	URL string `json:"url"`
+	// Cron expression or descriptor, e.g. "0 6 * * *" or "@every 6h"
+	Schedule string `json:"schedule"`
+	// Optional analyzers to run, every analyzer by default
+	Analyzers []string `json:"analyzers"`
+	// Optional days before the certificate expires from which an alert is raised
+	TLSExpiryDays *int `json:"tlsExpiryDays"`
+	Paused        bool `json:"paused"`
+}
+
+// MonitorRunResponse is the outcome of a run started through the API
+type MonitorRunResponse struct {
+	Run    *monitor.RunStatus `json:"run"`
+	Alerts []monitor.Alert    `json:"alerts"`
+}
+
+func monitorsUnavailable(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(webfetch.BuildErrorResponse(fiber.StatusServiceUnavailable, "Monitoring is not available"))
+}
+
+// monitorError responds to an error of the scheduler
+func monitorError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, monitor.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(webfetch.BuildErrorResponse(fiber.StatusNotFound, "Monitor not found"))
+	case errors.Is(err, monitor.ErrLimitReached):
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid monitor: "+err.Error()))
+	}
+	appLogger.RLoggerBuilder(c).Error(message, "id", c.Params("id"), "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(webfetch.BuildErrorResponse(fiber.StatusInternalServerError, message))
+}
+
+// parseMonitor reads and validates the settings of a monitor from the request body
+func parseMonitor(c *fiber.Ctx) (*monitor.Monitor, *webfetch.ErrorResponse) {
+	var body MonitorRequest
+	if err := c.BodyParser(&body); err != nil {
+		return nil, webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	settings := &monitor.Monitor{
+		URL:           body.URL,
+		Schedule:      body.Schedule,
+		Analyzers:     body.Analyzers,
+		TLSExpiryDays: constants.MONITOR_TLS_EXPIRY_DAYS,
+		Paused:        body.Paused,
+	}
+	if settings.Analyzers == nil {
+		settings.Analyzers = []string{}
+	}
+	if body.TLSExpiryDays != nil {
+		settings.TLSExpiryDays = *body.TLSExpiryDays
+	}
+
+	if err := settings.Validate(); err != nil {
+		return nil, webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid monitor: "+err.Error())
+	}
+	return settings, nil
+}
+
+// ListMonitors lists every monitor with its last and next run
+func ListMonitors(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	monitors, err := monitorScheduler.List()
+	if err != nil {
+		return monitorError(c, err, "Failed to list the monitors")
+	}
+	return c.JSON(monitors)
+}
+
+// CreateMonitor stores a monitor and schedules its runs
+func CreateMonitor(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	settings, validationErr := parseMonitor(c)
+	if validationErr != nil {
+		return c.Status(validationErr.StatusCode).JSON(validationErr)
+	}
+	if err := monitorScheduler.Create(settings); err != nil {
+		return monitorError(c, err, "Failed to create the monitor")
+	}
+	return c.Status(fiber.StatusCreated).JSON(settings)
+}
+
+func GetMonitor(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	found, err := monitorScheduler.Get(c.Params("id"))
+	if err != nil {
+		return monitorError(c, err, "Failed to read the monitor")
+	}
+	return c.JSON(found)
+}
+
+// UpdateMonitor replaces the settings of a monitor and reschedules it
+func UpdateMonitor(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	settings, validationErr := parseMonitor(c)
+	if validationErr != nil {
+		return c.Status(validationErr.StatusCode).JSON(validationErr)
+	}
+	settings.ID = c.Params("id")
+	if err := monitorScheduler.Update(settings); err != nil {
+		return monitorError(c, err, "Failed to update the monitor")
+	}
+	return c.JSON(settings)
+}
+
+// DeleteMonitor removes a monitor with its alerts. Its results stay in the history.
+func DeleteMonitor(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	if err := monitorScheduler.Delete(c.Params("id")); err != nil {
+		return monitorError(c, err, "Failed to delete the monitor")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// RunMonitor runs a monitor now, also when it is paused, and returns the outcome
+func RunMonitor(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	status, alerts, err := monitorScheduler.Run(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return monitorError(c, err, "Failed to run the monitor")
+	}
+	return c.JSON(MonitorRunResponse{Run: status, Alerts: alerts})
+}
+
+// ListMonitorAlerts lists the alerts of a monitor, newest first, e.g. /api/monitors/1/alerts?limit=10
+func ListMonitorAlerts(c *fiber.Ctx) error {
+	if monitorScheduler == nil {
+		return monitorsUnavailable(c)
+	}
+
+	limit := c.QueryInt("limit", constants.MONITOR_ALERTS_LIST_DEFAULT_LIMIT)
+	if limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid limit"))
+	}
+
+	alerts, err := monitorScheduler.Alerts(c.Params("id"), min(limit, constants.MONITOR_ALERTS_MAX_PER_MONITOR))
+	if err != nil {
+		return monitorError(c, err, "Failed to list the alerts")
+	}
+	return c.JSON(alerts)
+}
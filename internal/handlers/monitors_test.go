@@ -0,0 +1,160 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/history"
+	"lt-app/internal/monitor"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/services"
+	"lt-app/internal/storage"
+	"lt-app/internal/webfetch"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupMonitorApp(t *testing.T) *fiber.App {
+	app := setupTestApp()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	store, _ := monitor.NewStore(db, 0)
+	historyStore, _ := history.New(db, history.Retention{})
+
+	analyze := func(ctx context.Context, _ string, _ services.AnalyzeOptions, _ *slog.Logger) (*pagestats.WebPageStats, *webfetch.ErrorResponse) {
+		return analyzer.NewRegistry().Register(
+			analyzer.NewInline(pagestats.AnalyzerTitle, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.TitleSection, *webfetch.ErrorResponse) {
+				return pagestats.TitleSection{Title: "Kettles"}, nil
+			}),
+		).Run(ctx, nil, &analyzer.Input{})
+	}
+	scheduler := monitor.NewScheduler(store, historyStore, analyze, slog.Default())
+
+	SetMonitorScheduler(scheduler)
+	t.Cleanup(func() {
+		SetMonitorScheduler(nil)
+		scheduler.Stop()
+		db.Close()
+	})
+
+	app.Get("/api/monitors", ListMonitors)
+	app.Post("/api/monitors", CreateMonitor)
+	app.Get("/api/monitors/:id", GetMonitor)
+	app.Put("/api/monitors/:id", UpdateMonitor)
+	app.Delete("/api/monitors/:id", DeleteMonitor)
+	app.Post("/api/monitors/:id/run", RunMonitor)
+	app.Get("/api/monitors/:id/alerts", ListMonitorAlerts)
+	return app
+}
+
+func send(t *testing.T, app *fiber.App, method, target, body string) (int, []byte) {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("Error testing %s %s: %v", method, target, err)
+	}
+	respBody, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, respBody
+}
+
+func TestMonitors_CRUD(t *testing.T) {
+	app := setupMonitorApp(t)
+
+	status, body := send(t, app, "POST", "/api/monitors", `{"url": "https://example.com", "schedule": "@hourly", "analyzers": ["title"]}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, status, body)
+	}
+	var created monitor.Monitor
+	json.Unmarshal(body, &created)
+	if created.ID != "1" || created.TLSExpiryDays != 14 || created.NextRun == nil {
+		t.Errorf("Expected the monitor with the default TLS expiry days and its next run, got %s", body)
+	}
+
+	status, body = send(t, app, "PUT", "/api/monitors/1", `{"url": "https://example.com", "schedule": "0 6 * * *", "tlsExpiryDays": 30, "paused": true}`)
+	var updated monitor.Monitor
+	json.Unmarshal(body, &updated)
+	if status != http.StatusOK || updated.Schedule != "0 6 * * *" || updated.TLSExpiryDays != 30 || !updated.Paused || updated.NextRun != nil {
+		t.Errorf("Expected the monitor to be updated and paused, got %d %s", status, body)
+	}
+
+	status, body = send(t, app, "GET", "/api/monitors", "")
+	var monitors []monitor.Monitor
+	json.Unmarshal(body, &monitors)
+	if status != http.StatusOK || len(monitors) != 1 || monitors[0].Schedule != "0 6 * * *" {
+		t.Errorf("Expected the updated monitor to be listed, got %d %s", status, body)
+	}
+
+	if status, _ := send(t, app, "DELETE", "/api/monitors/1", ""); status != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, status)
+	}
+	if status, _ := send(t, app, "GET", "/api/monitors/1", ""); status != http.StatusNotFound {
+		t.Errorf("Expected the deleted monitor to be gone, got %d", status)
+	}
+}
+
+func TestMonitors_Run(t *testing.T) {
+	app := setupMonitorApp(t)
+	send(t, app, "POST", "/api/monitors", `{"url": "https://example.com", "schedule": "@daily", "paused": true}`)
+
+	status, body := send(t, app, "POST", "/api/monitors/1/run", "")
+	var response MonitorRunResponse
+	json.Unmarshal(body, &response)
+	if status != http.StatusOK || response.Run == nil || response.Run.HistoryID != "1" || len(response.Alerts) != 0 {
+		t.Errorf("Expected the run to be stored in the history, got %d %s", status, body)
+	}
+
+	status, body = send(t, app, "GET", "/api/monitors/1/alerts", "")
+	if status != http.StatusOK || string(body) != "[]" {
+		t.Errorf("Expected no alerts, got %d %s", status, body)
+	}
+}
+
+func TestMonitors_Errors(t *testing.T) {
+	app := setupMonitorApp(t)
+
+	tests := []struct {
+		method   string
+		target   string
+		body     string
+		status   int
+		expected string
+	}{
+		{"POST", "/api/monitors", `{`, http.StatusBadRequest, "Invalid request body"},
+		{"POST", "/api/monitors", `{"url": "example", "schedule": "@hourly"}`, http.StatusBadRequest, "Invalid monitor: invalid url format"},
+		{"POST", "/api/monitors", `{"url": "https://example.com", "schedule": "@every 10s"}`, http.StatusBadRequest, "Invalid monitor: invalid schedule"},
+		{"PUT", "/api/monitors/7", `{"url": "https://example.com", "schedule": "@hourly"}`, http.StatusNotFound, "Monitor not found"},
+		{"DELETE", "/api/monitors/7", ``, http.StatusNotFound, "Monitor not found"},
+		{"POST", "/api/monitors/7/run", ``, http.StatusNotFound, "Monitor not found"},
+		{"GET", "/api/monitors/7/alerts", ``, http.StatusNotFound, "Monitor not found"},
+		{"GET", "/api/monitors/1/alerts?limit=0", ``, http.StatusBadRequest, "Invalid limit"},
+	}
+
+	for _, test := range tests {
+		status, body := send(t, app, test.method, test.target, test.body)
+		if status != test.status || !strings.Contains(string(body), test.expected) {
+			t.Errorf("%s %s: expected %d %q, got %d %s", test.method, test.target, test.status, test.expected, status, body)
+		}
+	}
+}
+
+func TestMonitors_Unavailable(t *testing.T) {
+	app := setupTestApp()
+	app.Get("/api/monitors", ListMonitors)
+	app.Post("/api/monitors", CreateMonitor)
+
+	for _, method := range []string{"GET", "POST"} {
+		if status, _ := send(t, app, method, "/api/monitors", `{}`); status != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status %d without a scheduler, got %d", method, http.StatusServiceUnavailable, status)
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"errors"
+	appLogger "lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/webfetch"
+	"lt-app/internal/webhook"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// webhookStore keeps the webhook subscriptions. It is opened by main along with
+// the history; the webhook endpoints are unavailable without it.
+var webhookStore *webhook.Store
+
+func SetWebhookStore(store *webhook.Store) {
+	webhookStore = store
+}
+
+// webhookDispatcher publishes the analyses made through the API, none without it
+var webhookDispatcher *webhook.Dispatcher
+
+func SetWebhookDispatcher(dispatcher *webhook.Dispatcher) {
+	webhookDispatcher = dispatcher
+}
+
+// WebhookRequest holds the settings of a webhook
+type WebhookRequest struct {
+	URL string `json:"url"`
+	// Optional events to deliver, every event by default
+	Events []string `json:"events"`
+	// Optional key of the signature, generated on creation and kept on updates when empty
+	Secret string `json:"secret"`
+	Paused bool   `json:"paused"`
+}
+
+func webhooksUnavailable(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(webfetch.BuildErrorResponse(fiber.StatusServiceUnavailable, "Webhooks are not available"))
+}
+
+// webhookError responds to an error of the store
+func webhookError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, webhook.ErrNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(webfetch.BuildErrorResponse(fiber.StatusNotFound, "Webhook not found"))
+	case errors.Is(err, webhook.ErrLimitReached):
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid webhook: "+err.Error()))
+	}
+	appLogger.RLoggerBuilder(c).Error(message, "id", c.Params("id"), "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(webfetch.BuildErrorResponse(fiber.StatusInternalServerError, message))
+}
+
+// parseWebhook reads and validates the settings of a webhook from the request body
+func parseWebhook(c *fiber.Ctx) (*webhook.Subscription, *webfetch.ErrorResponse) {
+	var body WebhookRequest
+	if err := c.BodyParser(&body); err != nil {
+		return nil, webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid request body")
+	}
+
+	subscription := &webhook.Subscription{
+		URL:       body.URL,
+		Events:    body.Events,
+		Secret:    body.Secret,
+		Paused:    body.Paused,
+		UpdatedAt: time.Now().UTC(),
+	}
+	if subscription.Events == nil {
+		subscription.Events = []string{}
+	}
+	if err := subscription.Validate(); err != nil {
+		return nil, webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid webhook: "+err.Error())
+	}
+	return subscription, nil
+}
+
+// ListWebhooks lists every webhook, without their secrets
+func ListWebhooks(c *fiber.Ctx) error {
+	if webhookStore == nil {
+		return webhooksUnavailable(c)
+	}
+
+	subscriptions, err := webhookStore.List()
+	if err != nil {
+		return webhookError(c, err, "Failed to list the webhooks")
+	}
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+	return c.JSON(subscriptions)
+}
+
+// CreateWebhook stores a webhook and returns it with its secret, which is not returned again
+func CreateWebhook(c *fiber.Ctx) error {
+	if webhookStore == nil {
+		return webhooksUnavailable(c)
+	}
+
+	subscription, validationErr := parseWebhook(c)
+	if validationErr != nil {
+		return c.Status(validationErr.StatusCode).JSON(validationErr)
+	}
+	if subscription.Secret == "" {
+		secret, err := webhook.NewSecret()
+		if err != nil {
+			return webhookError(c, err, "Failed to create the webhook")
+		}
+		subscription.Secret = secret
+	}
+	subscription.CreatedAt = subscription.UpdatedAt
+	if err := webhookStore.Create(subscription); err != nil {
+		return webhookError(c, err, "Failed to create the webhook")
+	}
+	return c.Status(fiber.StatusCreated).JSON(subscription)
+}
+
+func GetWebhook(c *fiber.Ctx) error {
+	if webhookStore == nil {
+		return webhooksUnavailable(c)
+	}
+
+	subscription, err := webhookStore.Get(c.Params("id"))
+	if err != nil {
+		return webhookError(c, err, "Failed to read the webhook")
+	}
+	subscription.Secret = ""
+	return c.JSON(subscription)
+}
+
+// UpdateWebhook replaces the settings of a webhook
+func UpdateWebhook(c *fiber.Ctx) error {
+	if webhookStore == nil {
+		return webhooksUnavailable(c)
+	}
+
+	subscription, validationErr := parseWebhook(c)
+	if validationErr != nil {
+		return c.Status(validationErr.StatusCode).JSON(validationErr)
+	}
+	subscription.ID = c.Params("id")
+	if err := webhookStore.Update(subscription); err != nil {
+		return webhookError(c, err, "Failed to update the webhook")
+	}
+	subscription.Secret = ""
+	return c.JSON(subscription)
+}
+
+// DeleteWebhook removes a webhook with its deliveries
+func DeleteWebhook(c *fiber.Ctx) error {
+	if webhookStore == nil {
+		return webhooksUnavailable(c)
+	}
+
+	if err := webhookStore.Delete(c.Params("id")); err != nil {
+		return webhookError(c, err, "Failed to delete the webhook")
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// ListWebhookDeliveries lists the deliveries of a webhook with their attempts, newest
+// first, e.g. /api/webhooks/1/deliveries?limit=10
+func ListWebhookDeliveries(c *fiber.Ctx) error {
+	if webhookStore == nil {
+		return webhooksUnavailable(c)
+	}
+
+	limit := c.QueryInt("limit", constants.WEBHOOK_DELIVERIES_LIST_DEFAULT_LIMIT)
+	if limit < 1 {
+		return c.Status(fiber.StatusBadRequest).JSON(webfetch.BuildErrorResponse(fiber.StatusBadRequest, "Invalid limit"))
+	}
+
+	deliveries, err := webhookStore.Deliveries(c.Params("id"), min(limit, constants.WEBHOOK_DELIVERIES_MAX_PER_SUBSCRIPTION))
+	if err != nil {
+		return webhookError(c, err, "Failed to list the deliveries")
+	}
+	return c.JSON(deliveries)
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"lt-app/internal/storage"
+	"lt-app/internal/webhook"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func setupWebhookApp(t *testing.T) (*fiber.App, *webhook.Store) {
+	app := setupTestApp()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	store, _ := webhook.NewStore(db, 0)
+
+	SetWebhookStore(store)
+	t.Cleanup(func() {
+		SetWebhookStore(nil)
+		db.Close()
+	})
+
+	app.Get("/api/webhooks", ListWebhooks)
+	app.Post("/api/webhooks", CreateWebhook)
+	app.Get("/api/webhooks/:id", GetWebhook)
+	app.Put("/api/webhooks/:id", UpdateWebhook)
+	app.Delete("/api/webhooks/:id", DeleteWebhook)
+	app.Get("/api/webhooks/:id/deliveries", ListWebhookDeliveries)
+	return app, store
+}
+
+func TestWebhooks_CRUD(t *testing.T) {
+	app, store := setupWebhookApp(t)
+
+	status, body := send(t, app, "POST", "/api/webhooks", `{"url": "https://example.com/hooks", "events": ["monitor.regressed"]}`)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusCreated, status, body)
+	}
+	var created webhook.Subscription
+	json.Unmarshal(body, &created)
+	if created.ID != "1" || len(created.Secret) != 64 || created.CreatedAt.IsZero() {
+		t.Errorf("Expected the webhook with a generated secret, got %s", body)
+	}
+
+	status, body = send(t, app, "POST", "/api/webhooks", `{"url": "https://example.org/hooks", "secret": "0123456789abcdef"}`)
+	if status != http.StatusCreated || !strings.Contains(string(body), `"secret":"0123456789abcdef"`) || !strings.Contains(string(body), `"events":[]`) {
+		t.Errorf("Expected the webhook with its secret, got %d: %s", status, body)
+	}
+
+	// Secrets are only returned on creation
+	for _, target := range []string{"/api/webhooks", "/api/webhooks/1"} {
+		status, body = send(t, app, "GET", target, "")
+		if status != http.StatusOK || strings.Contains(string(body), "secret") {
+			t.Errorf("GET %s: expected no secrets, got %d: %s", target, status, body)
+		}
+	}
+
+	status, body = send(t, app, "PUT", "/api/webhooks/1", `{"url": "https://example.com/other", "paused": true}`)
+	if status != http.StatusOK || strings.Contains(string(body), "secret") || !strings.Contains(string(body), `"paused":true`) {
+		t.Errorf("Expected the updated webhook, got %d: %s", status, body)
+	}
+	if stored, _ := store.Get("1"); stored.Secret != created.Secret || !stored.CreatedAt.Equal(created.CreatedAt) {
+		t.Errorf("Expected the secret and creation time to be kept, got %+v", stored)
+	}
+
+	status, _ = send(t, app, "DELETE", "/api/webhooks/2", "")
+	if status != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, status)
+	}
+	status, body = send(t, app, "GET", "/api/webhooks", "")
+	var listed []webhook.Subscription
+	json.Unmarshal(body, &listed)
+	if status != http.StatusOK || len(listed) != 1 || listed[0].URL != "https://example.com/other" {
+		t.Errorf("Expected webhook 1 only, got %s", body)
+	}
+}
+
+func TestWebhooks_Errors(t *testing.T) {
+	app, _ := setupWebhookApp(t)
+	send(t, app, "POST", "/api/webhooks", `{"url": "https://example.com"}`)
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		status  int
+		message string
+	}{
+		{"invalid body", "POST", "/api/webhooks", `{`, http.StatusBadRequest, "Invalid request body"},
+		{"invalid url", "POST", "/api/webhooks", `{"url": "example.com"}`, http.StatusBadRequest, "Invalid webhook: invalid url format"},
+		{"unknown event", "POST", "/api/webhooks", `{"url": "https://example.com", "events": ["page.analyzed"]}`, http.StatusBadRequest, `Invalid webhook: unknown event`},
+		{"short secret", "PUT", "/api/webhooks/1", `{"url": "https://example.com", "secret": "secret"}`, http.StatusBadRequest, "at least 16 characters"},
+		{"get unknown", "GET", "/api/webhooks/9", "", http.StatusNotFound, "Webhook not found"},
+		{"update unknown", "PUT", "/api/webhooks/9", `{"url": "https://example.com"}`, http.StatusNotFound, "Webhook not found"},
+		{"delete unknown", "DELETE", "/api/webhooks/9", "", http.StatusNotFound, "Webhook not found"},
+		{"deliveries of unknown", "GET", "/api/webhooks/9/deliveries", "", http.StatusNotFound, "Webhook not found"},
+		{"invalid limit", "GET", "/api/webhooks/1/deliveries?limit=0", "", http.StatusBadRequest, "Invalid limit"},
+	}
+
+	for _, test := range tests {
+		status, body := send(t, app, test.method, test.target, test.body)
+		if status != test.status || !strings.Contains(string(body), test.message) {
+			t.Errorf("%s: expected %d %q, got %d: %s", test.name, test.status, test.message, status, body)
+		}
+	}
+}
+
+func TestWebhooks_Deliveries(t *testing.T) {
+	app, store := setupWebhookApp(t)
+	send(t, app, "POST", "/api/webhooks", `{"url": "https://example.com"}`)
+	for _, event := range []string{webhook.EventMonitorRunCompleted, webhook.EventMonitorRegressed} {
+		store.CreateDelivery(&webhook.Delivery{SubscriptionID: "1", Event: event, Status: webhook.StatusDelivered, Payload: json.RawMessage(`{}`)})
+	}
+
+	status, body := send(t, app, "GET", "/api/webhooks/1/deliveries?limit=1", "")
+	var deliveries []webhook.Delivery
+	json.Unmarshal(body, &deliveries)
+	if status != http.StatusOK || len(deliveries) != 1 || deliveries[0].Event != webhook.EventMonitorRegressed {
+		t.Errorf("Expected the newest delivery, got %d: %s", status, body)
+	}
+}
+
+func TestWebhooks_Unavailable(t *testing.T) {
+	app := setupTestApp()
+	app.Get("/api/webhooks", ListWebhooks)
+
+	status, body := send(t, app, "GET", "/api/webhooks", "")
+	if status != http.StatusServiceUnavailable || !strings.Contains(string(body), "Webhooks are not available") {
+		t.Errorf("Expected status %d, got %d: %s", http.StatusServiceUnavailable, status, body)
+	}
+}
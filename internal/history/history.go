@@ -0,0 +1,270 @@
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lt-app/internal/storage"
+	"strconv"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// Summary of every run by ID
+	runsBucket = []byte("history-runs")
+	// Stored result of every run by ID
+	resultsBucket = []byte("history-results")
+	// A bucket per URL with a key per run, ordered by the time of the run
+	urlsBucket = []byte("history-urls")
+)
+
+// ErrNotFound is returned for runs that do not exist or were removed
+var ErrNotFound = errors.New("run not found")
+
+// Retention limits how long and how many runs are kept. Zero values keep runs
+// of any age or number.
+type Retention struct {
+	MaxAge        time.Duration
+	MaxRunsPerURL int
+}
+
+// Run summarizes a stored analysis so runs can be listed without reading results
+type Run struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	AnalyzedAt time.Time `json:"analyzedAt"`
+	Analyzers  []string  `json:"analyzers"`
+	// Only set when the score analyzer ran
+	Score *int   `json:"score,omitempty"`
+	Grade string `json:"grade,omitempty"`
+	// Only set when a budget was checked
+	BudgetPassed *bool `json:"budgetPassed,omitempty"`
+	// Size of the stored result in bytes
+	Size int `json:"size"`
+}
+
+// Store keeps analysis results in the embedded database, keyed by URL and time
+type Store struct {
+	db        *bolt.DB
+	retention Retention
+	now       func() time.Time
+}
+
+func New(db *bolt.DB, retention Retention) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{runsBucket, resultsBucket, urlsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, retention: retention, now: time.Now}, nil
+}
+
+// Save stores the JSON result of an analysis of the URL and applies the retention
+// to the runs of the URL
+func (s *Store) Save(url string, result []byte) (*Run, error) {
+	var summary struct {
+		Analyzers []string `json:"analyzers"`
+		Score     *struct {
+			Score int    `json:"score"`
+			Grade string `json:"grade"`
+		} `json:"score"`
+		Budget *struct {
+			Passed bool `json:"passed"`
+		} `json:"budget"`
+	}
+	if err := json.Unmarshal(result, &summary); err != nil {
+		return nil, fmt.Errorf("invalid result: %w", err)
+	}
+
+	run := &Run{URL: url, AnalyzedAt: s.now().UTC(), Analyzers: summary.Analyzers, Size: len(result)}
+	if run.Analyzers == nil {
+		run.Analyzers = []string{}
+	}
+	if summary.Score != nil {
+		run.Score, run.Grade = &summary.Score.Score, summary.Score.Grade
+	}
+	if summary.Budget != nil {
+		run.BudgetPassed = &summary.Budget.Passed
+	}
+
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		seq, err := runs.NextSequence()
+		if err != nil {
+			return err
+		}
+		run.ID = strconv.FormatUint(seq, 10)
+
+		encoded, err := json.Marshal(run)
+		if err != nil {
+			return err
+		}
+		if err := runs.Put(storage.SeqKey(seq), encoded); err != nil {
+			return err
+		}
+		if err := tx.Bucket(resultsBucket).Put(storage.SeqKey(seq), result); err != nil {
+			return err
+		}
+
+		urlRuns, err := tx.Bucket(urlsBucket).CreateBucketIfNotExists([]byte(url))
+		if err != nil {
+			return err
+		}
+		if err := urlRuns.Put(runKey(run.AnalyzedAt, seq), nil); err != nil {
+			return err
+		}
+
+		_, err = s.prune(tx, []byte(url))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// List returns the runs of the URL, or of every URL when it is empty, newest first
+func (s *Store) List(url string, limit int) ([]Run, error) {
+	result := []Run{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		runs := tx.Bucket(runsBucket)
+		add := func(encoded []byte) error {
+			var run Run
+			if err := json.Unmarshal(encoded, &run); err != nil {
+				return err
+			}
+			result = append(result, run)
+			return nil
+		}
+
+		if url == "" {
+			cursor := runs.Cursor()
+			for key, value := cursor.Last(); key != nil && len(result) < limit; key, value = cursor.Prev() {
+				if err := add(value); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		urlRuns := tx.Bucket(urlsBucket).Bucket([]byte(url))
+		if urlRuns == nil {
+			return nil
+		}
+		cursor := urlRuns.Cursor()
+		for key, _ := cursor.Last(); key != nil && len(result) < limit; key, _ = cursor.Prev() {
+			if err := add(runs.Get(key[8:])); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return result, err
+}
+
+// Get returns a run and its stored result
+func (s *Store) Get(id string) (*Run, json.RawMessage, error) {
+	key := storage.Key(id)
+	var run Run
+	var result json.RawMessage
+	err := s.db.View(func(tx *bolt.Tx) error {
+		encoded := tx.Bucket(runsBucket).Get(key)
+		if encoded == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(encoded, &run); err != nil {
+			return err
+		}
+		// Values are only valid during the transaction
+		result = append(json.RawMessage{}, tx.Bucket(resultsBucket).Get(key)...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &run, result, nil
+}
+
+// Prune applies the retention to every URL and returns the number of runs removed.
+// Save only prunes the URL it stores, so URLs that are no longer analyzed are
+// pruned here.
+func (s *Store) Prune() (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var urls [][]byte
+		err := tx.Bucket(urlsBucket).ForEachBucket(func(url []byte) error {
+			urls = append(urls, append([]byte{}, url...))
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, url := range urls {
+			count, err := s.prune(tx, url)
+			if err != nil {
+				return err
+			}
+			removed += count
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// prune removes the runs of the URL that are too old or beyond the count, oldest first
+func (s *Store) prune(tx *bolt.Tx, url []byte) (int, error) {
+	urls := tx.Bucket(urlsBucket)
+	urlRuns := urls.Bucket(url)
+	if urlRuns == nil {
+		return 0, nil
+	}
+
+	var cutoff time.Time
+	if s.retention.MaxAge > 0 {
+		cutoff = s.now().Add(-s.retention.MaxAge)
+	}
+	expired, err := storage.Prune(urlRuns, func(key []byte, remaining int) bool {
+		tooMany := s.retention.MaxRunsPerURL > 0 && remaining > s.retention.MaxRunsPerURL
+		tooOld := !cutoff.IsZero() && keyTime(key).Before(cutoff)
+		return tooMany || tooOld
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	for _, key := range expired {
+		if err := tx.Bucket(runsBucket).Delete(key[8:]); err != nil {
+			return 0, err
+		}
+		if err := tx.Bucket(resultsBucket).Delete(key[8:]); err != nil {
+			return 0, err
+		}
+	}
+
+	if key, _ := urlRuns.Cursor().First(); key == nil {
+		if err := urls.DeleteBucket(url); err != nil {
+			return 0, err
+		}
+	}
+	return len(expired), nil
+}
+
+// runKey orders the runs of a URL by time. The ID follows so runs made at the
+// same time do not overwrite each other.
+func runKey(analyzedAt time.Time, seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(binary.BigEndian.AppendUint64(nil, uint64(analyzedAt.UnixNano())), seq)
+}
+
+func keyTime(key []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(key[:8])))
+}
@@ -0,0 +1,198 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lt-app/internal/storage"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// testStore opens a store in a temporary file with a clock that advances a minute per call
+func testStore(t *testing.T, retention Retention) (*Store, *time.Time) {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "nested", "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := New(db, retention)
+	if err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	}
+
+	clock := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return store, &clock
+}
+
+func result(score int) []byte {
+	return []byte(fmt.Sprintf(`{"analyzers":["title","score"],"title":"Kettles","score":{"score":%d,"grade":"B"},"budget":{"passed":true}}`, score))
+}
+
+func ids(runs []Run) []string {
+	result := []string{}
+	for _, run := range runs {
+		result = append(result, run.ID)
+	}
+	return result
+}
+
+func TestSaveAndGet(t *testing.T) {
+	store, _ := testStore(t, Retention{})
+
+	run, err := store.Save("https://example.com", result(81))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if run.ID != "1" || *run.Score != 81 || run.Grade != "B" || !*run.BudgetPassed || run.Size != len(result(81)) {
+		t.Errorf("Unexpected run %+v", run)
+	}
+	if !reflect.DeepEqual(run.Analyzers, []string{"title", "score"}) || !run.AnalyzedAt.Equal(time.Date(2026, 10, 1, 12, 1, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected run %+v", run)
+	}
+
+	stored, storedResult, err := store.Get(run.ID)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(stored, run) || string(storedResult) != string(result(81)) {
+		t.Errorf("Expected the saved run and result, got %+v %s", stored, storedResult)
+	}
+
+	// A result without score or budget has neither in its summary
+	run, _ = store.Save("https://example.com", []byte(`{"analyzers":["title"],"title":"Kettles"}`))
+	if run.Score != nil || run.BudgetPassed != nil {
+		t.Errorf("Expected no score or verdict, got %+v", run)
+	}
+}
+
+func TestSave_InvalidResult(t *testing.T) {
+	store, _ := testStore(t, Retention{})
+
+	if _, err := store.Save("https://example.com", []byte("{")); err == nil {
+		t.Errorf("Expected an error for a result that is not JSON")
+	}
+}
+
+func TestGet_NotFound(t *testing.T) {
+	store, _ := testStore(t, Retention{})
+
+	for _, id := range []string{"1", "abc", ""} {
+		if _, _, err := store.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%q: expected ErrNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestList(t *testing.T) {
+	store, _ := testStore(t, Retention{})
+	for i, url := range []string{"https://example.com", "https://example.org", "https://example.com", "https://example.com"} {
+		if _, err := store.Save(url, result(70+i)); err != nil {
+			t.Fatalf("Failed to save: %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		url      string
+		limit    int
+		expected []string
+	}{
+		{"url newest first", "https://example.com", 10, []string{"4", "3", "1"}},
+		{"limit", "https://example.com", 2, []string{"4", "3"}},
+		{"other url", "https://example.org", 10, []string{"2"}},
+		{"unknown url", "https://example.net", 10, []string{}},
+		{"every url", "", 3, []string{"4", "3", "2"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			runs, err := store.List(test.url, test.limit)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(ids(runs), test.expected) {
+				t.Errorf("Expected runs %v, got %v", test.expected, ids(runs))
+			}
+		})
+	}
+}
+
+func TestRetention_Count(t *testing.T) {
+	store, _ := testStore(t, Retention{MaxRunsPerURL: 2})
+	for i := 0; i < 4; i++ {
+		store.Save("https://example.com", result(i))
+	}
+	store.Save("https://example.org", result(0))
+
+	runs, _ := store.List("https://example.com", 10)
+	if !reflect.DeepEqual(ids(runs), []string{"4", "3"}) {
+		t.Errorf("Expected the two newest runs, got %v", ids(runs))
+	}
+	if _, _, err := store.Get("1"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the result of a removed run to be deleted, got %v", err)
+	}
+	if runs, _ := store.List("https://example.org", 10); len(runs) != 1 {
+		t.Errorf("Expected the runs of other URLs to be kept, got %v", ids(runs))
+	}
+}
+
+func TestRetention_Age(t *testing.T) {
+	store, clock := testStore(t, Retention{MaxAge: 24 * time.Hour})
+	store.Save("https://example.com", result(1))
+	store.Save("https://example.org", result(2))
+
+	*clock = clock.Add(24 * time.Hour)
+	store.Save("https://example.com", result(3))
+
+	// The first run of example.com is a day old by now and pruned when saving
+	runs, _ := store.List("https://example.com", 10)
+	if !reflect.DeepEqual(ids(runs), []string{"3"}) {
+		t.Errorf("Expected the old run to be removed, got %v", ids(runs))
+	}
+
+	// example.org is only pruned by Prune
+	if runs, _ := store.List("https://example.org", 10); len(runs) != 1 {
+		t.Errorf("Expected example.org to be kept until pruned, got %v", ids(runs))
+	}
+	removed, err := store.Prune()
+	if err != nil || removed != 1 {
+		t.Errorf("Expected 1 run to be pruned, got %d, %v", removed, err)
+	}
+	if runs, _ := store.List("", 10); !reflect.DeepEqual(ids(runs), []string{"3"}) {
+		t.Errorf("Expected only the recent run to remain, got %v", ids(runs))
+	}
+}
+
+func TestReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	store, _ := New(db, Retention{})
+	store.Save("https://example.com", result(90))
+	db.Close()
+
+	db, err = storage.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to reopen the database: %v", err)
+	}
+	defer db.Close()
+	store, _ = New(db, Retention{})
+
+	run, stored, err := store.Get("1")
+	if err != nil || *run.Score != 90 || !json.Valid(stored) {
+		t.Errorf("Expected the run to survive a restart, got %+v, %v", run, err)
+	}
+	if run, _ := store.Save("https://example.com", result(91)); run.ID != "2" {
+		t.Errorf("Expected IDs to continue after a restart, got %s", run.ID)
+	}
+}
@@ -0,0 +1,139 @@
+package htmlvalidate
+
+import "golang.org/x/net/html"
+
+func toSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
+
+var voidElements = toSet(
+	"area", "base", "br", "col", "embed", "hr", "img", "input",
+	"link", "meta", "param", "source", "track", "wbr",
+)
+
+// Elements whose end tag may be omitted according to the HTML spec
+var optionalEndTags = toSet(
+	"html", "head", "body", "p", "li", "dt", "dd", "option", "optgroup",
+	"tr", "td", "th", "thead", "tbody", "tfoot", "colgroup", "caption", "rt", "rp",
+)
+
+// See https://html.spec.whatwg.org/multipage/obsolete.html#non-conforming-features
+var obsoleteElements = toSet(
+	"acronym", "applet", "basefont", "bgsound", "big", "blink", "center", "dir",
+	"font", "frame", "frameset", "isindex", "keygen", "listing", "marquee",
+	"menuitem", "multicol", "nextid", "nobr", "noembed", "noframes", "plaintext",
+	"rb", "rtc", "spacer", "strike", "tt", "xmp",
+)
+
+// Obsolete attributes keyed by element name. The "*" entry applies to every element.
+var obsoleteAttributes = map[string]map[string]bool{
+	"*":        toSet("bgcolor"),
+	"a":        toSet("charset", "coords", "shape", "rev", "methods", "urn"),
+	"area":     toSet("nohref", "type"),
+	"body":     toSet("alink", "background", "link", "marginbottom", "marginheight", "marginleft", "marginright", "margintop", "marginwidth", "text", "vlink"),
+	"br":       toSet("clear"),
+	"caption":  toSet("align"),
+	"col":      toSet("align", "char", "charoff", "valign", "width"),
+	"colgroup": toSet("align", "char", "charoff", "valign", "width"),
+	"div":      toSet("align"),
+	"embed":    toSet("align", "hspace", "name", "vspace"),
+	"form":     toSet("accept"),
+	"h1":       toSet("align"),
+	"h2":       toSet("align"),
+	"h3":       toSet("align"),
+	"h4":       toSet("align"),
+	"h5":       toSet("align"),
+	"h6":       toSet("align"),
+	"head":     toSet("profile"),
+	"hr":       toSet("align", "color", "noshade", "size", "width"),
+	"html":     toSet("manifest", "version"),
+	"iframe":   toSet("align", "allowtransparency", "frameborder", "framespacing", "hspace", "longdesc", "marginheight", "marginwidth", "scrolling", "vspace"),
+	"img":      toSet("align", "hspace", "longdesc", "lowsrc", "name", "vspace"),
+	"input":    toSet("align", "ismap", "usemap"),
+	"legend":   toSet("align"),
+	"li":       toSet("type"),
+	"link":     toSet("charset", "methods", "rev", "target", "urn"),
+	"meta":     toSet("scheme"),
+	"object":   toSet("align", "archive", "border", "classid", "code", "codebase", "codetype", "declare", "hspace", "standby", "vspace"),
+	"ol":       toSet("compact"),
+	"p":        toSet("align"),
+	"param":    toSet("type", "valuetype"),
+	"pre":      toSet("width"),
+	"script":   toSet("event", "for", "language"),
+	"table":    toSet("align", "cellpadding", "cellspacing", "frame", "rules", "summary", "width", "datapagesize"),
+	"tbody":    toSet("align", "char", "charoff", "valign"),
+	"td":       toSet("abbr", "align", "axis", "char", "charoff", "height", "nowrap", "scope", "valign", "width"),
+	"tfoot":    toSet("align", "char", "charoff", "valign"),
+	"th":       toSet("align", "axis", "char", "charoff", "height", "nowrap", "valign", "width"),
+	"thead":    toSet("align", "char", "charoff", "valign"),
+	"tr":       toSet("align", "char", "charoff", "height", "valign"),
+	"ul":       toSet("compact", "type"),
+}
+
+// Elements that cannot be nested inside another interactive element
+var interactiveElements = toSet(
+	"a", "button", "details", "embed", "iframe", "input", "label", "select", "textarea",
+)
+
+func isInteractive(name string, attrs []html.Attribute) bool {
+	if name == "input" {
+		for _, attr := range attrs {
+			if attr.Key == "type" && attr.Val == "hidden" {
+				return false
+			}
+		}
+	}
+	return interactiveElements[name]
+}
+
+var blockElements = toSet(
+	"address", "article", "aside", "blockquote", "details", "dialog", "div", "dl",
+	"fieldset", "figcaption", "figure", "footer", "form", "h1", "h2", "h3", "h4",
+	"h5", "h6", "header", "hgroup", "hr", "main", "menu", "nav", "ol", "p", "pre",
+	"section", "table", "ul",
+)
+
+// Inline elements whose content model only allows phrasing content
+var phrasingOnlyElements = toSet(
+	"abbr", "b", "bdi", "bdo", "button", "cite", "code", "data", "dfn", "em", "i",
+	"kbd", "label", "mark", "q", "s", "samp", "small", "span", "strong", "sub",
+	"sup", "time", "u", "var",
+)
+
+// An impliedEndRule describes elements that are closed automatically when one
+// of the closedBy elements starts, unless a boundary element is open in between
+type impliedEndRule struct {
+	closes     map[string]bool
+	closedBy   map[string]bool
+	boundaries map[string]bool
+}
+
+var scopeBoundaries = []string{"applet", "button", "caption", "html", "marquee", "object", "table", "td", "template", "th"}
+
+// Order matters: a new <tr> first closes the open cell and then the open row
+var impliedEndRules = []impliedEndRule{
+	{
+		closes: toSet("p"),
+		closedBy: toSet(
+			"address", "article", "aside", "blockquote", "center", "dd", "details",
+			"dialog", "dir", "div", "dl", "dt", "fieldset", "figcaption", "figure",
+			"footer", "form", "h1", "h2", "h3", "h4", "h5", "h6", "header", "hgroup",
+			"hr", "li", "listing", "main", "menu", "nav", "ol", "p", "plaintext",
+			"pre", "search", "section", "summary", "table", "ul", "xmp",
+		),
+		boundaries: toSet(scopeBoundaries...),
+	},
+	{closes: toSet("li"), closedBy: toSet("li"), boundaries: toSet(append(scopeBoundaries, "ol", "ul", "menu")...)},
+	{closes: toSet("dt", "dd"), closedBy: toSet("dt", "dd"), boundaries: toSet(append(scopeBoundaries, "dl")...)},
+	{closes: toSet("option"), closedBy: toSet("option", "optgroup"), boundaries: toSet("select", "datalist")},
+	{closes: toSet("optgroup"), closedBy: toSet("optgroup"), boundaries: toSet("select")},
+	{closes: toSet("td", "th"), closedBy: toSet("td", "th", "tr", "tbody", "thead", "tfoot"), boundaries: toSet("table")},
+	{closes: toSet("tr"), closedBy: toSet("tr", "tbody", "thead", "tfoot"), boundaries: toSet("table")},
+	{closes: toSet("thead", "tbody", "tfoot"), closedBy: toSet("tbody", "tfoot"), boundaries: toSet("table")},
+	{closes: toSet("head"), closedBy: toSet("body"), boundaries: toSet()},
+	{closes: toSet("rt", "rp"), closedBy: toSet("rt", "rp"), boundaries: toSet("ruby")},
+}
@@ -0,0 +1,367 @@
+package htmlvalidate
+
+import (
+	"fmt"
+	"lt-app/internal/constants"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+type IssueType string
+
+const (
+	IssueUnclosedTag        IssueType = "unclosed-tag"
+	IssueMisnestedTag       IssueType = "misnested-tag"
+	IssueStrayEndTag        IssueType = "stray-end-tag"
+	IssueDuplicateAttribute IssueType = "duplicate-attribute"
+	IssueObsoleteElement    IssueType = "obsolete-element"
+	IssueObsoleteAttribute  IssueType = "obsolete-attribute"
+	IssueInvalidNesting     IssueType = "invalid-nesting"
+	IssueSelfClosingNonVoid IssueType = "self-closing-non-void"
+)
+
+type Issue struct {
+	Type     IssueType `json:"type"`
+	Severity Severity  `json:"severity"`
+	Tag      string    `json:"tag"`
+	Message  string    `json:"message"`
+	Line     int       `json:"line"`
+	Column   int       `json:"column"`
+}
+
+type Report struct {
+	Valid        bool    `json:"valid"`
+	ErrorCount   int     `json:"errorCount"`
+	WarningCount int     `json:"warningCount"`
+	Issues       []Issue `json:"issues"`
+	Truncated    bool    `json:"truncated"`
+}
+
+type openElement struct {
+	name   string
+	line   int
+	column int
+}
+
+type validator struct {
+	stack  []openElement
+	issues []Issue
+	// Depth of svg/math elements. HTML content model rules do not apply inside them.
+	foreignDepth int
+	line         int
+	column       int
+}
+
+// Validate runs the HTML source through the tokenizer and reports well-formedness
+// and conformance problems with the line and column they were found at.
+// It is not a full HTML5 tree construction. Only the common error cases are tracked.
+func Validate(htmlSource string) *Report {
+	v := &validator{line: 1, column: 1}
+	tokenizer := html.NewTokenizer(strings.NewReader(htmlSource))
+
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == html.ErrorToken {
+			break
+		}
+
+		line, column := v.line, v.column
+		v.advance(tokenizer.Raw())
+
+		switch tokenType {
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, attrs := readTag(tokenizer)
+			v.startTag(name, attrs, tokenType == html.SelfClosingTagToken, line, column)
+		case html.EndTagToken:
+			name, _ := tokenizer.TagName()
+			v.endTag(string(name), line, column)
+		}
+	}
+
+	v.closeDocument()
+
+	return v.buildReport()
+}
+
+func readTag(tokenizer *html.Tokenizer) (string, []html.Attribute) {
+	name, hasAttr := tokenizer.TagName()
+	tagName := string(name)
+
+	var attrs []html.Attribute
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = tokenizer.TagAttr()
+		attrs = append(attrs, html.Attribute{Key: string(key), Val: string(val)})
+	}
+
+	return tagName, attrs
+}
+
+// advance moves the cursor past the raw bytes of the current token
+func (v *validator) advance(raw []byte) {
+	for len(raw) > 0 {
+		r, size := utf8.DecodeRune(raw)
+		raw = raw[size:]
+		switch r {
+		case '\n':
+			v.line++
+			v.column = 1
+		case '\r':
+		default:
+			v.column++
+		}
+	}
+}
+
+func (v *validator) report(issueType IssueType, severity Severity, tag string, line int, column int, message string) {
+	v.issues = append(v.issues, Issue{
+		Type:     issueType,
+		Severity: severity,
+		Tag:      tag,
+		Message:  message,
+		Line:     line,
+		Column:   column,
+	})
+}
+
+func (v *validator) startTag(name string, attrs []html.Attribute, selfClosing bool, line int, column int) {
+	v.checkAttributes(name, attrs, line, column)
+
+	if v.foreignDepth > 0 {
+		if !selfClosing {
+			v.push(name, line, column)
+			if name == "svg" || name == "math" {
+				v.foreignDepth++
+			}
+		}
+		return
+	}
+
+	if obsoleteElements[name] {
+		v.report(IssueObsoleteElement, SeverityWarning, name, line, column,
+			fmt.Sprintf("The <%s> element is obsolete", name))
+	}
+
+	v.closeImpliedElements(name)
+	v.checkNesting(name, attrs, line, column)
+
+	if voidElements[name] {
+		return
+	}
+
+	if selfClosing && name != "svg" && name != "math" {
+		v.report(IssueSelfClosingNonVoid, SeverityWarning, name, line, column,
+			fmt.Sprintf("Self-closing syntax is ignored on the non-void element <%s>", name))
+	}
+
+	if selfClosing {
+		return
+	}
+
+	v.push(name, line, column)
+	if name == "svg" || name == "math" {
+		v.foreignDepth++
+	}
+}
+
+func (v *validator) checkAttributes(name string, attrs []html.Attribute, line int, column int) {
+	seen := make(map[string]bool, len(attrs))
+
+	for _, attr := range attrs {
+		if seen[attr.Key] {
+			v.report(IssueDuplicateAttribute, SeverityError, name, line, column,
+				fmt.Sprintf("Duplicate attribute %q on <%s>", attr.Key, name))
+		}
+		seen[attr.Key] = true
+
+		if v.foreignDepth > 0 {
+			continue
+		}
+
+		if obsoleteAttributes[name][attr.Key] || obsoleteAttributes["*"][attr.Key] {
+			v.report(IssueObsoleteAttribute, SeverityWarning, name, line, column,
+				fmt.Sprintf("The %q attribute on <%s> is obsolete", attr.Key, name))
+		}
+	}
+}
+
+// checkNesting reports elements placed where the content model does not allow them
+func (v *validator) checkNesting(name string, attrs []html.Attribute, line int, column int) {
+	if name == "form" && v.isOpen("form") {
+		v.report(IssueInvalidNesting, SeverityError, name, line, column, "A <form> element cannot be nested inside another <form>")
+	}
+
+	if name == "a" && v.isOpen("a") {
+		v.report(IssueInvalidNesting, SeverityError, name, line, column, "An <a> element cannot be nested inside another <a>")
+	}
+
+	if isInteractive(name, attrs) {
+		if v.isOpen("button") {
+			v.report(IssueInvalidNesting, SeverityError, name, line, column,
+				fmt.Sprintf("Interactive element <%s> cannot be nested inside <button>", name))
+		}
+		if name != "a" && v.isOpen("a") {
+			v.report(IssueInvalidNesting, SeverityError, name, line, column,
+				fmt.Sprintf("Interactive element <%s> cannot be nested inside <a>", name))
+		}
+	}
+
+	if blockElements[name] {
+		if parent := v.nearestPhrasingParent(); parent != "" {
+			v.report(IssueInvalidNesting, SeverityError, name, line, column,
+				fmt.Sprintf("Block element <%s> cannot be nested inside inline element <%s>", name, parent))
+		}
+	}
+}
+
+// nearestPhrasingParent returns the innermost open inline element that only
+// accepts phrasing content, stopping at the first element that accepts blocks
+func (v *validator) nearestPhrasingParent() string {
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		name := v.stack[i].name
+		if phrasingOnlyElements[name] {
+			return name
+		}
+		if name != "a" {
+			return ""
+		}
+	}
+	return ""
+}
+
+func (v *validator) isOpen(name string) bool {
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		if v.stack[i].name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *validator) push(name string, line int, column int) {
+	v.stack = append(v.stack, openElement{name: name, line: line, column: column})
+}
+
+// closeImpliedElements pops elements whose end tag is implied by the start tag
+// that is about to be opened, e.g. a new <li> closes the previous <li>
+func (v *validator) closeImpliedElements(name string) {
+	for _, rule := range impliedEndRules {
+		if !rule.closedBy[name] {
+			continue
+		}
+
+		for i := len(v.stack) - 1; i >= 0; i-- {
+			open := v.stack[i].name
+			if rule.boundaries[open] {
+				break
+			}
+			if rule.closes[open] {
+				if v.onlyOptionalAbove(i) {
+					v.stack = v.stack[:i]
+				}
+				break
+			}
+		}
+	}
+}
+
+// onlyOptionalAbove reports whether every element above the given stack index
+// can have its end tag omitted
+func (v *validator) onlyOptionalAbove(index int) bool {
+	for _, el := range v.stack[index+1:] {
+		if !optionalEndTags[el.name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (v *validator) endTag(name string, line int, column int) {
+	if voidElements[name] {
+		v.report(IssueStrayEndTag, SeverityError, name, line, column,
+			fmt.Sprintf("Void element <%s> must not have an end tag", name))
+		return
+	}
+
+	index := -1
+	for i := len(v.stack) - 1; i >= 0; i-- {
+		if v.stack[i].name == name {
+			index = i
+			break
+		}
+	}
+
+	if index == -1 {
+		v.report(IssueStrayEndTag, SeverityError, name, line, column,
+			fmt.Sprintf("End tag </%s> has no matching start tag", name))
+		return
+	}
+
+	for _, el := range v.stack[index+1:] {
+		if optionalEndTags[el.name] {
+			continue
+		}
+		v.report(IssueMisnestedTag, SeverityError, name, line, column,
+			fmt.Sprintf("End tag </%s> closes <%s> opened at line %d, column %d before it was closed", name, el.name, el.line, el.column))
+	}
+
+	for _, el := range v.stack[index:] {
+		if (el.name == "svg" || el.name == "math") && v.foreignDepth > 0 {
+			v.foreignDepth--
+		}
+	}
+	v.stack = v.stack[:index]
+}
+
+func (v *validator) closeDocument() {
+	for _, el := range v.stack {
+		if optionalEndTags[el.name] {
+			continue
+		}
+		v.report(IssueUnclosedTag, SeverityError, el.name, el.line, el.column,
+			fmt.Sprintf("Element <%s> is never closed", el.name))
+	}
+	v.stack = nil
+}
+
+func (v *validator) buildReport() *Report {
+	sort.SliceStable(v.issues, func(i, j int) bool {
+		if v.issues[i].Line != v.issues[j].Line {
+			return v.issues[i].Line < v.issues[j].Line
+		}
+		return v.issues[i].Column < v.issues[j].Column
+	})
+
+	report := &Report{Issues: v.issues}
+	for _, issue := range v.issues {
+		if issue.Severity == SeverityError {
+			report.ErrorCount++
+		} else {
+			report.WarningCount++
+		}
+	}
+
+	// Badly broken pages can produce thousands of issues
+	if len(report.Issues) > constants.VALIDATION_MAX_ISSUES {
+		report.Issues = report.Issues[:constants.VALIDATION_MAX_ISSUES]
+		report.Truncated = true
+	}
+
+	if report.Issues == nil {
+		report.Issues = []Issue{}
+	}
+
+	report.Valid = report.ErrorCount == 0
+
+	return report
+}
@@ -0,0 +1,102 @@
+package htmlvalidate
+
+import (
+	"lt-app/internal/utils"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name          string
+		htmlSource    string
+		expectedTypes []IssueType
+	}{
+		{"Valid document", "<!DOCTYPE html><html><head><title>T</title></head><body><div><p>Text</p></div></body></html>", nil},
+		{"Optional end tags", "<ul><li>One<li>Two</ul><p>First<p>Second<table><tr><td>A<td>B</table>", nil},
+		{"Void elements", "<div><br><img src=\"a.png\" alt=\"\"><input type=\"text\"></div>", nil},
+		{"Foreign content", "<div><svg><path d=\"M0\"/><circle r=\"1\"/></svg></div>", nil},
+		{"Unclosed tag", "<div><span>Text</div>", []IssueType{IssueMisnestedTag}},
+		{"Never closed", "<div><section>Text", []IssueType{IssueUnclosedTag, IssueUnclosedTag}},
+		{"Misnested tags", "<b><i>Text</b></i>", []IssueType{IssueMisnestedTag, IssueStrayEndTag}},
+		{"Stray end tag", "<div>Text</div></span>", []IssueType{IssueStrayEndTag}},
+		{"Void end tag", "<div><br></br></div>", []IssueType{IssueStrayEndTag}},
+		{"Duplicate attribute", "<div class=\"a\" class=\"b\"></div>", []IssueType{IssueDuplicateAttribute}},
+		{"Obsolete element", "<center>Text</center>", []IssueType{IssueObsoleteElement}},
+		{"Obsolete attribute", "<table cellpadding=\"0\"><tr><td>A</td></tr></table>", []IssueType{IssueObsoleteAttribute}},
+		{"Block inside inline", "<span><div>Text</div></span>", []IssueType{IssueInvalidNesting}},
+		{"Block inside link inside inline", "<em><a href=\"/\"><div>Text</div></a></em>", []IssueType{IssueInvalidNesting}},
+		{"Form in form", "<form><form></form></form>", []IssueType{IssueInvalidNesting}},
+		{"Link in link", "<a href=\"/a\"><a href=\"/b\">B</a></a>", []IssueType{IssueInvalidNesting}},
+		{"Button in link", "<a href=\"/a\"><button>B</button></a>", []IssueType{IssueInvalidNesting}},
+		{"Self-closing div", "<div/>", []IssueType{IssueSelfClosingNonVoid}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := Validate(test.htmlSource)
+
+			if len(report.Issues) != len(test.expectedTypes) {
+				t.Fatalf("Validate(%q) returned %d issues %v; want %v", test.htmlSource, len(report.Issues), report.Issues, test.expectedTypes)
+			}
+
+			for i, issue := range report.Issues {
+				if issue.Type != test.expectedTypes[i] {
+					t.Errorf("Validate(%q) issue %d type = %v; want %v", test.htmlSource, i, issue.Type, test.expectedTypes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidate_Positions(t *testing.T) {
+	htmlSource := "<div>\n  <p>Text</p>\n  </span>\n</div>"
+
+	report := Validate(htmlSource)
+
+	if len(report.Issues) != 1 {
+		t.Fatalf("Expected 1 issue, got %d", len(report.Issues))
+	}
+
+	issue := report.Issues[0]
+	if issue.Line != 3 || issue.Column != 3 {
+		t.Errorf("Expected issue at line 3, column 3, got line %d, column %d", issue.Line, issue.Column)
+	}
+}
+
+func TestValidate_Counts(t *testing.T) {
+	report := Validate("<center><span><div>Text</div></span></center>")
+
+	if report.Valid {
+		t.Errorf("Expected report to be invalid")
+	}
+	if report.ErrorCount != 1 {
+		t.Errorf("Expected 1 error, got %d", report.ErrorCount)
+	}
+	if report.WarningCount != 1 {
+		t.Errorf("Expected 1 warning, got %d", report.WarningCount)
+	}
+}
+
+func TestValidate_MockFiles(t *testing.T) {
+	rootDir := utils.GetProjectRoot()
+
+	validHtml, err := os.ReadFile(filepath.Join(rootDir, "mocks", "scrape.html"))
+	if err != nil {
+		t.Fatalf("Failed to read mock HTML file: %v", err)
+	}
+
+	if report := Validate(string(validHtml)); !report.Valid {
+		t.Errorf("Expected scrape.html to be valid, got issues %v", report.Issues)
+	}
+
+	invalidHtml, err := os.ReadFile(filepath.Join(rootDir, "mocks", "invalid.html"))
+	if err != nil {
+		t.Fatalf("Failed to read mock HTML file: %v", err)
+	}
+
+	if report := Validate(string(invalidHtml)); report.Valid {
+		t.Errorf("Expected invalid.html to be invalid")
+	}
+}
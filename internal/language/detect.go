@@ -0,0 +1,253 @@
+package language
+
+import (
+	"embed"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	// Number of the most frequent trigrams kept per language and per text
+	profileSize = 300
+	// Texts with fewer letters are too short to detect reliably
+	minLetters = 40
+	// Han, kana and Hangul characters stand for whole syllables or words
+	minSyllabicLetters = 10
+	// Distance between the best and second best language needed for a reliable result
+	minMargin = 0.05
+)
+
+// Sample texts the trigram profiles of Latin script languages are built from
+//
+//go:embed corpus/*.txt
+var corpus embed.FS
+
+var profiles = loadProfiles()
+
+// Languages written in a script of their own are recognised from the script alone
+var scriptLanguages = []struct {
+	table    *unicode.RangeTable
+	script   string
+	language string
+}{
+	{unicode.Hangul, "Hang", "ko"},
+	{unicode.Arabic, "Arab", "ar"},
+	{unicode.Hebrew, "Hebr", "he"},
+	{unicode.Greek, "Grek", "el"},
+	{unicode.Thai, "Thai", "th"},
+	{unicode.Devanagari, "Deva", "hi"},
+	{unicode.Armenian, "Armn", "hy"},
+	{unicode.Georgian, "Geor", "ka"},
+}
+
+type profile struct {
+	language string
+	ranks    map[string]int
+}
+
+func loadProfiles() []profile {
+	entries, err := corpus.ReadDir("corpus")
+	if err != nil {
+		panic(err)
+	}
+
+	result := make([]profile, 0, len(entries))
+	for _, entry := range entries {
+		content, err := corpus.ReadFile(path.Join("corpus", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, profile{
+			language: strings.TrimSuffix(entry.Name(), ".txt"),
+			ranks:    rankTrigrams(string(content)),
+		})
+	}
+	return result
+}
+
+// Detect guesses the language of the text. The script decides for languages with
+// a script of their own, Latin script text is compared with the trigram profiles.
+func Detect(text string) *Detection {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		counts[scriptOf(r)]++
+	}
+
+	detection := &Detection{}
+
+	// Japanese mixes kana with Han characters and may use more Han than kana
+	if counts["Jpan"] > 0 && counts["Jpan"]*10 >= counts["Hani"] {
+		counts["Jpan"] += counts["Hani"]
+		delete(counts, "Hani")
+	}
+
+	script := ""
+	for name, count := range counts {
+		if script == "" || count > counts[script] || (count == counts[script] && name < script) {
+			script = name
+		}
+	}
+
+	syllabic := script == "Hani" || script == "Jpan" || script == "Hang"
+	if letters < minLetters && (!syllabic || letters < minSyllabicLetters) {
+		return detection
+	}
+	detection.Script = script
+	share := float64(counts[script]) / float64(letters)
+
+	switch script {
+	case "Latn":
+		detection.Language, detection.Confidence = detectLatin(text)
+		detection.Confidence = round(detection.Confidence * share)
+		detection.Reliable = detection.Confidence >= minMargin
+		return detection
+	case "Cyrl":
+		language, distinguished := detectCyrillic(text)
+		detection.Language = language
+		detection.Confidence = round(share)
+		detection.Reliable = distinguished && share >= 0.5
+		return detection
+	case "Jpan":
+		detection.Language = "ja"
+	case "Hani":
+		detection.Language = "zh"
+	default:
+		for _, entry := range scriptLanguages {
+			if entry.script == script {
+				detection.Language = entry.language
+			}
+		}
+	}
+
+	detection.Confidence = round(share)
+	detection.Reliable = detection.Language != "" && share >= 0.5
+	return detection
+}
+
+// detectCyrillic recognises Cyrillic languages by the letters only they use. Text
+// without such a letter, e.g. Bulgarian, is reported as Russian but not distinguished.
+func detectCyrillic(text string) (string, bool) {
+	lower := strings.ToLower(text)
+	has := func(letters string) bool { return strings.ContainsAny(lower, letters) }
+	switch {
+	// Belarusian writes both і and ы, Ukrainian only і and Russian only ы
+	case has("ў"), has("і") && has("ы"):
+		return "be", true
+	case has("іїєґ"):
+		return "uk", true
+	case has("ѓќѕ"):
+		return "mk", true
+	case has("ђћјљњџ"):
+		return "sr", true
+	case has("ыэё"):
+		return "ru", true
+	}
+	return "ru", false
+}
+
+func scriptOf(r rune) string {
+	switch {
+	case unicode.Is(unicode.Latin, r):
+		return "Latn"
+	case unicode.Is(unicode.Cyrillic, r):
+		return "Cyrl"
+	case unicode.Is(unicode.Hiragana, r), unicode.Is(unicode.Katakana, r):
+		return "Jpan"
+	case unicode.Is(unicode.Han, r):
+		return "Hani"
+	}
+	for _, entry := range scriptLanguages {
+		if unicode.Is(entry.table, r) {
+			return entry.script
+		}
+	}
+	return "Zyyy"
+}
+
+// detectLatin ranks the trigrams of the text and returns the language whose profile
+// is closest (the "out of place" measure of Cavnar and Trenkle). The confidence is
+// the relative distance to the runner up.
+func detectLatin(text string) (string, float64) {
+	ranks := rankTrigrams(text)
+
+	type distance struct {
+		language string
+		value    int
+	}
+	distances := make([]distance, 0, len(profiles))
+	for _, p := range profiles {
+		value := 0
+		for trigram, rank := range ranks {
+			if profileRank, exists := p.ranks[trigram]; exists {
+				value += abs(rank - profileRank)
+			} else {
+				value += profileSize
+			}
+		}
+		distances = append(distances, distance{p.language, value})
+	}
+
+	sort.Slice(distances, func(i, j int) bool {
+		if distances[i].value != distances[j].value {
+			return distances[i].value < distances[j].value
+		}
+		return distances[i].language < distances[j].language
+	})
+
+	if len(distances) < 2 || distances[1].value == 0 {
+		return distances[0].language, 0
+	}
+	return distances[0].language, float64(distances[1].value-distances[0].value) / float64(distances[1].value)
+}
+
+// rankTrigrams returns the rank of the most frequent trigrams of the text. Words are
+// lower cased and padded with spaces so that trigrams capture word starts and ends.
+func rankTrigrams(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		runes := []rune(" " + word + " ")
+		for i := 0; i+3 <= len(runes); i++ {
+			counts[string(runes[i:i+3])]++
+		}
+	}
+
+	trigrams := make([]string, 0, len(counts))
+	for trigram := range counts {
+		trigrams = append(trigrams, trigram)
+	}
+	sort.Slice(trigrams, func(i, j int) bool {
+		if counts[trigrams[i]] != counts[trigrams[j]] {
+			return counts[trigrams[i]] > counts[trigrams[j]]
+		}
+		return trigrams[i] < trigrams[j]
+	})
+
+	if len(trigrams) > profileSize {
+		trigrams = trigrams[:profileSize]
+	}
+
+	ranks := make(map[string]int, len(trigrams))
+	for rank, trigram := range trigrams {
+		ranks[trigram] = rank
+	}
+	return ranks
+}
+
+func abs(value int) int {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,208 @@
+package language
+
+import (
+	"fmt"
+	"lt-app/internal/findings"
+	"net/http"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/text/language"
+)
+
+const (
+	SourceHTMLLang        = "html lang"
+	SourceContentLanguage = "Content-Language"
+	SourceOGLocale        = "og:locale"
+)
+
+// Declaration is a language the page claims to be written in
+type Declaration struct {
+	Source string `json:"source"`
+	Value  string `json:"value"`
+	// Canonical BCP 47 form of the value, e.g. en-US for en-us
+	Tag   string `json:"tag,omitempty"`
+	Valid bool   `json:"valid"`
+	Error string `json:"error,omitempty"`
+}
+
+// Detection is the language of the visible text. Language is empty when the text
+// is too short or in a script the detector does not know.
+type Detection struct {
+	Language   string  `json:"language"`
+	Script     string  `json:"script"`
+	Confidence float64 `json:"confidence"`
+	Reliable   bool    `json:"reliable"`
+}
+
+type Report struct {
+	Declared []Declaration      `json:"declared"`
+	Detected *Detection         `json:"detected"`
+	Findings []findings.Finding `json:"findings"`
+}
+
+// Analyze reads the declared languages of the page, detects the language of the
+// text and compares them
+func Analyze(doc *goquery.Document, headers http.Header, text string) *Report {
+	report := &Report{Declared: []Declaration{}, Findings: []findings.Finding{}}
+
+	htmlLang := ""
+	if doc != nil {
+		htmlLang = strings.TrimSpace(doc.Find("html").AttrOr("lang", ""))
+	}
+	if htmlLang != "" {
+		report.Declared = append(report.Declared, declare(SourceHTMLLang, htmlLang, htmlLang))
+	}
+
+	for _, value := range headers.Values("Content-Language") {
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				report.Declared = append(report.Declared, declare(SourceContentLanguage, part, part))
+			}
+		}
+	}
+
+	if doc != nil {
+		if locale := strings.TrimSpace(doc.Find(`meta[property="og:locale"]`).First().AttrOr("content", "")); locale != "" {
+			// Open Graph locales are written with an underscore, e.g. en_US
+			report.Declared = append(report.Declared, declare(SourceOGLocale, locale, strings.ReplaceAll(locale, "_", "-")))
+		}
+	}
+
+	report.Detected = Detect(text)
+	report.Findings = check(report, htmlLang != "")
+
+	return report
+}
+
+func declare(source string, value string, tagString string) Declaration {
+	declaration := Declaration{Source: source, Value: value}
+
+	// Parse also accepts underscores, which BCP 47 does not allow
+	if strings.Contains(tagString, "_") {
+		declaration.Error = "subtags must be separated by hyphens"
+		return declaration
+	}
+
+	tag, err := language.Parse(tagString)
+	if err != nil {
+		declaration.Error = err.Error()
+		return declaration
+	}
+
+	declaration.Tag = tag.String()
+	declaration.Valid = true
+	return declaration
+}
+
+func check(report *Report, hasHTMLLang bool) []findings.Finding {
+	result := []findings.Finding{}
+
+	if !hasHTMLLang {
+		result = append(result, findings.Finding{
+			ID:       "lang-missing",
+			Category: findings.CategoryAccessibility,
+			Severity: findings.SeverityWarning,
+			Message:  "The html element has no lang attribute, so screen readers cannot pick the right pronunciation",
+			Target:   "html",
+		})
+	}
+
+	var htmlBase string
+	contentLanguages := map[string]bool{}
+	var otherBases []Declaration
+
+	for _, declaration := range report.Declared {
+		if !declaration.Valid {
+			finding := findings.Finding{
+				ID:       "lang-invalid",
+				Category: findings.CategorySEO,
+				Severity: findings.SeverityWarning,
+				Message:  fmt.Sprintf("%q is not a valid BCP 47 language tag: %s", declaration.Value, declaration.Error),
+				Target:   declaration.Source,
+			}
+			if declaration.Source == SourceHTMLLang {
+				finding.Category = findings.CategoryAccessibility
+				finding.Severity = findings.SeverityError
+			}
+			result = append(result, finding)
+			continue
+		}
+
+		switch declaration.Source {
+		case SourceHTMLLang:
+			htmlBase = base(declaration.Tag)
+		case SourceContentLanguage:
+			contentLanguages[base(declaration.Tag)] = true
+			otherBases = append(otherBases, declaration)
+		default:
+			otherBases = append(otherBases, declaration)
+		}
+	}
+
+	if htmlBase != "" {
+		for _, declaration := range otherBases {
+			// Content-Language may list several languages, one of them has to match
+			if declaration.Source == SourceContentLanguage && contentLanguages[htmlBase] {
+				continue
+			}
+			if base(declaration.Tag) != htmlBase {
+				result = append(result, findings.Finding{
+					ID:       "lang-declaration-mismatch",
+					Category: findings.CategorySEO,
+					Severity: findings.SeverityWarning,
+					Message:  fmt.Sprintf("%s declares %q but the html lang attribute declares %q", declaration.Source, declaration.Value, htmlBase),
+					Target:   declaration.Source,
+				})
+			}
+		}
+	}
+
+	if detected := report.Detected; detected != nil && detected.Reliable && htmlBase != "" && htmlBase != detected.Language {
+		result = append(result, findings.Finding{
+			ID:       "lang-detected-mismatch",
+			Category: findings.CategoryAccessibility,
+			Severity: findings.SeverityWarning,
+			Message:  fmt.Sprintf("The text appears to be in %q but the html lang attribute declares %q", detected.Language, htmlBase),
+			Target:   SourceHTMLLang,
+		})
+	}
+
+	return result
+}
+
+// Languages returns the primary language subtags of the page, the declared html
+// language first. They select the keyword sets of keyword based heuristics.
+func (r *Report) Languages() []string {
+	languages := []string{}
+	seen := map[string]bool{}
+
+	add := func(language string) {
+		if language != "" && !seen[language] {
+			seen[language] = true
+			languages = append(languages, language)
+		}
+	}
+
+	for _, declaration := range r.Declared {
+		if declaration.Source == SourceHTMLLang && declaration.Valid {
+			add(base(declaration.Tag))
+		}
+	}
+	if r.Detected != nil && r.Detected.Reliable {
+		add(r.Detected.Language)
+	}
+
+	return languages
+}
+
+// base returns the primary language subtag, with deprecated codes such as iw
+// replaced by their current form
+func base(tag string) string {
+	parsed, err := language.Parse(tag)
+	if err != nil {
+		return ""
+	}
+	b, _ := parsed.Base()
+	return b.String()
+}
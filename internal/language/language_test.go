@@ -0,0 +1,198 @@
+package language
+
+import (
+	"lt-app/internal/findings"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+		script   string
+	}{
+		{"The quick brown fox jumps over the lazy dog while the children are playing in the garden.", "en", "Latn"},
+		{"Der schnelle braune Fuchs springt über den faulen Hund, während die Kinder im Garten spielen.", "de", "Latn"},
+		{"Le renard brun rapide saute par-dessus le chien paresseux pendant que les enfants jouent dans le jardin.", "fr", "Latn"},
+		{"El rápido zorro marrón salta sobre el perro perezoso mientras los niños juegan en el jardín.", "es", "Latn"},
+		{"A rápida raposa marrom pula sobre o cão preguiçoso enquanto as crianças brincam no jardim.", "pt", "Latn"},
+		{"La rapida volpe marrone salta sopra il cane pigro mentre i bambini giocano nel giardino.", "it", "Latn"},
+		{"De snelle bruine vos springt over de luie hond terwijl de kinderen in de tuin spelen.", "nl", "Latn"},
+		{"Szybki brązowy lis przeskakuje nad leniwym psem, podczas gdy dzieci bawią się w ogrodzie.", "pl", "Latn"},
+		{"Den snabba bruna räven hoppar över den lata hunden medan barnen leker i trädgården.", "sv", "Latn"},
+		{"Быстрая коричневая лиса прыгает через ленивую собаку, пока дети играют в саду.", "ru", "Cyrl"},
+		{"Швидка руда лисиця перестрибує через ледачого пса, поки діти граються в саду.", "uk", "Cyrl"},
+		{"Хуткая карычневая ліса пераскоквае праз гультаяватага сабаку, пакуль дзеці гуляюць у садзе.", "be", "Cyrl"},
+		{"Брза смеђа лисица прескаче лењог пса док се деца играју у башти.", "sr", "Cyrl"},
+		{"Брзата кафеава лисица скока преку мрзливото куче додека децата си играат во градината, а ѕвездите светат.", "mk", "Cyrl"},
+		{"素早い茶色の狐は怠け者の犬を飛び越え、子供たちは庭で遊んでいます。そしてみんなは楽しく笑っていました。", "ja", "Jpan"},
+		{"東京都は新型車両の導入計画を発表し、来年度から運行を開始する予定です。", "ja", "Jpan"},
+		{"敏捷的棕色狐狸跳过了懒狗，孩子们正在花园里玩耍，大家都非常开心，阳光明媚，天气很好。", "zh", "Hani"},
+		{"빠른 갈색 여우가 게으른 개를 뛰어넘는 동안 아이들은 정원에서 놀고 있습니다.", "ko", "Hang"},
+		{"Η γρήγορη καφέ αλεπού πηδά πάνω από τον τεμπέλη σκύλο ενώ τα παιδιά παίζουν.", "el", "Grek"},
+	}
+
+	for _, test := range tests {
+		detection := Detect(test.text)
+		if detection.Language != test.expected || detection.Script != test.script {
+			t.Errorf("Detect(%q) = %s/%s, expected %s/%s", test.text, detection.Language, detection.Script, test.expected, test.script)
+		}
+		if !detection.Reliable {
+			t.Errorf("Expected a reliable detection for %q, got confidence %v", test.text, detection.Confidence)
+		}
+	}
+}
+
+func TestDetectUndistinguishedCyrillic(t *testing.T) {
+	// Bulgarian uses no letter that Russian does not
+	detection := Detect("Бързата кафява лисица прескача мързеливото куче, докато децата играят в градината.")
+	if detection.Script != "Cyrl" || detection.Reliable {
+		t.Errorf("Expected an unreliable detection, got %+v", detection)
+	}
+
+	report := &Report{
+		Declared: []Declaration{declare(SourceHTMLLang, "bg", "bg")},
+		Detected: detection,
+	}
+	for _, finding := range check(report, true) {
+		if finding.ID == "lang-detected-mismatch" {
+			t.Errorf("Expected no mismatch for an unreliable detection, got %+v", finding)
+		}
+	}
+}
+
+func TestDetectShortText(t *testing.T) {
+	detection := Detect("Hello world")
+	if detection.Language != "" || detection.Reliable {
+		t.Errorf("Expected no detection for a short text, got %+v", detection)
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedTag string
+		valid       bool
+	}{
+		{"en", "en", true},
+		{"en-us", "en-US", true},
+		{"zh-Hant-TW", "zh-Hant-TW", true},
+		{"en_US", "", false},
+		{"english", "", false},
+		{"xx", "", false},
+		{"en-", "", false},
+	}
+
+	for _, test := range tests {
+		declaration := declare(SourceHTMLLang, test.value, test.value)
+		if declaration.Valid != test.valid || declaration.Tag != test.expectedTag {
+			t.Errorf("declare(%q) = %+v, expected tag %q valid %v", test.value, declaration, test.expectedTag, test.valid)
+		}
+		if !declaration.Valid && declaration.Error == "" {
+			t.Errorf("Expected an error for %q", test.value)
+		}
+	}
+}
+
+const englishText = "Welcome to our shop. We sell handmade furniture, lamps and carpets that are built to last for many years."
+const germanText = "Willkommen in unserem Laden. Wir verkaufen handgemachte Möbel, Lampen und Teppiche, die viele Jahre halten."
+
+func TestAnalyze(t *testing.T) {
+	tests := []struct {
+		name              string
+		page              string
+		headers           http.Header
+		text              string
+		expectedIDs       []string
+		expectedLanguages []string
+	}{
+		{
+			name:              "consistent declarations",
+			page:              `<html lang="en-GB"><head><meta property="og:locale" content="en_GB"></head></html>`,
+			headers:           http.Header{"Content-Language": {"en-GB, fr"}},
+			text:              englishText,
+			expectedIDs:       []string{},
+			expectedLanguages: []string{"en"},
+		},
+		{
+			name:              "missing lang",
+			page:              `<html><body></body></html>`,
+			text:              germanText,
+			expectedIDs:       []string{"lang-missing"},
+			expectedLanguages: []string{"de"},
+		},
+		{
+			name:              "invalid lang",
+			page:              `<html lang="en_US"></html>`,
+			text:              englishText,
+			expectedIDs:       []string{"lang-invalid"},
+			expectedLanguages: []string{"en"},
+		},
+		{
+			name:              "declarations disagree",
+			page:              `<html lang="en"><head><meta property="og:locale" content="de_DE"></head></html>`,
+			headers:           http.Header{"Content-Language": {"fr"}},
+			text:              englishText,
+			expectedIDs:       []string{"lang-declaration-mismatch", "lang-declaration-mismatch"},
+			expectedLanguages: []string{"en"},
+		},
+		{
+			name:              "text in another language",
+			page:              `<html lang="en"></html>`,
+			text:              germanText,
+			expectedIDs:       []string{"lang-detected-mismatch"},
+			expectedLanguages: []string{"en", "de"},
+		},
+		{
+			name:              "deprecated code",
+			page:              `<html lang="iw"></html>`,
+			text:              "",
+			expectedIDs:       []string{},
+			expectedLanguages: []string{"he"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			doc, err := goquery.NewDocumentFromReader(strings.NewReader(test.page))
+			if err != nil {
+				t.Fatalf("Failed to parse the page: %v", err)
+			}
+
+			report := Analyze(doc, test.headers, test.text)
+
+			ids := []string{}
+			for _, finding := range report.Findings {
+				ids = append(ids, finding.ID)
+			}
+			if !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected findings %v, got %+v", test.expectedIDs, report.Findings)
+			}
+
+			if languages := report.Languages(); !reflect.DeepEqual(languages, test.expectedLanguages) {
+				t.Errorf("Expected languages %v, got %v", test.expectedLanguages, languages)
+			}
+		})
+	}
+}
+
+func TestAnalyzeInvalidLangIsAnAccessibilityError(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(`<html lang="english"></html>`))
+	report := Analyze(doc, nil, "")
+
+	expected := findings.Finding{
+		ID:       "lang-invalid",
+		Category: findings.CategoryAccessibility,
+		Severity: findings.SeverityError,
+		Message:  `"english" is not a valid BCP 47 language tag: language: tag is not well-formed`,
+		Target:   SourceHTMLLang,
+	}
+	if len(report.Findings) != 1 || report.Findings[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report.Findings)
+	}
+}
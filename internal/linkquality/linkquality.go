@@ -0,0 +1,161 @@
+package linkquality
+
+import (
+	"fmt"
+	"lt-app/internal/findings"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Anchor texts that do not tell where the link goes
+var genericTexts = map[string]bool{
+	"click here": true, "click": true, "here": true, "read more": true, "more": true,
+	"learn more": true, "more info": true, "more information": true, "details": true,
+	"continue": true, "continue reading": true, "link": true, "this link": true,
+	"go": true, "go here": true, "start": true, "this": true, "info": true, "see more": true,
+}
+
+// Link is an <a href> element with the attributes that affect its quality
+type Link struct {
+	Href string `json:"href"`
+	// Visible text of the link, including the alt text of images inside it
+	Text      string   `json:"text"`
+	AriaLabel string   `json:"ariaLabel,omitempty"`
+	Title     string   `json:"title,omitempty"`
+	Rel       []string `json:"rel"`
+	Target    string   `json:"target,omitempty"`
+	// Number of images inside the link and how many of them have no alt text
+	Images           int `json:"images"`
+	ImagesWithoutAlt int `json:"imagesWithoutAlt"`
+}
+
+type Report struct {
+	Total       int `json:"total"`
+	TargetBlank int `json:"targetBlank"`
+	// Links with target="_blank" and neither noopener nor noreferrer
+	UnsafeTargetBlank    int                `json:"unsafeTargetBlank"`
+	Nofollow             int                `json:"nofollow"`
+	Sponsored            int                `json:"sponsored"`
+	UGC                  int                `json:"ugc"`
+	GenericText          int                `json:"genericText"`
+	Empty                int                `json:"empty"`
+	ImageLinksWithoutAlt int                `json:"imageLinksWithoutAlt"`
+	Findings             []findings.Finding `json:"findings"`
+}
+
+// NewLink reads the link attributes of an <a> element
+func NewLink(s *goquery.Selection) Link {
+	link := Link{
+		Href:      strings.TrimSpace(s.AttrOr("href", "")),
+		AriaLabel: strings.TrimSpace(s.AttrOr("aria-label", "")),
+		Title:     strings.TrimSpace(s.AttrOr("title", "")),
+		Rel:       strings.Fields(strings.ToLower(s.AttrOr("rel", ""))),
+		Target:    strings.TrimSpace(s.AttrOr("target", "")),
+	}
+
+	text := []string{strings.Join(strings.Fields(s.Text()), " ")}
+	s.Find("img").Each(func(_ int, img *goquery.Selection) {
+		link.Images++
+		if alt := strings.TrimSpace(img.AttrOr("alt", "")); alt != "" {
+			text = append(text, alt)
+		} else {
+			link.ImagesWithoutAlt++
+		}
+	})
+	link.Text = strings.TrimSpace(strings.Join(text, " "))
+
+	return link
+}
+
+// accessibleName is the name screen readers announce for the link
+func (l Link) accessibleName() string {
+	switch {
+	case l.AriaLabel != "":
+		return l.AriaLabel
+	case l.Text != "":
+		return l.Text
+	default:
+		return l.Title
+	}
+}
+
+func (l Link) hasRel(value string) bool {
+	for _, rel := range l.Rel {
+		if rel == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Check counts the rel and target attributes of the links and reports links with
+// generic or missing text, image links without alt text and unsafe target="_blank"
+func Check(links []Link) *Report {
+	report := &Report{Total: len(links), Findings: []findings.Finding{}}
+
+	for _, link := range links {
+		if link.hasRel("nofollow") {
+			report.Nofollow++
+		}
+		if link.hasRel("sponsored") {
+			report.Sponsored++
+		}
+		if link.hasRel("ugc") {
+			report.UGC++
+		}
+
+		if strings.EqualFold(link.Target, "_blank") {
+			report.TargetBlank++
+			// noreferrer implies noopener
+			if !link.hasRel("noopener") && !link.hasRel("noreferrer") {
+				report.UnsafeTargetBlank++
+				report.Findings = append(report.Findings, findings.Finding{
+					ID:       "link-target-blank-without-noopener",
+					Category: findings.CategorySecurity,
+					Severity: findings.SeverityWarning,
+					Message:  `Link opens in a new tab without rel="noopener", older browsers give the new page access to this one`,
+					Target:   link.Href,
+				})
+			}
+		}
+
+		name := link.accessibleName()
+		switch {
+		case name == "" && link.Images > 0:
+			report.ImageLinksWithoutAlt++
+			report.Findings = append(report.Findings, findings.Finding{
+				ID:       "link-image-missing-alt",
+				Category: findings.CategoryAccessibility,
+				Severity: findings.SeverityError,
+				Message:  "Image link has no alt text, so it has no accessible name",
+				Target:   link.Href,
+			})
+		case name == "":
+			report.Empty++
+			report.Findings = append(report.Findings, findings.Finding{
+				ID:       "link-empty",
+				Category: findings.CategoryAccessibility,
+				Severity: findings.SeverityError,
+				Message:  "Link has no text, aria-label or title",
+				Target:   link.Href,
+			})
+		case isGeneric(name):
+			report.GenericText++
+			report.Findings = append(report.Findings, findings.Finding{
+				ID:       "link-generic-text",
+				Category: findings.CategorySEO,
+				Severity: findings.SeverityWarning,
+				Message:  fmt.Sprintf("Anchor text %q does not describe the link target", name),
+				Target:   link.Href,
+			})
+		}
+	}
+
+	return report
+}
+
+func isGeneric(text string) bool {
+	normalized := strings.ToLower(strings.Trim(strings.Join(strings.Fields(text), " "), ".!?:…»›→> "))
+	return genericTexts[normalized]
+}
@@ -0,0 +1,101 @@
+package linkquality
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func parseLinks(t *testing.T, body string) []Link {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<html><body>" + body + "</body></html>"))
+	if err != nil {
+		t.Fatalf("Failed to parse the page: %v", err)
+	}
+
+	links := []Link{}
+	doc.Find("a[href]").Each(func(_ int, s *goquery.Selection) {
+		links = append(links, NewLink(s))
+	})
+	return links
+}
+
+func TestNewLink(t *testing.T) {
+	links := parseLinks(t, `
+		<a href="/docs" rel="Nofollow  UGC" target="_blank" title="Docs">  Read the
+			<strong>docs</strong></a>
+		<a href="/home"><img src="logo.png" alt="Home"><img src="spacer.gif"></a>`)
+
+	expected := []Link{
+		{Href: "/docs", Text: "Read the docs", Title: "Docs", Rel: []string{"nofollow", "ugc"}, Target: "_blank"},
+		{Href: "/home", Text: "Home", Rel: []string{}, Images: 2, ImagesWithoutAlt: 1},
+	}
+
+	if !reflect.DeepEqual(links, expected) {
+		t.Errorf("Expected\n%+v\ngot\n%+v", expected, links)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		expectedIDs []string
+	}{
+		{"descriptive link", `<a href="/pricing">See our pricing plans</a>`, []string{}},
+		{"generic text", `<a href="/post">Read more…</a>`, []string{"link-generic-text"}},
+		{"generic text is case insensitive", `<a href="/post">CLICK HERE!</a>`, []string{"link-generic-text"}},
+		{"aria-label replaces generic text", `<a href="/post" aria-label="Read more about pricing">Read more</a>`, []string{}},
+		{"empty link", `<a href="/icon"><i class="icon"></i></a>`, []string{"link-empty"}},
+		{"title names the link", `<a href="/icon" title="Settings"><i class="icon"></i></a>`, []string{}},
+		{"image without alt", `<a href="/"><img src="logo.png"></a>`, []string{"link-image-missing-alt"}},
+		{"image with empty alt", `<a href="/"><img src="logo.png" alt=""></a>`, []string{"link-image-missing-alt"}},
+		{"image with alt", `<a href="/"><img src="logo.png" alt="Home page"></a>`, []string{}},
+		{"target blank", `<a href="https://other.com/" target="_blank">Other site</a>`, []string{"link-target-blank-without-noopener"}},
+		{"target blank with noopener", `<a href="https://other.com/" target="_blank" rel="noopener">Other site</a>`, []string{}},
+		{"target blank with noreferrer", `<a href="https://other.com/" target="_BLANK" rel="noreferrer">Other site</a>`, []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			report := Check(parseLinks(t, test.body))
+
+			ids := []string{}
+			for _, finding := range report.Findings {
+				ids = append(ids, finding.ID)
+			}
+			if !reflect.DeepEqual(ids, test.expectedIDs) {
+				t.Errorf("Expected findings %v, got %+v", test.expectedIDs, report.Findings)
+			}
+		})
+	}
+}
+
+func TestCheckCounts(t *testing.T) {
+	links := parseLinks(t, `
+		<a href="https://ads.com/" rel="sponsored nofollow" target="_blank">Sponsor</a>
+		<a href="https://forum.com/" rel="ugc nofollow">A forum post</a>
+		<a href="https://other.com/" target="_blank" rel="noopener">Other</a>
+		<a href="/a">here</a>
+		<a href="/b"></a>
+		<a href="/c"><img src="c.png"></a>`)
+
+	report := Check(links)
+	report.Findings = nil
+
+	expected := &Report{
+		Total:                6,
+		TargetBlank:          2,
+		UnsafeTargetBlank:    1,
+		Nofollow:             2,
+		Sponsored:            1,
+		UGC:                  1,
+		GenericText:          1,
+		Empty:                1,
+		ImageLinksWithoutAlt: 1,
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, report)
+	}
+}
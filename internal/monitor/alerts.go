@@ -0,0 +1,88 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"lt-app/internal/diff"
+	"time"
+)
+
+// Kinds of alerts
+const (
+	AlertBrokenLinks  = "broken-links"
+	AlertTitleChanged = "title-changed"
+	AlertPageError    = "page-error"
+	AlertTLSExpiring  = "tls-expiring"
+)
+
+// Alert reports a regression found by a run of a monitor
+type Alert struct {
+	ID        string    `json:"id"`
+	MonitorID string    `json:"monitorId"`
+	URL       string    `json:"url"`
+	Kind      string    `json:"kind"`
+	Message   string    `json:"message"`
+	At        time.Time `json:"at"`
+	// Links that broke, for broken-links alerts
+	Links []string `json:"links,omitempty"`
+	// Result of the run that raised the alert and of the run it was compared with
+	HistoryID         string `json:"historyId,omitempty"`
+	PreviousHistoryID string `json:"previousHistoryId,omitempty"`
+}
+
+// result holds the parts of a stored result that alerts are raised on
+type result struct {
+	diff.Snapshot
+	// Only set for HTTPS pages when the tls analyzer ran
+	TLS *struct {
+		DaysRemaining int  `json:"daysRemaining"`
+		Expired       bool `json:"expired"`
+	} `json:"tls"`
+}
+
+func parseResult(stats []byte) (*result, error) {
+	var parsed result
+	if err := json.Unmarshal(stats, &parsed); err != nil {
+		return nil, err
+	}
+	return &parsed, nil
+}
+
+// expiresWithin reports whether the certificate of the page expires within the days
+func (r *result) expiresWithin(days int) bool {
+	return r.TLS != nil && (r.TLS.Expired || r.TLS.DaysRemaining <= days)
+}
+
+// detect compares the result of a run with the previous result of the URL, which
+// is nil for the first result. Alerts are only raised for changes, so a problem
+// that persists is reported once.
+func detect(monitor *Monitor, previous, current *result) []Alert {
+	alerts := []Alert{}
+
+	if previous != nil {
+		changes := diff.Compare(&previous.Snapshot, &current.Snapshot)
+		if len(changes.NewlyBroken) > 0 {
+			alerts = append(alerts, Alert{
+				Kind:    AlertBrokenLinks,
+				Message: fmt.Sprintf("New broken links since the previous run: %d", len(changes.NewlyBroken)),
+				Links:   changes.NewlyBroken,
+			})
+		}
+		if changes.Title != nil && changes.Title.Changed {
+			alerts = append(alerts, Alert{
+				Kind:    AlertTitleChanged,
+				Message: fmt.Sprintf("The title changed from %q to %q", changes.Title.Before, changes.Title.After),
+			})
+		}
+	}
+
+	if current.expiresWithin(monitor.TLSExpiryDays) && (previous == nil || !previous.expiresWithin(monitor.TLSExpiryDays)) {
+		message := fmt.Sprintf("The certificate expires in %d days", current.TLS.DaysRemaining)
+		if current.TLS.Expired {
+			message = "The certificate has expired"
+		}
+		alerts = append(alerts, Alert{Kind: AlertTLSExpiring, Message: message})
+	}
+
+	return alerts
+}
@@ -0,0 +1,109 @@
+package monitor
+
+import (
+	"errors"
+	"fmt"
+	"lt-app/internal/constants"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/utils"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// ErrLimitReached is returned when creating more than MONITOR_MAX_CAP monitors
+var ErrLimitReached = fmt.Errorf("at most %d monitors can be created", constants.MONITOR_MAX_CAP)
+
+// Monitor re-analyzes a URL on a schedule
+type Monitor struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Cron expression with five fields, e.g. "0 6 * * *", or a descriptor such as
+	// "@hourly" or "@every 6h"
+	Schedule string `json:"schedule"`
+	// Analyzers to run, every analyzer when empty
+	Analyzers []string `json:"analyzers"`
+	// An alert is raised when the certificate expires within this many days
+	TLSExpiryDays int       `json:"tlsExpiryDays"`
+	Paused        bool      `json:"paused"`
+	CreatedAt     time.Time `json:"createdAt"`
+	UpdatedAt     time.Time `json:"updatedAt"`
+	// Only set once the monitor ran
+	LastRun *RunStatus `json:"lastRun,omitempty"`
+	// ID of the result of the last run that analyzed the page, which the next
+	// result is compared with. Unlike LastRun it is kept when a run fails.
+	LastHistoryID string `json:"lastHistoryId,omitempty"`
+	// Only set while the monitor is scheduled. It is not stored.
+	NextRun *time.Time `json:"nextRun,omitempty"`
+}
+
+// RunStatus is the outcome of a run of a monitor
+type RunStatus struct {
+	At time.Time `json:"at"`
+	// ID of the result in the history, empty when the page could not be analyzed
+	HistoryID string `json:"historyId,omitempty"`
+	Error     string `json:"error,omitempty"`
+	Alerts    int    `json:"alerts"`
+}
+
+// Validate checks the URL, schedule, analyzers and TLS expiry days of a monitor
+func (m *Monitor) Validate() error {
+	if !utils.IsValidURL(m.URL) {
+		return errors.New("invalid url format")
+	}
+	if _, err := parseSchedule(m.Schedule); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if err := pagestats.DefaultRegistry.Validate(m.Analyzers); err != nil {
+		return errors.New(err.Error)
+	}
+	if m.TLSExpiryDays < 0 {
+		return errors.New("tlsExpiryDays must not be negative")
+	}
+	return nil
+}
+
+// parseSchedule parses a cron expression or descriptor and rejects schedules that
+// never run or run more often than every MONITOR_MIN_INTERVAL_SECONDS
+func parseSchedule(spec string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, err
+	}
+	interval, runs := minInterval(schedule)
+	if !runs {
+		return nil, errors.New("never runs")
+	}
+	if interval < constants.MONITOR_MIN_INTERVAL_SECONDS*time.Second {
+		return nil, fmt.Errorf("runs more often than every %d seconds", constants.MONITOR_MIN_INTERVAL_SECONDS)
+	}
+	return schedule, nil
+}
+
+// minInterval returns the shortest time between consecutive runs of a schedule over a
+// year, or over its first MONITOR_SCHEDULE_CHECK_RUNS runs. Runs are counted from a
+// fixed time, so the result does not depend on when the schedule is checked.
+func minInterval(schedule cron.Schedule) (time.Duration, bool) {
+	if every, ok := schedule.(cron.ConstantDelaySchedule); ok {
+		return every.Delay, true
+	}
+
+	// A leap year, so that schedules for the 29th of February run
+	start := time.Date(2028, 1, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(1, 0, 0)
+	previous := schedule.Next(start)
+	if previous.IsZero() || !previous.Before(end) {
+		return 0, false
+	}
+
+	shortest := end.Sub(start)
+	for i := 0; i < constants.MONITOR_SCHEDULE_CHECK_RUNS && previous.Before(end); i++ {
+		next := schedule.Next(previous)
+		if next.IsZero() {
+			break
+		}
+		shortest = min(shortest, next.Sub(previous))
+		previous = next
+	}
+	return shortest, true
+}
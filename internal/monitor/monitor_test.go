@@ -0,0 +1,69 @@
+package monitor
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		monitor  Monitor
+		expected string
+	}{
+		{"valid", Monitor{URL: "https://example.com", Schedule: "0 6 * * *"}, ""},
+		{"descriptor", Monitor{URL: "https://example.com", Schedule: "@every 6h", Analyzers: []string{"title"}, TLSExpiryDays: 30}, ""},
+		{"time zone", Monitor{URL: "https://example.com", Schedule: "CRON_TZ=Europe/Berlin 30 9 * * 1-5"}, ""},
+		{"invalid url", Monitor{URL: "example", Schedule: "@hourly"}, "invalid url format"},
+		{"invalid schedule", Monitor{URL: "https://example.com", Schedule: "every hour"}, "invalid schedule"},
+		{"too frequent", Monitor{URL: "https://example.com", Schedule: "@every 30s"}, "runs more often than every 60 seconds"},
+		{"never runs", Monitor{URL: "https://example.com", Schedule: "0 0 30 2 *"}, "never runs"},
+		{"unknown analyzer", Monitor{URL: "https://example.com", Schedule: "@hourly", Analyzers: []string{"unknown"}}, `Unknown analyzer "unknown"`},
+		{"negative days", Monitor{URL: "https://example.com", Schedule: "@hourly", TLSExpiryDays: -1}, "tlsExpiryDays must not be negative"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.monitor.Validate()
+			if test.expected == "" && err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			if test.expected != "" && (err == nil || !strings.Contains(err.Error(), test.expected)) {
+				t.Errorf("Expected %q, got %v", test.expected, err)
+			}
+		})
+	}
+}
+
+func TestMinInterval(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected time.Duration
+	}{
+		{"@every 6h", 6 * time.Hour},
+		{"@hourly", time.Hour},
+		{"*/15 * * * *", 15 * time.Minute},
+		// The short interval is found wherever it is in the hour
+		{"0,1 * * * *", time.Minute},
+		{"0,50 * * * *", 10 * time.Minute},
+		// Across midnight
+		{"0,59 0,23 * * *", time.Minute},
+		// Across weekdays and months
+		{"0 9 * * 1-5", 24 * time.Hour},
+		{"0 0 1,31 * *", 24 * time.Hour},
+		{"0 12 29 2 *", 24 * 366 * time.Hour},
+	}
+
+	for _, test := range tests {
+		schedule, err := cron.ParseStandard(test.spec)
+		if err != nil {
+			t.Fatalf("%s: %v", test.spec, err)
+		}
+		if interval, runs := minInterval(schedule); !runs || interval != test.expected {
+			t.Errorf("%s: expected %v, got %v, %v", test.spec, test.expected, interval, runs)
+		}
+	}
+}
@@ -0,0 +1,291 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"lt-app/internal/budget"
+	"lt-app/internal/constants"
+	"lt-app/internal/history"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/services"
+	"lt-app/internal/webfetch"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// AnalyzeFunc analyzes a page, it is services.FetchWebPageStats outside of tests
+type AnalyzeFunc func(ctx context.Context, webPageUrl string, options services.AnalyzeOptions, RLogger *slog.Logger) (*pagestats.WebPageStats, *webfetch.ErrorResponse)
+
+// Scheduler runs the monitors of a store on their schedules. Changes made through
+// it are stored and rescheduled together.
+type Scheduler struct {
+	store   *Store
+	history *history.Store
+	analyze AnalyzeFunc
+	logger  *slog.Logger
+	now     func() time.Time
+	// A run that takes longer is cancelled and recorded as a page error
+	timeout time.Duration
+	// Called after every run, e.g. to publish webhooks
+	onRun func(monitor *Monitor, status *RunStatus, alerts []Alert)
+
+	cron *cron.Cron
+	// Cancelled by Stop, which ends the scheduled runs
+	ctx     context.Context
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	entries map[string]cron.EntryID
+}
+
+func NewScheduler(store *Store, history *history.Store, analyze AnalyzeFunc, logger *slog.Logger) *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Scheduler{
+		store:   store,
+		history: history,
+		analyze: analyze,
+		logger:  logger,
+		now:     time.Now,
+		timeout: constants.MONITOR_RUN_TIMEOUT_SECONDS * time.Second,
+		cron:    cron.New(),
+		ctx:     ctx,
+		cancel:  cancel,
+		entries: make(map[string]cron.EntryID),
+	}
+}
+
+// OnRun registers a function called after every recorded run, scheduled or not.
+// It must be registered before Start.
+func (s *Scheduler) OnRun(fn func(monitor *Monitor, status *RunStatus, alerts []Alert)) {
+	s.onRun = fn
+}
+
+// Start schedules the stored monitors, e.g. after a restart, and starts running them
+func (s *Scheduler) Start() error {
+	monitors, err := s.store.List()
+	if err != nil {
+		return err
+	}
+	for i := range monitors {
+		if err := s.schedule(&monitors[i]); err != nil {
+			s.logger.Error("Failed to schedule the monitor", "monitorId", monitors[i].ID, "error", err)
+		}
+	}
+	s.cron.Start()
+	return nil
+}
+
+// Stop stops scheduling runs, cancels the runs in progress and waits for them to end
+func (s *Scheduler) Stop() {
+	s.cancel()
+	<-s.cron.Stop().Done()
+}
+
+func (s *Scheduler) List() ([]Monitor, error) {
+	monitors, err := s.store.List()
+	if err != nil {
+		return nil, err
+	}
+	for i := range monitors {
+		s.setNextRun(&monitors[i])
+	}
+	return monitors, nil
+}
+
+func (s *Scheduler) Get(id string) (*Monitor, error) {
+	monitor, err := s.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	s.setNextRun(monitor)
+	return monitor, nil
+}
+
+// Create stores and schedules a valid monitor
+func (s *Scheduler) Create(monitor *Monitor) error {
+	monitors, err := s.store.List()
+	if err != nil {
+		return err
+	}
+	if len(monitors) >= constants.MONITOR_MAX_CAP {
+		return ErrLimitReached
+	}
+
+	monitor.CreatedAt = s.now().UTC()
+	monitor.UpdatedAt = monitor.CreatedAt
+	monitor.LastRun, monitor.LastHistoryID = nil, ""
+	if err := s.store.Create(monitor); err != nil {
+		return err
+	}
+	s.setNextRun(monitor)
+	return s.schedule(monitor)
+}
+
+// Update replaces the settings of a monitor with valid ones and reschedules it
+func (s *Scheduler) Update(monitor *Monitor) error {
+	monitor.UpdatedAt = s.now().UTC()
+	if err := s.store.Update(monitor); err != nil {
+		return err
+	}
+	s.setNextRun(monitor)
+	return s.schedule(monitor)
+}
+
+func (s *Scheduler) Delete(id string) error {
+	if err := s.store.Delete(id); err != nil {
+		return err
+	}
+	s.unschedule(id)
+	return nil
+}
+
+func (s *Scheduler) Alerts(id string, limit int) ([]Alert, error) {
+	return s.store.Alerts(id, limit)
+}
+
+// Run analyzes the page of a monitor now. The result is stored in the history and
+// alerts are raised for regressions since the previous run of the monitor. A run
+// cancelled through ctx, e.g. by Stop, is not recorded and returns the error of ctx.
+func (s *Scheduler) Run(ctx context.Context, id string) (*RunStatus, []Alert, error) {
+	monitor, err := s.store.Get(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	logger := s.logger.With("monitorId", id, "url", monitor.URL)
+	runCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	status := &RunStatus{At: s.now().UTC()}
+	alerts := []Alert{}
+	stats, fetchErr := s.analyze(runCtx, monitor.URL, services.AnalyzeOptions{Analyzers: monitor.Analyzers}, logger)
+	if fetchErr != nil {
+		if ctx.Err() != nil {
+			return nil, nil, ctx.Err()
+		}
+		status.Error = fetchErr.Error
+		// A page that keeps failing is reported once
+		if monitor.LastRun == nil || monitor.LastRun.Error == "" {
+			alerts = append(alerts, Alert{
+				Kind:    AlertPageError,
+				Message: fmt.Sprintf("The page could not be analyzed: %s (status %d)", fetchErr.Error, fetchErr.StatusCode),
+			})
+		}
+	} else if alerts, err = s.saveResult(monitor, stats, status); err != nil {
+		return nil, nil, err
+	}
+
+	for i := range alerts {
+		alerts[i].MonitorID, alerts[i].URL, alerts[i].At = id, monitor.URL, status.At
+	}
+	status.Alerts = len(alerts)
+	if err := s.store.RecordRun(id, *status, alerts); err != nil {
+		return nil, nil, err
+	}
+
+	for _, alert := range alerts {
+		logger.Warn("Monitor alert", "kind", alert.Kind, "message", alert.Message, "alertId", alert.ID)
+	}
+	if s.onRun != nil {
+		monitor.LastRun = status
+		if status.HistoryID != "" {
+			monitor.LastHistoryID = status.HistoryID
+		}
+		s.setNextRun(monitor)
+		s.onRun(monitor, status, alerts)
+	}
+	return status, alerts, nil
+}
+
+// saveResult stores the stats in the history like an analysis made through the API
+// and compares them with the result of the previous run of the monitor
+func (s *Scheduler) saveResult(monitor *Monitor, stats *pagestats.WebPageStats, status *RunStatus) ([]Alert, error) {
+	stats.Attach("budget", budget.Default().Evaluate(stats))
+	stats.Attach("monitorId", monitor.ID)
+	encoded, err := json.Marshal(stats)
+	if err != nil {
+		return nil, err
+	}
+	current, err := parseResult(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var previous *result
+	previousID := ""
+	// The previous result may have been pruned from the history
+	if monitor.LastHistoryID != "" {
+		_, stored, err := s.history.Get(monitor.LastHistoryID)
+		switch {
+		case errors.Is(err, history.ErrNotFound):
+		case err != nil:
+			return nil, err
+		default:
+			if previous, err = parseResult(stored); err != nil {
+				return nil, err
+			}
+			previousID = monitor.LastHistoryID
+		}
+	}
+
+	run, err := s.history.Save(monitor.URL, encoded)
+	if err != nil {
+		return nil, err
+	}
+	status.HistoryID = run.ID
+
+	alerts := detect(monitor, previous, current)
+	for i := range alerts {
+		alerts[i].HistoryID, alerts[i].PreviousHistoryID = run.ID, previousID
+	}
+	return alerts, nil
+}
+
+// schedule replaces the cron entry of a monitor. Paused monitors have none.
+func (s *Scheduler) schedule(monitor *Monitor) error {
+	s.unschedule(monitor.ID)
+	if monitor.Paused {
+		return nil
+	}
+	schedule, err := parseSchedule(monitor.Schedule)
+	if err != nil {
+		return err
+	}
+
+	id := monitor.ID
+	// A run that takes longer than the interval skips the next run instead of overlapping
+	job := cron.NewChain(cron.SkipIfStillRunning(cron.DiscardLogger)).Then(cron.FuncJob(func() {
+		if _, _, err := s.Run(s.ctx, id); err != nil && !errors.Is(err, ErrNotFound) && !errors.Is(err, context.Canceled) {
+			s.logger.Error("Failed to run the monitor", "monitorId", id, "error", err)
+		}
+	}))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[id] = s.cron.Schedule(schedule, job)
+	return nil
+}
+
+func (s *Scheduler) unschedule(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if entryID, found := s.entries[id]; found {
+		s.cron.Remove(entryID)
+		delete(s.entries, id)
+	}
+}
+
+func (s *Scheduler) setNextRun(monitor *Monitor) {
+	monitor.NextRun = nil
+	if monitor.Paused {
+		return
+	}
+	// Stored schedules were validated, so they are not checked again on every read
+	if schedule, err := cron.ParseStandard(monitor.Schedule); err == nil {
+		next := schedule.Next(s.now())
+		monitor.NextRun = &next
+	}
+}
@@ -0,0 +1,326 @@
+package monitor
+
+import (
+	"context"
+	"log/slog"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/applogger"
+	"lt-app/internal/history"
+	"lt-app/internal/myhttp"
+	"lt-app/internal/pagestats"
+	"lt-app/internal/services"
+	"lt-app/internal/storage"
+	"lt-app/internal/webfetch"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// page is what the stubbed analysis finds on the page
+type page struct {
+	title       string
+	links       []string
+	brokenLinks []string
+	// Days until the certificate expires, no TLS section when 0
+	tlsDays    int
+	tlsExpired bool
+	err        *webfetch.ErrorResponse
+}
+
+func testScheduler(t *testing.T, current *page) (*Scheduler, *history.Store) {
+	applogger.InitLogger()
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	store, _ := NewStore(db, 0)
+	historyStore, _ := history.New(db, history.Retention{})
+
+	analyze := func(ctx context.Context, _ string, _ services.AnalyzeOptions, _ *slog.Logger) (*pagestats.WebPageStats, *webfetch.ErrorResponse) {
+		if current.err != nil {
+			return nil, current.err
+		}
+		analyzers := []analyzer.Analyzer{
+			analyzer.NewInline(pagestats.AnalyzerTitle, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.TitleSection, *webfetch.ErrorResponse) {
+				return pagestats.TitleSection{Title: current.title}, nil
+			}),
+			analyzer.NewInline(pagestats.AnalyzerLinks, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.LinksSection, *webfetch.ErrorResponse) {
+				return pagestats.LinksSection{Links: current.links}, nil
+			}),
+			analyzer.NewInline(pagestats.AnalyzerLinkChecks, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.LinkChecksSection, *webfetch.ErrorResponse) {
+				return pagestats.LinkChecksSection{BrokenLinks: current.brokenLinks}, nil
+			}),
+		}
+		if current.tlsDays != 0 {
+			analyzers = append(analyzers, analyzer.NewInline(pagestats.AnalyzerTLS, nil, func(_ context.Context, _ *analyzer.Input) (pagestats.TLSSection, *webfetch.ErrorResponse) {
+				return pagestats.TLSSection{TLS: &myhttp.TLSInfo{DaysRemaining: current.tlsDays, Expired: current.tlsExpired}}, nil
+			}))
+		}
+		return analyzer.NewRegistry().Register(analyzers...).Run(ctx, nil, &analyzer.Input{})
+	}
+
+	scheduler := NewScheduler(store, historyStore, analyze, slog.Default())
+	t.Cleanup(scheduler.Stop)
+	return scheduler, historyStore
+}
+
+func kinds(alerts []Alert) []string {
+	result := []string{}
+	for _, alert := range alerts {
+		result = append(result, alert.Kind)
+	}
+	return result
+}
+
+func TestRun_Alerts(t *testing.T) {
+	current := &page{title: "Kettles", links: []string{"https://example.com/a", "https://example.com/b"}, brokenLinks: []string{}, tlsDays: 90}
+	scheduler, historyStore := testScheduler(t, current)
+	monitor := &Monitor{URL: "https://example.com", Schedule: "@hourly", TLSExpiryDays: 14}
+	scheduler.Create(monitor)
+
+	steps := []struct {
+		name     string
+		change   func()
+		expected []string
+	}{
+		{"first run", func() {}, []string{}},
+		{"unchanged", func() {}, []string{}},
+		{"broken link and title", func() {
+			current.title = "Teapots"
+			current.brokenLinks = []string{"https://example.com/b"}
+		}, []string{AlertBrokenLinks, AlertTitleChanged}},
+		{"still broken", func() {}, []string{}},
+		{"tls expiring", func() { current.tlsDays = 10 }, []string{AlertTLSExpiring}},
+		{"tls still expiring", func() { current.tlsDays = 9 }, []string{}},
+		{"page error", func() { current.err = webfetch.BuildErrorResponse(http.StatusNotFound, "Page not found") }, []string{AlertPageError}},
+		{"still failing", func() {}, []string{}},
+		{"recovered", func() { current.err = nil }, []string{}},
+	}
+
+	for _, step := range steps {
+		step.change()
+		status, alerts, err := scheduler.Run(context.Background(), monitor.ID)
+		if err != nil {
+			t.Fatalf("%s: expected no error, got %v", step.name, err)
+		}
+		if !reflect.DeepEqual(kinds(alerts), step.expected) {
+			t.Errorf("%s: expected alerts %v, got %+v", step.name, step.expected, alerts)
+		}
+		if status.Alerts != len(alerts) || (current.err == nil) != (status.HistoryID != "") {
+			t.Errorf("%s: unexpected status %+v", step.name, status)
+		}
+	}
+
+	alerts, _ := scheduler.Alerts(monitor.ID, 10)
+	if len(alerts) != 4 {
+		t.Fatalf("Expected the alerts to be stored, got %+v", alerts)
+	}
+	broken := alerts[3]
+	if broken.MonitorID != monitor.ID || broken.URL != "https://example.com" || broken.HistoryID != "3" || broken.PreviousHistoryID != "2" ||
+		!reflect.DeepEqual(broken.Links, []string{"https://example.com/b"}) || broken.Message != "New broken links since the previous run: 1" {
+		t.Errorf("Unexpected alert %+v", broken)
+	}
+	if alerts[1].Message != "The certificate expires in 10 days" || alerts[0].Message != "The page could not be analyzed: Page not found (status 404)" {
+		t.Errorf("Unexpected alerts %+v", alerts)
+	}
+
+	// Results of failed runs are not stored
+	runs, _ := historyStore.List("https://example.com", 100)
+	if len(runs) != 7 {
+		t.Errorf("Expected 7 results in the history, got %d", len(runs))
+	}
+	stored, _ := scheduler.Get(monitor.ID)
+	if stored.LastRun == nil || stored.LastRun.HistoryID != runs[0].ID || stored.LastRun.Error != "" {
+		t.Errorf("Expected the last run to be recorded, got %+v", stored.LastRun)
+	}
+}
+
+func TestRun_ComparesWithOwnResult(t *testing.T) {
+	current := &page{title: "Kettles", links: []string{}, brokenLinks: []string{}}
+	scheduler, historyStore := testScheduler(t, current)
+	monitor := &Monitor{URL: "https://example.com", Schedule: "@hourly"}
+	scheduler.Create(monitor)
+	scheduler.Run(context.Background(), monitor.ID)
+
+	// An analysis of the same URL made through the API is not the previous result of the monitor
+	historyStore.Save("https://example.com", []byte(`{"title":"Teapots"}`))
+	_, alerts, err := scheduler.Run(context.Background(), monitor.ID)
+	if err != nil || len(alerts) != 0 {
+		t.Errorf("Expected no alerts, got %+v, %v", alerts, err)
+	}
+
+	// A failed run keeps the result to compare with
+	current.err = webfetch.BuildErrorResponse(http.StatusBadGateway, "Bad gateway")
+	scheduler.Run(context.Background(), monitor.ID)
+	current.err, current.title = nil, "Teapots"
+	_, alerts, _ = scheduler.Run(context.Background(), monitor.ID)
+	if !reflect.DeepEqual(kinds(alerts), []string{AlertTitleChanged}) || alerts[0].PreviousHistoryID != "3" {
+		t.Errorf("Expected the title change since the last result, got %+v", alerts)
+	}
+
+	// A result that was pruned from the history is not compared with
+	scheduler.history, _ = history.New(scheduler.store.db, history.Retention{MaxRunsPerURL: 1})
+	scheduler.history.Save("https://example.com", []byte(`{"title":"Teapots"}`))
+	current.title = "Cups"
+	if _, alerts, err = scheduler.Run(context.Background(), monitor.ID); err != nil || len(alerts) != 0 {
+		t.Errorf("Expected no alerts, got %+v, %v", alerts, err)
+	}
+}
+
+func TestRun_NotFound(t *testing.T) {
+	scheduler, _ := testScheduler(t, &page{})
+
+	if _, _, err := scheduler.Run(context.Background(), "1"); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+}
+
+func TestScheduler_Entries(t *testing.T) {
+	scheduler, _ := testScheduler(t, &page{})
+	clock := time.Date(2026, 10, 19, 9, 30, 0, 0, time.Local)
+	scheduler.now = func() time.Time { return clock }
+
+	first := &Monitor{URL: "https://example.com", Schedule: "0 * * * *"}
+	scheduler.Create(first)
+	scheduler.Create(&Monitor{URL: "https://example.org", Schedule: "@daily", Paused: true})
+
+	if first.NextRun == nil || !first.NextRun.Equal(time.Date(2026, 10, 19, 10, 0, 0, 0, time.Local)) {
+		t.Errorf("Expected the next run at 10:00, got %v", first.NextRun)
+	}
+	if len(scheduler.entries) != 1 {
+		t.Errorf("Expected only the active monitor to be scheduled, got %v", scheduler.entries)
+	}
+
+	first.Paused = true
+	scheduler.Update(first)
+	if len(scheduler.entries) != 0 || first.NextRun != nil {
+		t.Errorf("Expected a paused monitor to be unscheduled, got %v", scheduler.entries)
+	}
+
+	paused, _ := scheduler.Get("2")
+	paused.Paused = false
+	scheduler.Update(paused)
+	scheduler.Delete("1")
+	if _, found := scheduler.entries["2"]; !found || len(scheduler.entries) != 1 {
+		t.Errorf("Expected monitor 2 to be scheduled, got %v", scheduler.entries)
+	}
+}
+
+func TestScheduler_Restart(t *testing.T) {
+	scheduler, _ := testScheduler(t, &page{})
+	scheduler.Create(&Monitor{URL: "https://example.com", Schedule: "@hourly"})
+	scheduler.Create(&Monitor{URL: "https://example.org", Schedule: "@hourly", Paused: true})
+
+	// A new scheduler on the same store schedules the stored monitors
+	restarted := NewScheduler(scheduler.store, scheduler.history, scheduler.analyze, slog.Default())
+	if err := restarted.Start(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer restarted.Stop()
+
+	if _, found := restarted.entries["1"]; !found || len(restarted.entries) != 1 {
+		t.Errorf("Expected the active monitor to be scheduled again, got %v", restarted.entries)
+	}
+}
+
+func TestRun_OnRun(t *testing.T) {
+	current := &page{title: "Kettles", links: []string{}, brokenLinks: []string{}}
+	scheduler, _ := testScheduler(t, current)
+	var calls []*Monitor
+	var reported [][]Alert
+	scheduler.OnRun(func(monitor *Monitor, status *RunStatus, alerts []Alert) {
+		if monitor.LastRun != status {
+			t.Errorf("Expected the monitor to hold the run, got %+v", monitor.LastRun)
+		}
+		calls = append(calls, monitor)
+		reported = append(reported, alerts)
+	})
+	monitor := &Monitor{URL: "https://example.com", Schedule: "@hourly"}
+	scheduler.Create(monitor)
+
+	scheduler.Run(context.Background(), monitor.ID)
+	current.title = "Teapots"
+	scheduler.Run(context.Background(), monitor.ID)
+
+	if len(calls) != 2 || calls[0].ID != monitor.ID || calls[1].NextRun == nil {
+		t.Fatalf("Expected two calls with the monitor, got %+v", calls)
+	}
+	if len(reported[0]) != 0 || !reflect.DeepEqual(kinds(reported[1]), []string{AlertTitleChanged}) || reported[1][0].ID == "" {
+		t.Errorf("Expected the stored alerts to be reported, got %+v", reported)
+	}
+}
+
+func TestRun_HungPage(t *testing.T) {
+	applogger.InitLogger()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+	store, _ := NewStore(db, 0)
+	historyStore, _ := history.New(db, history.Retention{})
+	// The real analysis, so that the timeout has to reach the request of the page
+	scheduler := NewScheduler(store, historyStore, services.FetchWebPageStats, slog.Default())
+	scheduler.timeout = 50 * time.Millisecond
+	monitor := &Monitor{URL: srv.URL, Schedule: "@hourly"}
+	scheduler.Create(monitor)
+
+	started := time.Now()
+	status, alerts, err := scheduler.Run(context.Background(), monitor.ID)
+	if err != nil || status.Error == "" || !reflect.DeepEqual(kinds(alerts), []string{AlertPageError}) {
+		t.Errorf("Expected the run to fail with a page error, got %+v, %+v, %v", status, alerts, err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Expected the run to end after its timeout, took %v", elapsed)
+	}
+
+	// Stop cancels the run in progress, which is not recorded
+	scheduler.timeout = time.Hour
+	done := make(chan error)
+	go func() {
+		_, _, err := scheduler.Run(scheduler.ctx, monitor.ID)
+		done <- err
+	}()
+	time.Sleep(20 * time.Millisecond)
+	scheduler.Stop()
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("Expected context.Canceled, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to cancel the run")
+	}
+	if stored, _ := scheduler.Alerts(monitor.ID, 10); len(stored) != 1 {
+		t.Errorf("Expected the cancelled run not to raise an alert, got %+v", stored)
+	}
+}
+
+func TestRun_CertificateExpired(t *testing.T) {
+	current := &page{title: "Kettles", links: []string{}, brokenLinks: []string{}, tlsDays: 90}
+	scheduler, _ := testScheduler(t, current)
+	monitor := &Monitor{URL: "https://example.com", Schedule: "@hourly", TLSExpiryDays: 14}
+	scheduler.Create(monitor)
+
+	scheduler.Run(context.Background(), monitor.ID)
+	// Pages with expired certificates are still analyzed, see myhttp.RestyClient.GetPage
+	current.tlsDays, current.tlsExpired = -2, true
+	_, alerts, err := scheduler.Run(context.Background(), monitor.ID)
+	if err != nil || len(alerts) != 1 || alerts[0].Kind != AlertTLSExpiring || alerts[0].Message != "The certificate has expired" {
+		t.Errorf("Expected an alert for the expired certificate, got %+v, %v", alerts, err)
+	}
+}
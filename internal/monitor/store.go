@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"encoding/json"
+	"errors"
+	"lt-app/internal/storage"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// Every monitor by ID
+	monitorsBucket = []byte("monitors")
+	// A bucket of alerts per monitor, ordered by ID
+	alertsBucket = []byte("monitor-alerts")
+)
+
+// ErrNotFound is returned for monitors that do not exist or were deleted
+var ErrNotFound = errors.New("monitor not found")
+
+// Store keeps monitors and their alerts in the embedded database
+type Store struct {
+	db *bolt.DB
+	// Alerts kept per monitor, the oldest are removed first
+	maxAlerts int
+}
+
+func NewStore(db *bolt.DB, maxAlerts int) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{monitorsBucket, alertsBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, maxAlerts: maxAlerts}, nil
+}
+
+// Create stores a new monitor and assigns its ID
+func (s *Store) Create(monitor *Monitor) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		monitors := tx.Bucket(monitorsBucket)
+		seq, err := monitors.NextSequence()
+		if err != nil {
+			return err
+		}
+		monitor.ID = strconv.FormatUint(seq, 10)
+		return put(monitors, monitor)
+	})
+}
+
+// Update replaces the settings of a monitor, keeping its ID, creation time and last run
+func (s *Store) Update(monitor *Monitor) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		monitors := tx.Bucket(monitorsBucket)
+		stored, err := get(monitors, monitor.ID)
+		if err != nil {
+			return err
+		}
+		monitor.CreatedAt, monitor.LastRun, monitor.LastHistoryID = stored.CreatedAt, stored.LastRun, stored.LastHistoryID
+		return put(monitors, monitor)
+	})
+}
+
+// Delete removes a monitor with its alerts
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		monitors := tx.Bucket(monitorsBucket)
+		if _, err := get(monitors, id); err != nil {
+			return err
+		}
+		if err := tx.Bucket(alertsBucket).DeleteBucket([]byte(id)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return monitors.Delete(storage.Key(id))
+	})
+}
+
+func (s *Store) Get(id string) (*Monitor, error) {
+	var monitor *Monitor
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		monitor, err = get(tx.Bucket(monitorsBucket), id)
+		return err
+	})
+	return monitor, err
+}
+
+// List returns every monitor, oldest first
+func (s *Store) List() ([]Monitor, error) {
+	result := []Monitor{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(monitorsBucket).ForEach(func(_, value []byte) error {
+			var monitor Monitor
+			if err := json.Unmarshal(value, &monitor); err != nil {
+				return err
+			}
+			result = append(result, monitor)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// RecordRun stores the outcome of a run with the alerts it raised, assigning
+// their IDs. It fails with ErrNotFound when the monitor was deleted meanwhile.
+func (s *Store) RecordRun(id string, status RunStatus, alerts []Alert) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		monitors := tx.Bucket(monitorsBucket)
+		monitor, err := get(monitors, id)
+		if err != nil {
+			return err
+		}
+		monitor.LastRun = &status
+		if status.HistoryID != "" {
+			monitor.LastHistoryID = status.HistoryID
+		}
+		if err := put(monitors, monitor); err != nil {
+			return err
+		}
+		if len(alerts) == 0 {
+			return nil
+		}
+
+		all := tx.Bucket(alertsBucket)
+		monitorAlerts, err := all.CreateBucketIfNotExists([]byte(id))
+		if err != nil {
+			return err
+		}
+		for i := range alerts {
+			seq, err := all.NextSequence()
+			if err != nil {
+				return err
+			}
+			alerts[i].ID = strconv.FormatUint(seq, 10)
+			encoded, err := json.Marshal(alerts[i])
+			if err != nil {
+				return err
+			}
+			if err := monitorAlerts.Put(storage.SeqKey(seq), encoded); err != nil {
+				return err
+			}
+		}
+		return storage.KeepNewest(monitorAlerts, s.maxAlerts)
+	})
+}
+
+// Alerts returns the alerts of a monitor, newest first
+func (s *Store) Alerts(id string, limit int) ([]Alert, error) {
+	result := []Alert{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if _, err := get(tx.Bucket(monitorsBucket), id); err != nil {
+			return err
+		}
+		monitorAlerts := tx.Bucket(alertsBucket).Bucket([]byte(id))
+		if monitorAlerts == nil {
+			return nil
+		}
+
+		cursor := monitorAlerts.Cursor()
+		for key, value := cursor.Last(); key != nil && len(result) < limit; key, value = cursor.Prev() {
+			var alert Alert
+			if err := json.Unmarshal(value, &alert); err != nil {
+				return err
+			}
+			result = append(result, alert)
+		}
+		return nil
+	})
+	return result, err
+}
+
+func get(monitors *bolt.Bucket, id string) (*Monitor, error) {
+	var monitor Monitor
+	found, err := storage.GetJSON(monitors, id, &monitor)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return &monitor, nil
+}
+
+func put(monitors *bolt.Bucket, monitor *Monitor) error {
+	stored := *monitor
+	stored.NextRun = nil
+	return storage.PutJSON(monitors, monitor.ID, stored)
+}
@@ -0,0 +1,117 @@
+package monitor
+
+import (
+	"errors"
+	"lt-app/internal/storage"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testStore(t *testing.T, maxAlerts int) (*Store, string) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := storage.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db, maxAlerts)
+	if err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	}
+	return store, path
+}
+
+func TestStore_CRUD(t *testing.T) {
+	store, _ := testStore(t, 0)
+
+	monitor := &Monitor{URL: "https://example.com", Schedule: "@hourly", NextRun: &time.Time{}}
+	if err := store.Create(monitor); err != nil || monitor.ID != "1" {
+		t.Fatalf("Expected monitor 1, got %q, %v", monitor.ID, err)
+	}
+	store.Create(&Monitor{URL: "https://example.org", Schedule: "@daily"})
+
+	stored, err := store.Get("1")
+	if err != nil || stored.URL != "https://example.com" || stored.NextRun != nil {
+		t.Errorf("Expected the monitor without its next run, got %+v, %v", stored, err)
+	}
+
+	store.RecordRun("1", RunStatus{HistoryID: "7"}, nil)
+	created := stored.CreatedAt
+	if err := store.Update(&Monitor{ID: "1", URL: "https://example.com", Schedule: "@daily", Paused: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, _ = store.Get("1")
+	if stored.Schedule != "@daily" || !stored.Paused || stored.LastRun == nil || stored.LastRun.HistoryID != "7" || !stored.CreatedAt.Equal(created) {
+		t.Errorf("Expected the settings to change and the last run to be kept, got %+v", stored)
+	}
+
+	if err := store.Delete("1"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	monitors, _ := store.List()
+	if len(monitors) != 1 || monitors[0].ID != "2" {
+		t.Errorf("Expected only monitor 2 to remain, got %+v", monitors)
+	}
+
+	// "02" would find monitor 2 but keep its alerts under another ID
+	for _, id := range []string{"1", "abc", "02"} {
+		if _, err := store.Get(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound from Get, got %v", id, err)
+		}
+		if err := store.Update(&Monitor{ID: id}); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound from Update, got %v", id, err)
+		}
+		if err := store.Delete(id); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound from Delete, got %v", id, err)
+		}
+		if err := store.RecordRun(id, RunStatus{}, nil); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound from RecordRun, got %v", id, err)
+		}
+		if _, err := store.Alerts(id, 10); !errors.Is(err, ErrNotFound) {
+			t.Errorf("%s: expected ErrNotFound from Alerts, got %v", id, err)
+		}
+	}
+}
+
+func TestStore_Alerts(t *testing.T) {
+	store, _ := testStore(t, 3)
+	store.Create(&Monitor{URL: "https://example.com", Schedule: "@hourly"})
+	store.Create(&Monitor{URL: "https://example.org", Schedule: "@hourly"})
+
+	for _, kind := range []string{AlertPageError, AlertTitleChanged, AlertBrokenLinks} {
+		alerts := []Alert{{Kind: kind}}
+		if err := store.RecordRun("1", RunStatus{Alerts: 1}, alerts); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	store.RecordRun("2", RunStatus{}, []Alert{{Kind: AlertTLSExpiring}})
+	store.RecordRun("1", RunStatus{}, []Alert{{Kind: AlertTLSExpiring}})
+
+	alerts, err := store.Alerts("1", 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	var ids []string
+	for _, alert := range alerts {
+		ids = append(ids, alert.ID+":"+alert.Kind)
+	}
+	// The oldest alert of monitor 1 was removed, IDs are shared by every monitor
+	expected := "5:tls-expiring 3:broken-links 2:title-changed"
+	if got := strings.Join(ids, " "); got != expected {
+		t.Errorf("Expected alerts %s, got %s", expected, got)
+	}
+	if alerts, _ := store.Alerts("1", 1); len(alerts) != 1 || alerts[0].ID != "5" {
+		t.Errorf("Expected the newest alert, got %+v", alerts)
+	}
+
+	store.Delete("1")
+	if _, err := store.Alerts("1", 10); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the alerts to be deleted with the monitor, got %v", err)
+	}
+	if alerts, _ := store.Alerts("2", 10); len(alerts) != 1 {
+		t.Errorf("Expected the alerts of other monitors to be kept, got %+v", alerts)
+	}
+}
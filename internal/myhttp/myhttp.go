@@ -1,6 +1,9 @@
 package myhttp
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"lt-app/internal/constants"
 	"net/http"
 	"time"
@@ -9,25 +12,52 @@ import (
 )
 
 type HTTPClient interface {
-	Get(url string) (*resty.Response, error)
+	Get(ctx context.Context, url string) (*resty.Response, error)
+	GetWithTiming(ctx context.Context, url string) (*resty.Response, *Timing, error)
+	GetPage(ctx context.Context, url string) (*resty.Response, *Timing, *TLSInfo, error)
 }
 
 type RestyClient struct {
 	client *resty.Client
+	// Requests the analyzed page without verifying its certificate, see GetPage
+	pageClient *resty.Client
+	// Root CAs of the transport, the system roots when nil
+	rootCAs *x509.CertPool
 }
 
 func NewRestyClient() *RestyClient {
+	c := &RestyClient{client: newClient(), pageClient: newClient()}
+	c.SetTransport(c.client.GetClient().Transport)
+	return c
+}
+
+func newClient() *resty.Client {
 	client := resty.New().SetTimeout(constants.REQUEST_TIMEOUT_SECONDS * time.Second)
 	client.SetDoNotParseResponse(true)
 	client.SetContentLength(true)
-	return &RestyClient{client: client}
+	return client
 }
 
-func (c *RestyClient) Get(url string) (*resty.Response, error) {
-	resp, err := c.client.R().Get(url)
+func (c *RestyClient) Get(ctx context.Context, url string) (*resty.Response, error) {
+	resp, err := c.client.R().SetContext(ctx).Get(url)
 	return resp, err
 }
 
+// SetTransport sets the transport of every request. The page is requested with a copy
+// that skips certificate verification, when the transport is an *http.Transport.
 func (r *RestyClient) SetTransport(transport http.RoundTripper) {
 	r.client.SetTransport(transport)
+	r.rootCAs = nil
+
+	pageTransport := transport
+	if httpTransport, ok := transport.(*http.Transport); ok {
+		clone := httpTransport.Clone()
+		if clone.TLSClientConfig == nil {
+			clone.TLSClientConfig = &tls.Config{}
+		}
+		r.rootCAs = clone.TLSClientConfig.RootCAs
+		clone.TLSClientConfig.InsecureSkipVerify = true
+		pageTransport = clone
+	}
+	r.pageClient.SetTransport(pageTransport)
 }
@@ -1,6 +1,7 @@
 package myhttp
 
 import (
+	"context"
 	"net/http"
 	"testing"
 	"time"
@@ -36,7 +37,7 @@ func TestRestyClient_Get(t *testing.T) {
 	client.client.SetTransport(httpmock.DefaultTransport)
 
 	// Test successful response
-	resp, err := client.Get("http://example.com/success")
+	resp, err := client.Get(context.Background(), "http://example.com/success")
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
@@ -48,7 +49,7 @@ func TestRestyClient_Get(t *testing.T) {
 	httpmock.RegisterResponder("GET", "http://example.com/error",
 		httpmock.NewStringResponder(http.StatusNotFound, "Not Found"))
 
-	resp, err = client.Get("http://example.com/error")
+	resp, err = client.Get(context.Background(), "http://example.com/error")
 
 	if err != nil {
 		t.Errorf("Did not expect an error, got %v", err)
@@ -59,7 +60,7 @@ func TestRestyClient_Get(t *testing.T) {
 	}
 
 	//Test a bad url.
-	_, err = client.Get("bad url")
+	_, err = client.Get(context.Background(), "bad url")
 	if err == nil {
 		t.Errorf("Expected error, got nil")
 	}
@@ -0,0 +1,143 @@
+package myhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Timing breaks a request down into its phases, in milliseconds. When a request is
+// redirected the phases describe the last request of the chain, while Total covers all.
+type Timing struct {
+	StartedAt    time.Time `json:"startedAt"`
+	DNS          float64   `json:"dnsMs"`
+	Connect      float64   `json:"connectMs"`
+	TLSHandshake float64   `json:"tlsHandshakeMs"`
+	// Time between sending the request and receiving the first byte of the response
+	TTFB     float64 `json:"ttfbMs"`
+	Transfer float64 `json:"transferMs"`
+	Total    float64 `json:"totalMs"`
+	// An idle keep-alive connection was used, so there was no DNS, connect or TLS phase
+	ReusedConnection bool `json:"reusedConnection"`
+}
+
+// GetWithTiming sends a GET request and records its phases with httptrace. The transfer
+// phase ends when the response body is read to the end or closed, so the timing is only
+// complete once the caller is done with the body.
+func (c *RestyClient) GetWithTiming(ctx context.Context, url string) (*resty.Response, *Timing, error) {
+	return getWithTiming(ctx, c.client, url)
+}
+
+func getWithTiming(ctx context.Context, client *resty.Client, url string) (*resty.Response, *Timing, error) {
+	tracer := newTracer()
+
+	resp, err := client.R().
+		SetContext(httptrace.WithClientTrace(ctx, tracer.clientTrace())).
+		Get(url)
+
+	if err != nil || resp == nil || resp.RawResponse == nil {
+		tracer.finish()
+		return resp, tracer.timing, err
+	}
+
+	resp.RawResponse.Body = &timedBody{ReadCloser: resp.RawResponse.Body, onDone: tracer.finish}
+	return resp, tracer.timing, nil
+}
+
+// tracer collects the timestamps of the httptrace hooks. The hooks can be called
+// from the goroutines of the transport, hence the mutex.
+type tracer struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	connectStart time.Time
+	tlsStart     time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	timing       *Timing
+}
+
+func newTracer() *tracer {
+	now := time.Now()
+	return &tracer{start: now, timing: &Timing{StartedAt: now}}
+}
+
+func (t *tracer) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.record(func(now time.Time) { t.dnsStart = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.record(func(now time.Time) { t.timing.DNS = milliseconds(now.Sub(t.dnsStart)) })
+		},
+		ConnectStart: func(string, string) {
+			t.record(func(now time.Time) { t.connectStart = now })
+		},
+		ConnectDone: func(string, string, error) {
+			t.record(func(now time.Time) { t.timing.Connect = milliseconds(now.Sub(t.connectStart)) })
+		},
+		TLSHandshakeStart: func() {
+			t.record(func(now time.Time) { t.tlsStart = now })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.record(func(now time.Time) { t.timing.TLSHandshake = milliseconds(now.Sub(t.tlsStart)) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.record(func(time.Time) { t.timing.ReusedConnection = info.Reused })
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			t.record(func(now time.Time) { t.wroteRequest = now })
+		},
+		GotFirstResponseByte: func() {
+			t.record(func(now time.Time) {
+				t.firstByte = now
+				t.timing.TTFB = milliseconds(now.Sub(t.wroteRequest))
+			})
+		},
+	}
+}
+
+func (t *tracer) record(update func(now time.Time)) {
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	update(now)
+}
+
+func (t *tracer) finish() {
+	t.record(func(now time.Time) {
+		if !t.firstByte.IsZero() {
+			t.timing.Transfer = milliseconds(now.Sub(t.firstByte))
+		}
+		t.timing.Total = milliseconds(now.Sub(t.start))
+	})
+}
+
+// timedBody calls onDone once, when the body has been read to the end or closed
+type timedBody struct {
+	io.ReadCloser
+	once   sync.Once
+	onDone func()
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.onDone)
+	}
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.once.Do(b.onDone)
+	return b.ReadCloser.Close()
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
@@ -0,0 +1,69 @@
+package myhttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRestyClient_GetWithTiming(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+
+	client := NewRestyClient()
+	client.SetTransport(srv.Client().Transport)
+
+	resp, timing, err := client.GetWithTiming(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	body, _ := io.ReadAll(resp.RawBody())
+	resp.RawBody().Close()
+
+	if string(body) != "OK" {
+		t.Errorf("Expected body 'OK', got %q", body)
+	}
+	if timing.TLSHandshake <= 0 || timing.Connect <= 0 {
+		t.Errorf("Expected connect and TLS handshake phases, got %+v", timing)
+	}
+	if timing.TTFB < 20 {
+		t.Errorf("Expected time to first byte of at least 20ms, got %v", timing.TTFB)
+	}
+	if timing.Transfer < 20 {
+		t.Errorf("Expected transfer of at least 20ms, got %v", timing.Transfer)
+	}
+	if timing.Total < timing.TTFB+timing.Transfer {
+		t.Errorf("Expected total to cover every phase, got %+v", timing)
+	}
+
+	// The second request reuses the keep-alive connection
+	resp, timing, err = client.GetWithTiming(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	resp.RawBody().Close()
+
+	if !timing.ReusedConnection || timing.TLSHandshake != 0 {
+		t.Errorf("Expected a reused connection without handshake, got %+v", timing)
+	}
+}
+
+func TestRestyClient_GetWithTiming_Error(t *testing.T) {
+	_, timing, err := NewRestyClient().GetWithTiming(context.Background(), "http://127.0.0.1:0")
+	if err == nil {
+		t.Errorf("Expected an error")
+	}
+	if timing == nil || timing.Total <= 0 {
+		t.Errorf("Expected the total time of the failed request, got %+v", timing)
+	}
+}
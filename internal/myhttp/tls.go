@@ -0,0 +1,157 @@
+package myhttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"lt-app/internal/constants"
+	"lt-app/internal/findings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+type CertificateInfo struct {
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	SANs      []string  `json:"sans"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	IsCA      bool      `json:"isCA"`
+}
+
+type TLSInfo struct {
+	Host        string `json:"host"`
+	Version     string `json:"version"`
+	CipherSuite string `json:"cipherSuite"`
+	// Certificates as sent by the server, starting with the leaf certificate
+	Chain         []CertificateInfo `json:"chain"`
+	ExpiresAt     time.Time         `json:"expiresAt"`
+	DaysRemaining int               `json:"daysRemaining"`
+	Expired       bool              `json:"expired"`
+	HostnameMatch bool              `json:"hostnameMatch"`
+	SelfSigned    bool              `json:"selfSigned"`
+	// The chain verifies against the root CAs of the HTTP client
+	Trusted           bool               `json:"trusted"`
+	VerificationError string             `json:"verificationError,omitempty"`
+	Findings          []findings.Finding `json:"findings"`
+}
+
+// GetPage requests the page to analyze like GetWithTiming, except that certificate
+// problems do not fail the request. The TLS connection of the final response is
+// described instead, with its chain verified against the root CAs of the transport,
+// so that expired, untrusted or mismatched certificates are reported as findings.
+// TLSInfo is nil when the page was served over plain HTTP.
+func (c *RestyClient) GetPage(ctx context.Context, url string) (*resty.Response, *Timing, *TLSInfo, error) {
+	resp, timing, err := getWithTiming(ctx, c.pageClient, url)
+	if err != nil || resp == nil || resp.RawResponse == nil || resp.RawResponse.TLS == nil {
+		return resp, timing, nil, err
+	}
+
+	host := resp.RawResponse.Request.URL.Hostname()
+	return resp, timing, buildTLSInfo(host, *resp.RawResponse.TLS, c.rootCAs, time.Now()), nil
+}
+
+// buildTLSInfo describes the connection and verifies the chain against rootCAs,
+// or the system roots when rootCAs is nil
+func buildTLSInfo(host string, state tls.ConnectionState, rootCAs *x509.CertPool, now time.Time) *TLSInfo {
+	info := &TLSInfo{
+		Host:        host,
+		Version:     tls.VersionName(state.Version),
+		CipherSuite: tls.CipherSuiteName(state.CipherSuite),
+		Chain:       []CertificateInfo{},
+		Findings:    []findings.Finding{},
+	}
+
+	if len(state.PeerCertificates) == 0 {
+		info.VerificationError = "The server did not send a certificate"
+		info.addFinding("tls-untrusted-chain", findings.SeverityError, info.VerificationError)
+		return info
+	}
+
+	intermediates := x509.NewCertPool()
+	for i, cert := range state.PeerCertificates {
+		info.Chain = append(info.Chain, describeCertificate(cert))
+		if i > 0 {
+			intermediates.AddCert(cert)
+		}
+	}
+
+	leaf := state.PeerCertificates[0]
+	info.ExpiresAt = leaf.NotAfter
+	info.DaysRemaining = int(leaf.NotAfter.Sub(now).Hours() / 24)
+	info.Expired = now.After(leaf.NotAfter)
+	info.HostnameMatch = leaf.VerifyHostname(host) == nil
+
+	last := state.PeerCertificates[len(state.PeerCertificates)-1]
+	info.SelfSigned = last.Subject.String() == last.Issuer.String() && last.CheckSignatureFrom(last) == nil
+
+	// The hostname is checked separately so that a mismatch is not reported as an untrusted chain
+	_, verifyErr := leaf.Verify(x509.VerifyOptions{
+		Roots:         rootCAs,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	info.Trusted = verifyErr == nil
+	if verifyErr != nil {
+		info.VerificationError = verifyErr.Error()
+	}
+
+	switch {
+	case info.Expired:
+		info.addFinding("tls-certificate-expired", findings.SeverityError,
+			fmt.Sprintf("The certificate expired on %s", leaf.NotAfter.Format(time.DateOnly)))
+	case info.DaysRemaining < constants.TLS_EXPIRY_WARNING_DAYS:
+		info.addFinding("tls-certificate-expiring", findings.SeverityWarning,
+			fmt.Sprintf("The certificate expires in %d days", info.DaysRemaining))
+	}
+
+	if !info.HostnameMatch {
+		info.addFinding("tls-hostname-mismatch", findings.SeverityError,
+			fmt.Sprintf("The certificate is not valid for %s", host))
+	}
+
+	if !info.Trusted {
+		if info.SelfSigned {
+			info.addFinding("tls-self-signed", findings.SeverityError,
+				"The certificate chain ends in a self-signed certificate that is not trusted")
+		} else if !info.Expired {
+			info.addFinding("tls-untrusted-chain", findings.SeverityError,
+				"The certificate chain could not be verified: "+info.VerificationError)
+		}
+	}
+
+	if state.Version < tls.VersionTLS12 {
+		info.addFinding("tls-legacy-protocol", findings.SeverityWarning,
+			fmt.Sprintf("The server negotiated the deprecated protocol %s", info.Version))
+	}
+
+	return info
+}
+
+func (info *TLSInfo) addFinding(id string, severity findings.Severity, message string) {
+	info.Findings = append(info.Findings, findings.Finding{
+		ID:       id,
+		Category: findings.CategorySecurity,
+		Severity: severity,
+		Message:  message,
+		Target:   info.Host,
+	})
+}
+
+func describeCertificate(cert *x509.Certificate) CertificateInfo {
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+
+	return CertificateInfo{
+		Subject:   cert.Subject.String(),
+		Issuer:    cert.Issuer.String(),
+		SANs:      sans,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		IsCA:      cert.IsCA,
+	}
+}
@@ -0,0 +1,170 @@
+package myhttp
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func hasFinding(info *TLSInfo, id string) bool {
+	for _, finding := range info.Findings {
+		if finding.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// expiredServer serves over TLS with a self-signed certificate for 127.0.0.1 that expired yesterday
+func expiredServer(t *testing.T) *httptest.Server {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate a key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "expired.test"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().AddDate(0, -1, 0),
+		NotAfter:              time.Now().AddDate(0, 0, -1),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Failed to create a certificate: %v", err)
+	}
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}}}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestRestyClient_GetPage(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	}))
+	defer srv.Close()
+	expired := expiredServer(t)
+
+	tests := []struct {
+		name          string
+		url           string
+		trustServer   bool
+		trusted       bool
+		hostnameMatch bool
+		expired       bool
+		findingIDs    []string
+	}{
+		{
+			name:          "Trusted certificate",
+			url:           srv.URL,
+			trustServer:   true,
+			trusted:       true,
+			hostnameMatch: true,
+		},
+		{
+			name:          "Hostname mismatch",
+			url:           strings.Replace(srv.URL, "127.0.0.1", "localhost", 1),
+			trustServer:   true,
+			trusted:       true,
+			hostnameMatch: false,
+			findingIDs:    []string{"tls-hostname-mismatch"},
+		},
+		{
+			name:          "Self-signed certificate",
+			url:           srv.URL,
+			trustServer:   false,
+			trusted:       false,
+			hostnameMatch: true,
+			findingIDs:    []string{"tls-self-signed"},
+		},
+		{
+			name:          "Expired certificate",
+			url:           expired.URL,
+			trustServer:   false,
+			trusted:       false,
+			hostnameMatch: true,
+			expired:       true,
+			findingIDs:    []string{"tls-certificate-expired", "tls-self-signed"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := NewRestyClient()
+			if test.trustServer {
+				// The test server's client transport trusts its certificate
+				client.SetTransport(srv.Client().Transport)
+			}
+
+			// Certificate problems are reported rather than failing the request
+			resp, timing, info, err := client.GetPage(context.Background(), test.url)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			body, _ := io.ReadAll(resp.RawBody())
+			resp.RawBody().Close()
+			if string(body) != "OK" || timing == nil || timing.TLSHandshake <= 0 {
+				t.Errorf("Expected the page with its timing, got %q, %+v", body, timing)
+			}
+
+			if info.Trusted != test.trusted || info.HostnameMatch != test.hostnameMatch || info.Expired != test.expired {
+				t.Errorf("Expected trusted %v, hostname match %v and expired %v, got %+v", test.trusted, test.hostnameMatch, test.expired, info)
+			}
+			if !info.SelfSigned {
+				t.Errorf("Expected the test server certificate to be reported as self-signed")
+			}
+			if info.Version == "" || info.CipherSuite == "" || len(info.Chain) == 0 || len(info.Chain[0].SANs) == 0 {
+				t.Errorf("Expected connection and certificate details, got %+v", info)
+			}
+			if len(info.Findings) != len(test.findingIDs) {
+				t.Errorf("Expected findings %v, got %v", test.findingIDs, info.Findings)
+			}
+			for _, id := range test.findingIDs {
+				if !hasFinding(info, id) {
+					t.Errorf("Expected finding %q, got %v", id, info.Findings)
+				}
+			}
+		})
+	}
+}
+
+func TestRestyClient_GetPage_HTTP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	resp, _, info, err := NewRestyClient().GetPage(context.Background(), srv.URL)
+	if err != nil || info != nil {
+		t.Fatalf("Expected no TLS details for a plain HTTP page, got %+v, %v", info, err)
+	}
+	resp.RawBody().Close()
+}
+
+func TestRestyClient_GetWithTiming_Untrusted(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	// Only the page skips verification, links and assets are still verified
+	if _, _, err := NewRestyClient().GetWithTiming(context.Background(), srv.URL); err == nil {
+		t.Errorf("Expected an error for an untrusted certificate")
+	}
+}
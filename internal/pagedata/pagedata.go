@@ -2,8 +2,13 @@ package pagedata
 
 import (
 	"log/slog"
+	"lt-app/internal/doctype"
+	"lt-app/internal/forms"
+	"lt-app/internal/linkquality"
 	"lt-app/internal/utils"
 	"lt-app/internal/webfetch"
+	"net/http"
+	"net/url"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
@@ -11,19 +16,43 @@ import (
 
 type PageData struct {
 	Doc           *goquery.Document `json:"-"` // Do not serialize this field
-	DoctypeStr    string            `json:"doctypeStr"`
+	Doctype       *doctype.Doctype  `json:"doctype"`
 	WebPageUrl    string            `json:"webPageUrl"`
 	WebPageOrigin string            `json:"webPageOrigin"`
+	// URL relative references on the page resolve against, honouring <base href>
+	BaseURL string `json:"baseUrl"`
+	// Response headers the page was served with
+	Headers http.Header `json:"-"`
+	// Raw HTML source of the page
+	HTML string `json:"-"`
 }
 
 type Links struct {
 	Internal int
 	External int
 	Total    int
+	// Every <a href> of the page with its text, rel, target and title
+	Details []linkquality.Link
+}
+
+// Heading is a heading of the page with its text
+type Heading struct {
+	Tag  string `json:"tag"`
+	Text string `json:"text"`
+}
+
+// FragmentLink is a link that points to a fragment (#section) of a document
+type FragmentLink struct {
+	Href     string `json:"href"`
+	PageURL  string `json:"pageUrl"`
+	Fragment string `json:"fragment"`
+	SamePage bool   `json:"samePage"`
+	// Only known for same page links. Other pages have to be fetched first.
+	TargetFound bool `json:"-"`
 }
 
 type IPageDataBuilder interface {
-	Build(webPageUrl string, bodyString string, RLogger *slog.Logger) (*PageData, *webfetch.ErrorResponse)
+	Build(webPageUrl string, bodyString string, headers http.Header, RLogger *slog.Logger) (*PageData, *webfetch.ErrorResponse)
 }
 
 type IPageData interface {
@@ -36,26 +65,31 @@ type IPageData interface {
 
 type PageDataBuilder struct{}
 
-func (pdb *PageDataBuilder) Build(webPageUrl string, bodyString string, RLogger *slog.Logger) (*PageData, *webfetch.ErrorResponse) {
+func (pdb *PageDataBuilder) Build(webPageUrl string, bodyString string, headers http.Header, RLogger *slog.Logger) (*PageData, *webfetch.ErrorResponse) {
 	/**
 	Go query does not validate html. So no error is returned if the html is invalid.
-	Ideally string should be validated for html before parsing it.
+	The source is run through the validator separately and its issues are reported
+	alongside the stats instead of rejecting the page.
 	*/
 	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(bodyString))
 
-	// if err != nil {
-	// 	RLogger.Error("Error parsing HTML", "HTML parsing error", err)
-	// 	return nil, webfetch.BuildErrorResponse(http.StatusBadRequest, "Web page url contains invalid html content")
-	// }
-
-	docTypeStr := utils.ExtractDoctypeFromHtmlSource(bodyString)
 	origin, _ := utils.GetOriginFromURL(webPageUrl)
 
+	baseUrl := webPageUrl
+	if href, exists := doc.Find("base[href]").First().Attr("href"); exists {
+		if resolved, err := utils.ResolveURL(webPageUrl, href); err == nil {
+			baseUrl = resolved
+		}
+	}
+
 	return &PageData{
 		Doc:           doc,
-		DoctypeStr:    docTypeStr,
+		Doctype:       doctype.Parse(bodyString),
 		WebPageUrl:    webPageUrl,
 		WebPageOrigin: origin,
+		BaseURL:       baseUrl,
+		Headers:       headers,
+		HTML:          bodyString,
 	}, nil
 }
 
@@ -78,42 +112,55 @@ func (pd *PageData) GetHeadings() map[string]int {
 	return headings
 }
 
+// HeadingOutline returns the headings of the page in document order, with
+// whitespace in their text collapsed
+func HeadingOutline(doc *goquery.Document) []Heading {
+	outline := []Heading{}
+	doc.Find("h1, h2, h3, h4, h5, h6").Each(func(_ int, s *goquery.Selection) {
+		outline = append(outline, Heading{Tag: goquery.NodeName(s), Text: strings.Join(strings.Fields(s.Text()), " ")})
+	})
+	return outline
+}
+
 func (pd *PageData) GetTitle() string {
 	return pd.Doc.Find("title").Text()
 }
 
-// Function to check if the page contains a login form
+// MetaDescription returns the content of the first description meta tag, whose
+// name is matched case insensitively like browsers and search engines do
+func MetaDescription(doc *goquery.Document) string {
+	description := ""
+	doc.Find("meta[name]").EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		if !strings.EqualFold(strings.TrimSpace(s.AttrOr("name", "")), "description") {
+			return true
+		}
+		description = strings.TrimSpace(s.AttrOr("content", ""))
+		return false
+	})
+	return description
+}
+
+// Function to check if the page contains a login form or a single sign-on entry point.
+// Forms are classified with the keywords of every language.
 func (pd *PageData) ContainsLoginForm() bool {
-	doc := pd.Doc
-	// Look for form elements with input fields for username and password
-	hasPassword := doc.Find("input[type='password']").Length() > 0
-	// hasUsername := doc.Find("input[type='text'], input[type='email'], input[name='username'], input[name='email']").Length() > 0
-	// hasSubmit := doc.Find("input[type='submit'], button[type='submit']").Length() > 0
-
-	// Check for a button with text "signin" or "sign in" (case-insensitive)
-	hasSigninButton := doc.Find("button").FilterFunction(func(_ int, s *goquery.Selection) bool {
-		text := strings.ToLower(s.Text())
-		return strings.Contains(text, "sign in") || strings.Contains(text, "signin") || strings.Contains(text, "login") || strings.Contains(text, "log in")
-	}).Length() > 0
-
-	return hasPassword && hasSigninButton
+	return forms.Detect(pd.Doc).HasLogin()
 }
 
 func (pd *PageData) GetHtmlVersion() string {
-	if pd.DoctypeStr == "html" {
-		return "html5"
-	}
-	return pd.DoctypeStr
+	return string(pd.Doctype.Version)
 }
 
 func (pd *PageData) GetLinkStats() (*Links, []string) {
 	// Store external links in slice to check for accessibility
 	var validLinks []string
-	links := &Links{}
+	links := &Links{Details: []linkquality.Link{}}
 
 	// Count internal and external links
 	pd.Doc.Find("a").Each(func(i int, s *goquery.Selection) {
 		href, exists := s.Attr("href")
+		if exists {
+			links.Details = append(links.Details, linkquality.NewLink(s))
+		}
 		if exists && len(href) > 1 && href[0] != '#' && href[0] != '?' {
 			if utils.IsInternalLink(href) {
 				links.Internal++
@@ -128,3 +175,55 @@ func (pd *PageData) GetLinkStats() (*Links, []string) {
 	links.Total = links.Internal + links.External
 	return links, validLinks
 }
+
+// FragmentLinks returns the links with a fragment that point to the page or to
+// another page of the same origin. Fragments of same page links are checked
+// against the ids and anchor names of the document right away.
+func FragmentLinks(doc *goquery.Document, webPageUrl string, baseUrl string) []FragmentLink {
+	fragmentLinks := []FragmentLink{}
+	pageUrl := utils.StripFragment(webPageUrl)
+	origin, _ := utils.GetOriginFromURL(webPageUrl)
+	var anchorTargets map[string]bool
+
+	doc.Find("a[href], area[href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if !strings.Contains(href, "#") {
+			return
+		}
+
+		resolved, err := utils.ResolveURL(baseUrl, href)
+		if err != nil {
+			return
+		}
+
+		parsed, err := url.Parse(resolved)
+		if err != nil {
+			return
+		}
+
+		// An empty fragment and "#top" scroll to the top of the page and always work
+		fragment := parsed.Fragment
+		if fragment == "" || strings.EqualFold(fragment, "top") {
+			return
+		}
+
+		targetPage := utils.StripFragment(resolved)
+		samePage := targetPage == pageUrl
+
+		if !samePage && parsed.Scheme+"://"+parsed.Host != origin {
+			return
+		}
+
+		link := FragmentLink{Href: href, PageURL: targetPage, Fragment: fragment, SamePage: samePage}
+		if samePage {
+			if anchorTargets == nil {
+				anchorTargets = utils.CollectAnchorTargets(doc)
+			}
+			link.TargetFound = anchorTargets[fragment]
+		}
+
+		fragmentLinks = append(fragmentLinks, link)
+	})
+
+	return fragmentLinks
+}
@@ -2,9 +2,11 @@ package pagedata
 
 import (
 	"log/slog"
+	"lt-app/internal/doctype"
 	"lt-app/internal/utils"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 )
 
@@ -32,7 +34,7 @@ func TestPageDataBuilder(t *testing.T) {
 	builder := &PageDataBuilder{}
 	expectedOrigin, _ := utils.GetOriginFromURL(expectedPageUrl)
 
-	pageData, _ := builder.Build(expectedPageUrl, htmlContentStr, RLogger)
+	pageData, _ := builder.Build(expectedPageUrl, htmlContentStr, nil, RLogger)
 
 	if pageData.WebPageUrl != expectedPageUrl {
 		t.Errorf("Expected title %q, got %q", expectedPageUrl, pageData.WebPageUrl)
@@ -41,8 +43,12 @@ func TestPageDataBuilder(t *testing.T) {
 	if pageData.WebPageOrigin != expectedOrigin {
 		t.Errorf("Expected doctype %q, got %q", expectedOrigin, pageData.WebPageOrigin)
 	}
-	if pageData.DoctypeStr != expectedDoctype {
-		t.Errorf("Expected doctype %q, got %q", expectedDoctype, pageData.DoctypeStr)
+	if pageData.Doctype.Name != expectedDoctype {
+		t.Errorf("Expected doctype %q, got %q", expectedDoctype, pageData.Doctype.Name)
+	}
+
+	if pageData.Doctype.Mode != doctype.ModeNoQuirks {
+		t.Errorf("Expected document mode %q, got %q", doctype.ModeNoQuirks, pageData.Doctype.Mode)
 	}
 
 	headings := pageData.GetHeadings()
@@ -100,4 +106,93 @@ func TestPageDataBuilder(t *testing.T) {
 	if len(validLinks) != 8 {
 		t.Errorf("Expected 8 valid links, got %d", len(validLinks))
 	}
+
+	// Details also cover fragment and query links that are not checked
+	if len(linkStats.Details) != 10 {
+		t.Errorf("Expected details of 10 links, got %d", len(linkStats.Details))
+	}
+}
+
+func TestFragmentLinks(t *testing.T) {
+	RLogger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	htmlContentStr := `<html><body>
+		<h2 id="intro">Intro</h2><a name="legacy"></a>
+		<a href="#intro">Intro</a>
+		<a href="#legacy">Legacy</a>
+		<a href="#missing">Missing</a>
+		<a href="#">Top</a>
+		<a href="/docs#install">Docs</a>
+		<a href="https://www.example.com/page1#intro">Self</a>
+		<a href="https://other.com/page#section">External</a>
+	</body></html>`
+
+	builder := &PageDataBuilder{}
+	pageData, _ := builder.Build("https://www.example.com/page1", htmlContentStr, nil, RLogger)
+
+	fragmentLinks := FragmentLinks(pageData.Doc, pageData.WebPageUrl, pageData.BaseURL)
+
+	expected := []FragmentLink{
+		{Href: "#intro", PageURL: "https://www.example.com/page1", Fragment: "intro", SamePage: true, TargetFound: true},
+		{Href: "#legacy", PageURL: "https://www.example.com/page1", Fragment: "legacy", SamePage: true, TargetFound: true},
+		{Href: "#missing", PageURL: "https://www.example.com/page1", Fragment: "missing", SamePage: true, TargetFound: false},
+		{Href: "/docs#install", PageURL: "https://www.example.com/docs", Fragment: "install", SamePage: false},
+		{Href: "https://www.example.com/page1#intro", PageURL: "https://www.example.com/page1", Fragment: "intro", SamePage: true, TargetFound: true},
+	}
+
+	if len(fragmentLinks) != len(expected) {
+		t.Fatalf("Expected %d fragment links, got %d: %+v", len(expected), len(fragmentLinks), fragmentLinks)
+	}
+
+	for i, link := range fragmentLinks {
+		if link != expected[i] {
+			t.Errorf("Expected fragment link %+v, got %+v", expected[i], link)
+		}
+	}
+}
+
+func TestMetaDescription(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		expected string
+	}{
+		{"description", `<meta name="description" content=" Kettles and teapots ">`, "Kettles and teapots"},
+		{"case insensitive name", `<meta name="Description" content="Kettles">`, "Kettles"},
+		{"first of several", `<meta name="keywords" content="tea"><meta name="description" content="First"><meta name="description" content="Second">`, "First"},
+		{"missing", `<meta name="keywords" content="tea">`, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pageData, _ := (&PageDataBuilder{}).Build("https://example.com", "<html><head>"+test.html+"</head></html>", nil, slog.Default())
+			if description := MetaDescription(pageData.Doc); description != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, description)
+			}
+		})
+	}
+}
+
+func TestHeadingOutline(t *testing.T) {
+	html := `<html><body><h1>Kettles</h1><p>Intro</p><h2> Electric
+		kettles </h2><div><h3><span>Glass</span> kettles</h3></div><h2></h2></body></html>`
+	pageData, _ := (&PageDataBuilder{}).Build("https://example.com", html, nil, slog.Default())
+
+	expected := []Heading{
+		{Tag: "h1", Text: "Kettles"},
+		{Tag: "h2", Text: "Electric kettles"},
+		{Tag: "h3", Text: "Glass kettles"},
+		{Tag: "h2", Text: ""},
+	}
+	if outline := HeadingOutline(pageData.Doc); !reflect.DeepEqual(outline, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, outline)
+	}
+}
+
+func TestPageDataBuilder_RedirectedURL(t *testing.T) {
+	// The final URL is not checked by IsValidURL, as the requested one was
+	pageData, _ := (&PageDataBuilder{}).Build("https://shop.example.com:8443/caf%C3%A9/", "<html></html>", nil, slog.Default())
+
+	if pageData.WebPageOrigin != "https://shop.example.com:8443" {
+		t.Errorf("Expected the origin of the final URL, got %q", pageData.WebPageOrigin)
+	}
 }
@@ -0,0 +1,450 @@
+package pagestats
+
+import (
+	"context"
+	"fmt"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/constants"
+	"lt-app/internal/content"
+	"lt-app/internal/doctype"
+	"lt-app/internal/extract"
+	"lt-app/internal/findings"
+	"lt-app/internal/forms"
+	"lt-app/internal/htmlvalidate"
+	"lt-app/internal/language"
+	"lt-app/internal/linkquality"
+	"lt-app/internal/myhttp"
+	"lt-app/internal/pagedata"
+	"lt-app/internal/resources"
+	"lt-app/internal/rules"
+	"lt-app/internal/score"
+	"lt-app/internal/scripts"
+	"lt-app/internal/security"
+	"lt-app/internal/techdetect"
+	"lt-app/internal/thirdparty"
+	"lt-app/internal/utils"
+	"lt-app/internal/webfetch"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Names of the built-in analyzers
+const (
+	AnalyzerHTML            = "html"
+	AnalyzerTitle           = "title"
+	AnalyzerHeadings        = "headings"
+	AnalyzerPage            = "page"
+	AnalyzerLinks           = "links"
+	AnalyzerLinkQuality     = "link-quality"
+	AnalyzerLinkChecks      = "link-checks"
+	AnalyzerAnchors         = "anchors"
+	AnalyzerResources       = "resources"
+	AnalyzerThirdParties    = "third-parties"
+	AnalyzerTechnologies    = "technologies"
+	AnalyzerContent         = "content"
+	AnalyzerLanguage        = "language"
+	AnalyzerMixedContent    = "mixed-content"
+	AnalyzerSecurityHeaders = "security-headers"
+	AnalyzerTLS             = "tls"
+	AnalyzerTiming          = "timing"
+	AnalyzerForms           = "forms"
+	AnalyzerRules           = "rules"
+	AnalyzerExtract         = "extract"
+	AnalyzerScripts         = "scripts"
+	AnalyzerScore           = "score"
+)
+
+type HTMLSection struct {
+	HTMLVersion string               `json:"htmlVersion"`
+	Doctype     *doctype.Doctype     `json:"doctype"`
+	Validation  *htmlvalidate.Report `json:"validation"`
+}
+
+type TitleSection struct {
+	Title string `json:"title"`
+}
+
+type HeadingsSection struct {
+	Headings map[string]int `json:"headings"`
+}
+
+type PageSection struct {
+	// Size of the HTML as served, in bytes
+	PageSize    int    `json:"pageSize"`
+	Description string `json:"description"`
+	// Headings in document order
+	Outline []pagedata.Heading `json:"outline"`
+}
+
+type LinksSection struct {
+	InternalLinks int `json:"internalLinks"`
+	ExternalLinks int `json:"externalLinks"`
+	TotalLinks    int `json:"totalLinks"`
+	// Unique URLs of the links, in page order
+	Links []string `json:"links"`
+
+	validLinks []string
+	details    []linkquality.Link
+}
+
+type LinkQualitySection struct {
+	LinkQuality *linkquality.Report `json:"linkQuality"`
+}
+
+// LinkChecksSection holds the result of requesting every link and asset of the page
+type LinkChecksSection struct {
+	InaccessibleLinks int      `json:"inaccessibleLinks"`
+	BrokenLinks       []string `json:"brokenLinks"`
+	// Result and timing of every link and asset request
+	LinkChecks []webfetch.LinkResult `json:"linkChecks"`
+
+	brokenAssets    []string
+	uncheckedAssets int
+}
+
+type AnchorsSection struct {
+	Anchors *AnchorReport `json:"anchors"`
+}
+
+type ResourcesSection struct {
+	Resources *resources.Report `json:"resources"`
+}
+
+type ThirdPartiesSection struct {
+	ThirdParties *thirdparty.Inventory `json:"thirdParties"`
+}
+
+type TechnologiesSection struct {
+	Technologies *techdetect.Report `json:"technologies"`
+}
+
+type ContentSection struct {
+	Content *content.Report `json:"content"`
+}
+
+type LanguageSection struct {
+	Language *language.Report `json:"language"`
+}
+
+type MixedContentSection struct {
+	MixedContent *security.MixedContentReport `json:"mixedContent"`
+}
+
+type SecurityHeadersSection struct {
+	SecurityHeaders *security.HeaderReport `json:"securityHeaders"`
+}
+
+type TLSSection struct {
+	// Only set for HTTPS pages
+	TLS *myhttp.TLSInfo `json:"tls"`
+}
+
+type TimingSection struct {
+	// Timing of the page request
+	Timing *myhttp.Timing `json:"timing"`
+}
+
+type FormsSection struct {
+	HasLoginForm  bool             `json:"hasLoginForm"`
+	FormDetection *forms.Detection `json:"formDetection"`
+	Forms         *forms.Inventory `json:"forms"`
+}
+
+type RulesSection struct {
+	Rules *rules.Report `json:"rules"`
+}
+
+type ExtractSection struct {
+	Extractions []extract.Result `json:"extractions"`
+}
+
+type ScriptsSection struct {
+	Scripts *scripts.Report `json:"scripts"`
+}
+
+type ScoreSection struct {
+	Score *score.Report `json:"score"`
+}
+
+// DefaultRegistry holds the built-in analyzers. Their sections are inline so the
+// stats keep one flat object.
+var DefaultRegistry = analyzer.NewRegistry().Register(
+	analyzer.NewInline(AnalyzerHTML, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (HTMLSection, *webfetch.ErrorResponse) {
+		return HTMLSection{
+			HTMLVersion: in.Page.GetHtmlVersion(),
+			Doctype:     doctype.Parse(in.HTML),
+			Validation:  htmlvalidate.Validate(in.HTML),
+		}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerTitle, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (TitleSection, *webfetch.ErrorResponse) {
+		return TitleSection{Title: in.Page.GetTitle()}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerHeadings, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (HeadingsSection, *webfetch.ErrorResponse) {
+		return HeadingsSection{Headings: in.Page.GetHeadings()}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerPage, []string{analyzer.Document, analyzer.Response}, func(_ context.Context, in *analyzer.Input) (PageSection, *webfetch.ErrorResponse) {
+		section := PageSection{Description: pagedata.MetaDescription(in.Doc), Outline: pagedata.HeadingOutline(in.Doc)}
+		if in.Source != nil {
+			section.PageSize = len(in.Source.Body)
+		}
+		return section, nil
+	}),
+
+	analyzer.NewInline(AnalyzerLinks, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (LinksSection, *webfetch.ErrorResponse) {
+		links, validLinks := in.Page.GetLinkStats()
+		return LinksSection{
+			InternalLinks: links.Internal,
+			ExternalLinks: links.External,
+			TotalLinks:    links.Total,
+			Links:         uniqueUrls(validLinks),
+			validLinks:    validLinks,
+			details:       links.Details,
+		}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerLinkQuality, []string{AnalyzerLinks}, func(_ context.Context, in *analyzer.Input) (LinkQualitySection, *webfetch.ErrorResponse) {
+		links, _ := analyzer.ResultOf[LinksSection](in, AnalyzerLinks)
+		return LinkQualitySection{LinkQuality: linkquality.Check(links.details)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerLinkChecks, []string{analyzer.Document, AnalyzerLinks}, runLinkChecks),
+
+	analyzer.NewInline(AnalyzerAnchors, []string{analyzer.Document}, func(ctx context.Context, in *analyzer.Input) (AnchorsSection, *webfetch.ErrorResponse) {
+		anchors := checkAnchors(ctx, pagedata.FragmentLinks(in.Doc, in.URL, in.BaseURL), in.Fetcher)
+		in.Logger.Info("BrokenAnchors", "brokenAnchors", anchors.Broken, "unverifiedAnchors", anchors.Unverified)
+		return AnchorsSection{Anchors: anchors}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerResources, []string{analyzer.Document, AnalyzerLinkChecks}, func(_ context.Context, in *analyzer.Input) (ResourcesSection, *webfetch.ErrorResponse) {
+		checks, _ := analyzer.ResultOf[LinkChecksSection](in, AnalyzerLinkChecks)
+		return ResourcesSection{Resources: resources.BuildReport(resources.Collect(in.Doc, in.BaseURL), checks.brokenAssets, checks.uncheckedAssets)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerThirdParties, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (ThirdPartiesSection, *webfetch.ErrorResponse) {
+		return ThirdPartiesSection{ThirdParties: thirdPartyDomains(in)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerTechnologies, []string{analyzer.Document, analyzer.Headers}, func(_ context.Context, in *analyzer.Input) (TechnologiesSection, *webfetch.ErrorResponse) {
+		return TechnologiesSection{Technologies: techdetect.DefaultDetector.Detect(techdetect.Input{HTML: in.HTML, Headers: in.Headers, Doc: in.Doc})}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerContent, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (ContentSection, *webfetch.ErrorResponse) {
+		return ContentSection{Content: content.Analyze(in.Doc, in.HTML)}, nil
+	}),
+
+	// The language of the main content is detected
+	analyzer.NewInline(AnalyzerLanguage, []string{analyzer.Document, analyzer.Headers, AnalyzerContent}, func(_ context.Context, in *analyzer.Input) (LanguageSection, *webfetch.ErrorResponse) {
+		contentSection, _ := analyzer.ResultOf[ContentSection](in, AnalyzerContent)
+		return LanguageSection{Language: language.Analyze(in.Doc, in.Headers, contentSection.Content.Text)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerMixedContent, []string{analyzer.Document, analyzer.Headers}, func(_ context.Context, in *analyzer.Input) (MixedContentSection, *webfetch.ErrorResponse) {
+		// Values returns the slice of the headers, which must not be appended to
+		cspPolicies := slices.Concat(in.Headers.Values("Content-Security-Policy"), security.MetaCSPPolicies(in.Doc))
+		pageForms := forms.BuildInventory(in.Doc, in.URL, in.BaseURL).Forms
+		return MixedContentSection{MixedContent: security.CheckMixedContent(in.URL, resources.Collect(in.Doc, in.BaseURL), pageForms, cspPolicies)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerSecurityHeaders, []string{analyzer.Headers}, func(_ context.Context, in *analyzer.Input) (SecurityHeadersSection, *webfetch.ErrorResponse) {
+		return SecurityHeadersSection{SecurityHeaders: security.AuditHeaders(in.URL, in.Headers)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerTLS, []string{analyzer.Response}, func(_ context.Context, in *analyzer.Input) (TLSSection, *webfetch.ErrorResponse) {
+		if in.Source == nil {
+			return TLSSection{}, nil
+		}
+		return TLSSection{TLS: in.Source.TLS}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerTiming, []string{analyzer.Response}, func(_ context.Context, in *analyzer.Input) (TimingSection, *webfetch.ErrorResponse) {
+		if in.Source == nil {
+			return TimingSection{}, nil
+		}
+		return TimingSection{Timing: in.Source.Timing}, nil
+	}),
+
+	// Forms are classified with the keywords of the declared and detected languages
+	analyzer.NewInline(AnalyzerForms, []string{analyzer.Document, AnalyzerLanguage}, func(_ context.Context, in *analyzer.Input) (FormsSection, *webfetch.ErrorResponse) {
+		languageSection, _ := analyzer.ResultOf[LanguageSection](in, AnalyzerLanguage)
+		detection := forms.Detect(in.Doc, languageSection.Language.Languages()...)
+		return FormsSection{
+			HasLoginForm:  detection.HasLogin(),
+			FormDetection: detection,
+			Forms:         forms.BuildInventory(in.Doc, in.URL, in.BaseURL),
+		}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerRules, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (RulesSection, *webfetch.ErrorResponse) {
+		return RulesSection{Rules: rules.DefaultEvaluator.Evaluate(in.Doc)}, nil
+	}),
+
+	analyzer.NewInline(AnalyzerExtract, []string{analyzer.Document}, func(_ context.Context, in *analyzer.Input) (ExtractSection, *webfetch.ErrorResponse) {
+		extractions, err := extract.Extract(in.Doc, in.Extractors)
+		if err != nil {
+			return ExtractSection{}, webfetch.BuildErrorResponse(http.StatusBadRequest, err.Error())
+		}
+		return ExtractSection{Extractions: extractions}, nil
+	}),
+
+	// Scripts get a read-only view of the page
+	analyzer.NewInline(AnalyzerScripts, []string{analyzer.Document, analyzer.Headers, AnalyzerLinks}, func(ctx context.Context, in *analyzer.Input) (ScriptsSection, *webfetch.ErrorResponse) {
+		links, _ := analyzer.ResultOf[LinksSection](in, AnalyzerLinks)
+		return ScriptsSection{Scripts: scripts.DefaultRunner.Run(ctx, scripts.Page{
+			URL:         in.URL,
+			Title:       in.Page.GetTitle(),
+			HTMLVersion: in.Page.GetHtmlVersion(),
+			Headers:     in.Headers,
+			Headings:    in.Page.GetHeadings(),
+			Links:       links.details,
+			Doc:         in.Doc,
+		})}, nil
+	}),
+
+	// The score depends on every analyzer that reports problems, so it always covers every category
+	analyzer.NewInline(AnalyzerScore, scoredAnalyzers, func(_ context.Context, in *analyzer.Input) (ScoreSection, *webfetch.ErrorResponse) {
+		return ScoreSection{Score: score.DefaultScorer.Score(collectFindings(in))}, nil
+	}),
+)
+
+var scoredAnalyzers = []string{
+	AnalyzerHTML, AnalyzerTitle, AnalyzerHeadings, AnalyzerLinkQuality, AnalyzerLinkChecks, AnalyzerAnchors,
+	AnalyzerResources, AnalyzerLanguage, AnalyzerMixedContent, AnalyzerSecurityHeaders, AnalyzerTLS,
+	AnalyzerForms, AnalyzerRules, AnalyzerScripts,
+}
+
+// collectFindings gathers the findings of every analyzer, and turns the problems
+// that analyzers report as counts or lists, such as broken links, into findings
+func collectFindings(in *analyzer.Input) []findings.Finding {
+	collected := []findings.Finding{}
+	add := func(reported ...findings.Finding) {
+		collected = append(collected, reported...)
+	}
+
+	if html, ok := analyzer.ResultOf[HTMLSection](in, AnalyzerHTML); ok {
+		if html.Doctype != nil && html.Doctype.Mode == doctype.ModeQuirks {
+			add(findings.Finding{ID: "doctype-quirks-mode", Category: findings.CategoryBestPractices, Severity: findings.SeverityWarning, Message: "The page is rendered in quirks mode"})
+		}
+		if html.Validation != nil {
+			for _, issue := range html.Validation.Issues {
+				severity, _ := findings.ParseSeverity(string(issue.Severity))
+				add(findings.Finding{ID: "html-" + string(issue.Type), Category: findings.CategoryBestPractices, Severity: severity, Message: issue.Message, Target: fmt.Sprintf("line %d, column %d", issue.Line, issue.Column)})
+			}
+		}
+	}
+	if title, ok := analyzer.ResultOf[TitleSection](in, AnalyzerTitle); ok && strings.TrimSpace(title.Title) == "" {
+		add(findings.Finding{ID: "title-missing", Category: findings.CategorySEO, Severity: findings.SeverityError, Message: "The page has no title"})
+	}
+	if headings, ok := analyzer.ResultOf[HeadingsSection](in, AnalyzerHeadings); ok && headings.Headings["h1"] == 0 {
+		add(findings.Finding{ID: "h1-missing", Category: findings.CategorySEO, Severity: findings.SeverityWarning, Message: "The page has no h1 heading"})
+	}
+	if linkQuality, ok := analyzer.ResultOf[LinkQualitySection](in, AnalyzerLinkQuality); ok && linkQuality.LinkQuality != nil {
+		add(linkQuality.LinkQuality.Findings...)
+	}
+	if checks, ok := analyzer.ResultOf[LinkChecksSection](in, AnalyzerLinkChecks); ok {
+		for _, link := range checks.BrokenLinks {
+			add(findings.Finding{ID: "link-broken", Category: findings.CategoryLinks, Severity: findings.SeverityError, Message: "Link is not accessible", Target: link})
+		}
+	}
+	if anchors, ok := analyzer.ResultOf[AnchorsSection](in, AnalyzerAnchors); ok && anchors.Anchors != nil {
+		for _, anchor := range anchors.Anchors.Broken {
+			add(findings.Finding{ID: "anchor-broken", Category: findings.CategoryLinks, Severity: findings.SeverityWarning, Message: "Link target does not exist", Target: anchor.Href})
+		}
+	}
+	if resourcesSection, ok := analyzer.ResultOf[ResourcesSection](in, AnalyzerResources); ok && resourcesSection.Resources != nil {
+		for _, resource := range resourcesSection.Resources.Broken {
+			add(findings.Finding{ID: "resource-broken", Category: findings.CategoryBestPractices, Severity: findings.SeverityError, Message: "Resource is not accessible", Target: resource.URL})
+		}
+	}
+	if languageSection, ok := analyzer.ResultOf[LanguageSection](in, AnalyzerLanguage); ok && languageSection.Language != nil {
+		add(languageSection.Language.Findings...)
+	}
+	if mixedContent, ok := analyzer.ResultOf[MixedContentSection](in, AnalyzerMixedContent); ok && mixedContent.MixedContent != nil {
+		add(mixedContent.MixedContent.Findings...)
+	}
+	if headers, ok := analyzer.ResultOf[SecurityHeadersSection](in, AnalyzerSecurityHeaders); ok && headers.SecurityHeaders != nil {
+		add(headers.SecurityHeaders.Findings...)
+	}
+	if tls, ok := analyzer.ResultOf[TLSSection](in, AnalyzerTLS); ok && tls.TLS != nil {
+		add(tls.TLS.Findings...)
+	}
+	if formsSection, ok := analyzer.ResultOf[FormsSection](in, AnalyzerForms); ok && formsSection.Forms != nil {
+		add(formsSection.Forms.Findings...)
+	}
+	if rulesSection, ok := analyzer.ResultOf[RulesSection](in, AnalyzerRules); ok && rulesSection.Rules != nil {
+		add(rulesSection.Rules.Findings...)
+	}
+	if scriptsSection, ok := analyzer.ResultOf[ScriptsSection](in, AnalyzerScripts); ok && scriptsSection.Scripts != nil {
+		add(scriptsSection.Scripts.Findings...)
+	}
+
+	return collected
+}
+
+// runLinkChecks requests the links and assets of the page in a single pass, so a
+// URL used as both is requested once
+func runLinkChecks(ctx context.Context, in *analyzer.Input) (LinkChecksSection, *webfetch.ErrorResponse) {
+	links, _ := analyzer.ResultOf[LinksSection](in, AnalyzerLinks)
+	validLinks := links.validLinks
+
+	// Won't allow more than 300 links to be checked
+	if len(validLinks) > constants.INACC_LINKS_MAX_CAP {
+		errMessage := fmt.Sprintf("Too many links to check. Exceeded the %d limit", constants.INACC_LINKS_MAX_CAP)
+		return LinkChecksSection{}, webfetch.BuildErrorResponse(http.StatusBadRequest, errMessage)
+	}
+
+	// Assets beyond the cap are reported as unchecked rather than rejecting the page
+	assetUrls := resources.UniqueURLs(resources.Collect(in.Doc, in.BaseURL))
+	section := LinkChecksSection{BrokenLinks: []string{}}
+	if len(assetUrls) > constants.ASSETS_CHECK_MAX_CAP {
+		section.uncheckedAssets = len(assetUrls) - constants.ASSETS_CHECK_MAX_CAP
+		assetUrls = assetUrls[:constants.ASSETS_CHECK_MAX_CAP]
+	}
+
+	section.LinkChecks = in.Fetcher.CheckLinks(ctx, uniqueUrls(validLinks, assetUrls))
+	inaccessible := make(map[string]bool)
+	for _, result := range section.LinkChecks {
+		if !result.Accessible {
+			inaccessible[result.URL] = true
+		}
+	}
+
+	for _, link := range validLinks {
+		if inaccessible[link] {
+			section.BrokenLinks = append(section.BrokenLinks, link)
+		}
+	}
+	section.InaccessibleLinks = len(section.BrokenLinks)
+	in.Logger.Info("InaccessibleLinks", "inaccessibleLinks", section.BrokenLinks, "inaccessibleLinkCount", section.InaccessibleLinks)
+
+	for _, u := range assetUrls {
+		if inaccessible[u] {
+			section.brokenAssets = append(section.brokenAssets, u)
+		}
+	}
+	in.Logger.Info("BrokenAssets", "brokenAssets", section.brokenAssets, "uncheckedAssets", section.uncheckedAssets)
+
+	return section, nil
+}
+
+// thirdPartyDomains groups the external hosts the links and resources of the page point to
+func thirdPartyDomains(in *analyzer.Input) *thirdparty.Inventory {
+	var linkUrls []string
+	in.Doc.Find("a[href], area[href]").Each(func(_ int, s *goquery.Selection) {
+		if resolved, err := utils.ResolveURL(in.BaseURL, strings.TrimSpace(s.AttrOr("href", ""))); err == nil {
+			linkUrls = append(linkUrls, resolved)
+		}
+	})
+
+	var resourceUrls []string
+	for _, resource := range resources.Collect(in.Doc, in.BaseURL) {
+		resourceUrls = append(resourceUrls, resource.URL)
+	}
+
+	return thirdparty.BuildInventory(in.URL, linkUrls, resourceUrls, thirdparty.DefaultClassifier)
+}
@@ -1,54 +1,102 @@
 package pagestats
 
 import (
-	"fmt"
-	"log/slog"
+	"context"
+	"lt-app/internal/analyzer"
 	"lt-app/internal/constants"
 	"lt-app/internal/pagedata"
 	"lt-app/internal/webfetch"
-	"net/http"
 )
 
 type IPageStatsBuilder interface {
-	Build(pageData pagedata.IPageData, RLogger *slog.Logger) (*WebPageStats, *webfetch.ErrorResponse)
+	Build(ctx context.Context, input *analyzer.Input, analyzers []string) (*WebPageStats, *webfetch.ErrorResponse)
 }
 
-type PageStatsBuilder struct{}
-
-type WebPageStats struct {
-	HTMLVersion       string         `json:"htmlVersion"`
-	Title             string         `json:"title"`
-	Headings          map[string]int `json:"headings"`
-	InternalLinks     int            `json:"internalLinks"`
-	ExternalLinks     int            `json:"externalLinks"`
-	TotalLinks        int            `json:"totalLinks"`
-	InaccessibleLinks int            `json:"inaccessibleLinks"`
-	HasLoginForm      bool           `json:"hasLoginForm"`
+// PageStatsBuilder runs the analyzers of a registry, DefaultRegistry when none is set
+type PageStatsBuilder struct {
+	Registry *analyzer.Registry
 }
 
-func (psb *PageStatsBuilder) Build(pageData pagedata.IPageData, fetcher webfetch.IFetcher, RLogger *slog.Logger) (*WebPageStats, *webfetch.ErrorResponse) {
-	stats := &WebPageStats{
-		HTMLVersion:       pageData.GetHtmlVersion(),
-		Title:             pageData.GetTitle(),
-		Headings:          pageData.GetHeadings(),
-		InaccessibleLinks: 0,
-		HasLoginForm:      pageData.ContainsLoginForm(),
+// WebPageStats holds a section per analyzer that ran
+type WebPageStats = analyzer.Results
+
+type BrokenAnchor struct {
+	Href     string `json:"href"`
+	PageURL  string `json:"pageUrl"`
+	Fragment string `json:"fragment"`
+	SamePage bool   `json:"samePage"`
+}
+
+type AnchorReport struct {
+	Checked int `json:"checked"`
+	// Links whose target page could not be fetched, or was beyond the page limit
+	Unverified int            `json:"unverified"`
+	Broken     []BrokenAnchor `json:"broken"`
+}
+
+// Build runs the named analyzers and the analyzers they depend on. No names run every analyzer.
+func (psb *PageStatsBuilder) Build(ctx context.Context, input *analyzer.Input, analyzers []string) (*WebPageStats, *webfetch.ErrorResponse) {
+	registry := psb.Registry
+	if registry == nil {
+		registry = DefaultRegistry
 	}
+	return registry.Run(ctx, analyzers, input)
+}
 
-	links, validLinks := pageData.GetLinkStats()
-	stats.InternalLinks = links.Internal
-	stats.ExternalLinks = links.External
-	stats.TotalLinks = links.Total
+func uniqueUrls(lists ...[]string) []string {
+	seen := make(map[string]bool)
+	urls := []string{}
+	for _, list := range lists {
+		for _, u := range list {
+			if !seen[u] {
+				seen[u] = true
+				urls = append(urls, u)
+			}
+		}
+	}
+	return urls
+}
+
+// checkAnchors reports fragment links whose target id or anchor name does not exist.
+// Other pages of the site are fetched once each, up to ANCHOR_PAGES_MAX_CAP pages.
+func checkAnchors(ctx context.Context, fragmentLinks []pagedata.FragmentLink, fetcher webfetch.IFetcher) *AnchorReport {
+	report := &AnchorReport{Broken: []BrokenAnchor{}}
+
+	var pageUrls []string
+	seenPages := make(map[string]bool)
+	for _, link := range fragmentLinks {
+		if !link.SamePage && !seenPages[link.PageURL] && len(pageUrls) < constants.ANCHOR_PAGES_MAX_CAP {
+			seenPages[link.PageURL] = true
+			pageUrls = append(pageUrls, link.PageURL)
+		}
+	}
 
-	// Won't allow more than 300 links to be checked
-	if len(validLinks) > constants.INACC_LINKS_MAX_CAP {
-		errMessage := fmt.Sprintf("Too many links to check. Exceeded the %d limit", constants.INACC_LINKS_MAX_CAP)
-		return nil, webfetch.BuildErrorResponse(http.StatusBadRequest, errMessage)
+	var pageAnchors map[string]map[string]bool
+	if len(pageUrls) > 0 {
+		pageAnchors = fetcher.GetPageAnchors(ctx, pageUrls)
 	}
 
-	inaccessibleLinks := fetcher.GetInaccessibleLinks(validLinks)
-	stats.InaccessibleLinks = len(inaccessibleLinks)
-	RLogger.Info("InaccessibleLinks", "inaccessibleLinks", inaccessibleLinks, "inaccessibleLinkCount", stats.InaccessibleLinks)
+	for _, link := range fragmentLinks {
+		found := link.TargetFound
+		if !link.SamePage {
+			targets, fetched := pageAnchors[link.PageURL]
+			if !fetched {
+				report.Unverified++
+				continue
+			}
+			found = targets[link.Fragment]
+		}
+
+		report.Checked++
+		if !found {
+			report.Broken = append(report.Broken, BrokenAnchor{
+				Href:     link.Href,
+				PageURL:  link.PageURL,
+				Fragment: link.Fragment,
+				SamePage: link.SamePage,
+			})
+		}
+	}
 
-	return stats, nil
+	return report
 }
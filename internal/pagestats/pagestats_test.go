@@ -1,12 +1,29 @@
 package pagestats
 
 import (
+	"context"
+	"encoding/json"
 	"log/slog"
+	"lt-app/internal/analyzer"
 	"lt-app/internal/constants"
+	"lt-app/internal/doctype"
+	"lt-app/internal/extract"
+	"lt-app/internal/findings"
+	"lt-app/internal/forms"
+	"lt-app/internal/htmlvalidate"
+	"lt-app/internal/language"
+	"lt-app/internal/linkquality"
 	"lt-app/internal/pagedata"
+	"lt-app/internal/resources"
+	"lt-app/internal/security"
+	"lt-app/internal/thirdparty"
 	"lt-app/internal/webfetch"
+	"net/http"
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 var callCount = 0
@@ -14,12 +31,69 @@ var callCount = 0
 // MockFetcher is a mock implementation of the IFetcher interface
 type MockFetcher struct{}
 
-func (m *MockFetcher) GetInaccessibleLinks(urls []string) []string {
-	return []string{"https://example.com/inaccessible1"}
+func (m *MockFetcher) CheckLinks(_ context.Context, urls []string) []webfetch.LinkResult {
+	results := make([]webfetch.LinkResult, 0, len(urls))
+	for _, u := range urls {
+		accessible := u != "https://example.com/inaccessible1" && u != "https://example.com/missing.png"
+		results = append(results, webfetch.LinkResult{URL: u, Accessible: accessible})
+	}
+	return results
+}
+
+func (m *MockFetcher) GetPageAnchors(_ context.Context, urls []string) map[string]map[string]bool {
+	return map[string]map[string]bool{
+		"https://example.com/about": {"team": true},
+	}
 }
 
-func (m *MockFetcher) Fetch(webPageurl string, RLogger *slog.Logger) (string, *webfetch.ErrorResponse) {
-	return "", nil
+func (m *MockFetcher) Fetch(_ context.Context, webPageurl string, RLogger *slog.Logger) (*webfetch.PageSource, *webfetch.ErrorResponse) {
+	return &webfetch.PageSource{}, nil
+}
+
+// testPage is the page the analyzers that read the document run against
+const testPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<title>Example Title</title>
+<meta name="description" content="An example page">
+<script src="/app.js"></script>
+</head>
+<body>
+<main>
+<h1 id="intro">Example</h1>
+<p>An example page with a few links and images.</p>
+<img src="/missing.png" srcset="/missing.png 2x" alt="Missing">
+<a href="#intro">Intro</a> <a href="#missing">Missing</a>
+<a href="/about#team">Team</a> <a href="/about#history">History</a> <a href="/down#top-story">Top story</a>
+</main>
+<footer>SKU <span itemprop="sku">KT-1001</span></footer>
+<script src="https://www.google-analytics.com/analytics.js"></script>
+</body>
+</html>`
+
+// newInput returns the input of the test page, served over HTTPS with strong security headers
+func newInput(t *testing.T, page *MockPageData) *analyzer.Input {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(testPage))
+	if err != nil {
+		t.Fatalf("Failed to parse the test page: %v", err)
+	}
+	headers := http.Header{}
+	headers.Set("Content-Security-Policy", "default-src 'self'; object-src 'none'; frame-ancestors 'none'")
+	headers.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	headers.Set("X-Content-Type-Options", "nosniff")
+	headers.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+	headers.Set("Permissions-Policy", "camera=()")
+
+	return &analyzer.Input{
+		Page:    page,
+		Doc:     doc,
+		HTML:    testPage,
+		Headers: headers,
+		URL:     "https://example.com",
+		BaseURL: "https://example.com",
+		Fetcher: &MockFetcher{},
+		Logger:  slog.Default(),
+	}
 }
 
 type MockPageData struct {
@@ -55,6 +129,7 @@ func (m *MockPageData) GetLinkStats() (*pagedata.Links, []string) {
 		Internal: 2,
 		External: 3,
 		Total:    5,
+		Details:  []linkquality.Link{{Href: "https://other.com/", Text: "click here", Rel: []string{"nofollow"}, Target: "_blank"}},
 	}, inaccessibleLinks
 }
 
@@ -64,41 +139,190 @@ func TestPageStatsBuilder_BuildSuccess(t *testing.T) {
 		Headings: map[string]int{"h1": 1, "h2": 2},
 	}
 
-	// Create a mock Fetcher
-	mockFetcher := &MockFetcher{}
-
-	logger := slog.Default()
-
 	// Create a PageStatsBuilder
 	psb := &PageStatsBuilder{}
 
 	// Call the Build function
-	pageStats, err := psb.Build(mockPageData, mockFetcher, logger)
+	input := newInput(t, mockPageData)
+	pageStats, err := psb.Build(context.Background(), input, nil)
 
 	// Assert that there is no error
 	if err != nil {
-		t.Errorf("Expected no error, got %v", err)
+		t.Fatalf("Expected no error, got %v", err)
 	}
 
 	// Assert that the PageStats is not nil
 	if pageStats == nil {
-		t.Errorf("Expected PageStats, got nil")
+		t.Fatalf("Expected PageStats, got nil")
+	}
+
+	// Assert that the sections are correct
+	expectedSections := map[string]any{
+		AnalyzerHTML: HTMLSection{
+			HTMLVersion: "html5",
+			Doctype:     &doctype.Doctype{Present: true, Name: "html", Version: doctype.VersionHTML5, Mode: doctype.ModeNoQuirks, IsFirstToken: true},
+			Validation:  &htmlvalidate.Report{Valid: true, Issues: []htmlvalidate.Issue{}},
+		},
+		AnalyzerTitle:    TitleSection{Title: "Example Title"},
+		AnalyzerHeadings: HeadingsSection{Headings: map[string]int{"h1": 1, "h2": 2}},
+		AnalyzerLinks: LinksSection{
+			InternalLinks: 2,
+			ExternalLinks: 3,
+			TotalLinks:    5,
+			Links:         []string{"https://example.com/inaccessible1"},
+			validLinks:    []string{"https://example.com/inaccessible1"},
+			details:       []linkquality.Link{{Href: "https://other.com/", Text: "click here", Rel: []string{"nofollow"}, Target: "_blank"}},
+		},
+		AnalyzerLinkQuality: LinkQualitySection{LinkQuality: &linkquality.Report{
+			Total:             1,
+			TargetBlank:       1,
+			UnsafeTargetBlank: 1,
+			Nofollow:          1,
+			GenericText:       1,
+			Findings: []findings.Finding{
+				{ID: "link-target-blank-without-noopener", Category: findings.CategorySecurity, Severity: findings.SeverityWarning, Message: `Link opens in a new tab without rel="noopener", older browsers give the new page access to this one`, Target: "https://other.com/"},
+				{ID: "link-generic-text", Category: findings.CategorySEO, Severity: findings.SeverityWarning, Message: `Anchor text "click here" does not describe the link target`, Target: "https://other.com/"},
+			},
+		}},
+		AnalyzerLinkChecks: LinkChecksSection{
+			InaccessibleLinks: 1,
+			BrokenLinks:       []string{"https://example.com/inaccessible1"},
+			LinkChecks: []webfetch.LinkResult{
+				{URL: "https://example.com/inaccessible1"},
+				{URL: "https://example.com/missing.png"},
+				{URL: "https://example.com/app.js", Accessible: true},
+				{URL: "https://www.google-analytics.com/analytics.js", Accessible: true},
+			},
+			brokenAssets: []string{"https://example.com/missing.png"},
+		},
+		AnalyzerAnchors: AnchorsSection{Anchors: &AnchorReport{
+			Checked:    4,
+			Unverified: 1,
+			Broken: []BrokenAnchor{
+				{Href: "#missing", PageURL: "https://example.com", Fragment: "missing", SamePage: true},
+				{Href: "/about#history", PageURL: "https://example.com/about", Fragment: "history"},
+			},
+		}},
+		AnalyzerResources: ResourcesSection{Resources: &resources.Report{
+			Total:  4,
+			ByType: map[resources.ResourceType]int{resources.ResourceScript: 2, resources.ResourceImage: 2},
+			Broken: []resources.Resource{
+				{URL: "https://example.com/missing.png", Type: resources.ResourceImage, Element: "img", Attribute: "src"},
+				{URL: "https://example.com/missing.png", Type: resources.ResourceImage, Element: "img", Attribute: "srcset"},
+			},
+			Resources: []resources.Resource{
+				{URL: "https://example.com/missing.png", Type: resources.ResourceImage, Element: "img", Attribute: "src"},
+				{URL: "https://example.com/missing.png", Type: resources.ResourceImage, Element: "img", Attribute: "srcset"},
+				{URL: "https://example.com/app.js", Type: resources.ResourceScript, Element: "script", Attribute: "src"},
+				{URL: "https://www.google-analytics.com/analytics.js", Type: resources.ResourceScript, Element: "script", Attribute: "src"},
+			},
+		}},
+		AnalyzerThirdParties: ThirdPartiesSection{ThirdParties: &thirdparty.Inventory{
+			ThirdPartyCount: 1,
+			ByCategory:      map[string]int{"analytics": 1},
+			ListVersion:     "2025-03-01",
+			Domains:         []thirdparty.Domain{{Domain: "google-analytics.com", ThirdParty: true, Category: "analytics", Owner: "Google", Hosts: []string{"www.google-analytics.com"}, Resources: 1}},
+		}},
+		// No page source, so the size is unknown
+		AnalyzerPage: PageSection{Description: "An example page", Outline: []pagedata.Heading{{Tag: "h1", Text: "Example"}}},
+		AnalyzerLanguage: LanguageSection{Language: &language.Report{
+			Declared: []language.Declaration{{Source: language.SourceHTMLLang, Value: "en", Tag: "en", Valid: true}},
+			Detected: &language.Detection{Language: "en", Script: "Latn", Confidence: 0.13, Reliable: true},
+			Findings: []findings.Finding{},
+		}},
+		AnalyzerMixedContent: MixedContentSection{MixedContent: &security.MixedContentReport{Applicable: true, Items: []security.MixedContentItem{}, Findings: []findings.Finding{}}},
+		// No page source, so there is nothing to inspect or time
+		AnalyzerTLS:    TLSSection{},
+		AnalyzerTiming: TimingSection{},
+		AnalyzerForms: FormsSection{
+			FormDetection: &forms.Detection{SSOProviders: []string{}, Forms: []forms.FormDetection{}},
+			Forms:         &forms.Inventory{Forms: []forms.Form{}, Findings: []findings.Finding{}},
+		},
+		// No extractors were supplied
+		AnalyzerExtract: ExtractSection{Extractions: []extract.Result{}},
 	}
 
-	// Assert that the PageStats fields are correct
-	expectedStats := &WebPageStats{
-		HTMLVersion:       "html5", // You might need to mock GetHtmlVersion as well
-		Title:             "Example Title",
-		Headings:          map[string]int{"h1": 1, "h2": 2},
-		InternalLinks:     2,
-		ExternalLinks:     3,
-		TotalLinks:        5,
-		InaccessibleLinks: 1,
-		HasLoginForm:      true,
+	if !reflect.DeepEqual(pageStats.Names(), DefaultRegistry.Names()) {
+		t.Errorf("Expected every analyzer to run, got %v", pageStats.Names())
 	}
 
-	if !reflect.DeepEqual(pageStats, expectedStats) {
-		t.Errorf("Expected stats %v, got %v", expectedStats, pageStats)
+	for name, expected := range expectedSections {
+		section, found := pageStats.Get(name)
+		if !found {
+			t.Errorf("Expected section %s", name)
+			continue
+		}
+		if !reflect.DeepEqual(section, expected) {
+			t.Errorf("Expected section %s\n%+v\ngot\n%+v", name, expected, section)
+		}
+	}
+
+	technologies, _ := analyzer.Section[TechnologiesSection](pageStats, AnalyzerTechnologies)
+	if len(technologies.Technologies.Technologies) != 1 || technologies.Technologies.Technologies[0].Name != "Google Analytics" {
+		t.Errorf("Expected Google Analytics to be detected, got %+v", technologies.Technologies)
+	}
+	contentSection, _ := analyzer.Section[ContentSection](pageStats, AnalyzerContent)
+	if contentSection.Content.Source != "main" || contentSection.Content.WordCount != 16 || contentSection.Content.Readability == nil {
+		t.Errorf("Expected the main content with its readability, got %+v", contentSection.Content)
+	}
+	// The test page sets no viewport, the other bundled rules pass
+	rulesSection, _ := analyzer.Section[RulesSection](pageStats, AnalyzerRules)
+	if rulesSection.Rules.Failed != 1 || len(rulesSection.Rules.Findings) != 1 || rulesSection.Rules.Findings[0].ID != "rule-viewport" {
+		t.Errorf("Expected only the viewport rule to fail, got %+v", rulesSection.Rules)
+	}
+	// The bundled script reports the missing structured data
+	scriptsSection, _ := analyzer.Section[ScriptsSection](pageStats, AnalyzerScripts)
+	if len(scriptsSection.Scripts.Scripts) != 1 || scriptsSection.Scripts.Scripts[0].Error != "" || len(scriptsSection.Scripts.Findings) != 1 || scriptsSection.Scripts.Findings[0].ID != "script-structured-data-missing" {
+		t.Errorf("Expected the structured data script to report a finding, got %+v", scriptsSection.Scripts)
+	}
+	headers, _ := analyzer.Section[SecurityHeadersSection](pageStats, AnalyzerSecurityHeaders)
+	if headers.SecurityHeaders.Score != 100 || len(headers.SecurityHeaders.Findings) != 0 {
+		t.Errorf("Expected a score of 100 without findings, got %+v", headers.SecurityHeaders)
+	}
+}
+
+func TestPageStatsBuilder_BuildSelectedAnalyzers(t *testing.T) {
+	input := newInput(t, &MockPageData{Headings: map[string]int{"h1": 1}})
+
+	psb := &PageStatsBuilder{}
+	pageStats, err := psb.Build(context.Background(), input, []string{AnalyzerTitle, AnalyzerLinkQuality})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Dependencies run along with the selected analyzers
+	expected := []string{AnalyzerTitle, AnalyzerLinks, AnalyzerLinkQuality}
+	if !reflect.DeepEqual(pageStats.Names(), expected) {
+		t.Errorf("Expected analyzers %v, got %v", expected, pageStats.Names())
+	}
+
+	body, marshalErr := json.Marshal(pageStats)
+	if marshalErr != nil {
+		t.Fatalf("Failed to marshal the stats: %v", marshalErr)
+	}
+	for _, key := range []string{`"title":"Example Title"`, `"totalLinks":5`, `"linkQuality":{`} {
+		if !strings.Contains(string(body), key) {
+			t.Errorf("Expected %s in %s", key, body)
+		}
+	}
+	if strings.Contains(string(body), `"headings"`) {
+		t.Errorf("Expected no headings section in %s", body)
+	}
+
+	_, err = psb.Build(context.Background(), input, []string{"unknown"})
+	if err == nil || err.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected a bad request for an unknown analyzer, got %v", err)
+	}
+
+	input.Extractors = []extract.Extractor{{Name: "sku", CSS: "[itemprop=sku]"}}
+	pageStats, err = psb.Build(context.Background(), input, []string{AnalyzerExtract})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	body, _ = json.Marshal(pageStats)
+	expectedBody := `{"analyzers":["extract"],"extractions":[{"name":"sku","count":1,"values":["KT-1001"]}]}`
+	if string(body) != expectedBody {
+		t.Errorf("Expected %s, got %s", expectedBody, body)
 	}
 }
 
@@ -109,20 +333,146 @@ func TestPageStatsBuilder_BuildFailure_TooManyLinks(t *testing.T) {
 		Headings:   map[string]int{"h1": 1, "h2": 2},
 	}
 
-	// Create a mock Fetcher
-	mockFetcher := &MockFetcher{}
-
-	// Create a logger
-	logger := slog.Default()
-
 	// Create a PageStatsBuilder
 	psb := &PageStatsBuilder{}
 
 	// Call the Build function
-	_, err := psb.Build(mockPageData, mockFetcher, logger)
+	input := newInput(t, mockPageData)
+	_, err := psb.Build(context.Background(), input, nil)
 
 	// Expecting error for too many links
 	if err == nil {
 		t.Errorf("Expected error for too many links, got nil")
 	}
 }
+
+func TestPageStatsBuilder_Score(t *testing.T) {
+	callCount = 0
+	input := newInput(t, &MockPageData{Headings: map[string]int{"h1": 1}})
+
+	pageStats, err := (&PageStatsBuilder{}).Build(context.Background(), input, []string{AnalyzerScore})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// The score runs every analyzer that reports problems
+	for _, name := range scoredAnalyzers {
+		if _, found := pageStats.Get(name); !found {
+			t.Errorf("Expected %s to run for the score", name)
+		}
+	}
+
+	section, _ := analyzer.Section[ScoreSection](pageStats, AnalyzerScore)
+	if section.Score.Score != 91 || section.Score.Grade != "A" {
+		t.Errorf("Expected a score of 91 (A), got %d (%s)", section.Score.Score, section.Score.Grade)
+	}
+
+	// Broken links, anchors and assets become findings along with those the analyzers report
+	expectedContributions := []string{
+		"resource-broken", "link-broken", "anchor-broken",
+		"link-target-blank-without-noopener", "link-generic-text", "rule-viewport", "script-structured-data-missing",
+	}
+	contributions := []string{}
+	for _, contribution := range section.Score.Contributions {
+		contributions = append(contributions, contribution.ID)
+	}
+	if !reflect.DeepEqual(contributions, expectedContributions) {
+		t.Errorf("Expected contributions %v, got %v", expectedContributions, contributions)
+	}
+}
+
+func TestCollectFindings_DerivedFindings(t *testing.T) {
+	registry := analyzer.NewRegistry().Register(
+		analyzer.NewInline(AnalyzerHTML, nil, func(_ context.Context, _ *analyzer.Input) (HTMLSection, *webfetch.ErrorResponse) {
+			return HTMLSection{
+				Doctype: &doctype.Doctype{Mode: doctype.ModeQuirks},
+				Validation: &htmlvalidate.Report{Issues: []htmlvalidate.Issue{
+					{Type: htmlvalidate.IssueUnclosedTag, Severity: htmlvalidate.SeverityError, Tag: "div", Message: "Unclosed div", Line: 3, Column: 5},
+				}},
+			}, nil
+		}),
+		analyzer.NewInline(AnalyzerTitle, nil, func(_ context.Context, _ *analyzer.Input) (TitleSection, *webfetch.ErrorResponse) {
+			return TitleSection{Title: "  "}, nil
+		}),
+		analyzer.NewInline(AnalyzerHeadings, nil, func(_ context.Context, _ *analyzer.Input) (HeadingsSection, *webfetch.ErrorResponse) {
+			return HeadingsSection{Headings: map[string]int{"h2": 1}}, nil
+		}),
+		analyzer.NewInline("collect", []string{AnalyzerHTML, AnalyzerTitle, AnalyzerHeadings}, func(_ context.Context, in *analyzer.Input) ([]findings.Finding, *webfetch.ErrorResponse) {
+			return collectFindings(in), nil
+		}),
+	)
+
+	results, err := registry.Run(context.Background(), nil, &analyzer.Input{Logger: slog.Default()})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	collected, _ := analyzer.Section[[]findings.Finding](results, "collect")
+	expected := []findings.Finding{
+		{ID: "doctype-quirks-mode", Category: findings.CategoryBestPractices, Severity: findings.SeverityWarning, Message: "The page is rendered in quirks mode"},
+		{ID: "html-unclosed-tag", Category: findings.CategoryBestPractices, Severity: findings.SeverityError, Message: "Unclosed div", Target: "line 3, column 5"},
+		{ID: "title-missing", Category: findings.CategorySEO, Severity: findings.SeverityError, Message: "The page has no title"},
+		{ID: "h1-missing", Category: findings.CategorySEO, Severity: findings.SeverityWarning, Message: "The page has no h1 heading"},
+	}
+	if !reflect.DeepEqual(collected, expected) {
+		t.Errorf("Expected %+v, got %+v", expected, collected)
+	}
+}
+
+func TestForms_DetectedLanguage(t *testing.T) {
+	html := `<html><body><main>
+		<p>Bienvenue sur votre espace client. Connectez-vous pour consulter vos commandes et modifier vos informations personnelles.</p>
+		<form action="/session" method="post">
+			<input type="email" name="courriel">
+			<input type="password" name="mot_de_passe">
+			<button type="submit">Se connecter</button>
+		</form>
+	</main></body></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	input := &analyzer.Input{Doc: doc, HTML: html, URL: "https://www.example.fr/", BaseURL: "https://www.example.fr/", Logger: slog.Default()}
+
+	pageStats, err := (&PageStatsBuilder{}).Build(context.Background(), input, []string{AnalyzerForms})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	languageSection, _ := analyzer.Section[LanguageSection](pageStats, AnalyzerLanguage)
+	if languageSection.Language.Detected.Language != "fr" {
+		t.Errorf("Expected French to be detected, got %+v", languageSection.Language.Detected)
+	}
+	if len(languageSection.Language.Findings) != 1 || languageSection.Language.Findings[0].ID != "lang-missing" {
+		t.Errorf("Expected a missing lang finding, got %+v", languageSection.Language.Findings)
+	}
+
+	// The detected language selects the keywords used to classify the form
+	formsSection, _ := analyzer.Section[FormsSection](pageStats, AnalyzerForms)
+	if !formsSection.HasLoginForm {
+		t.Errorf("Expected the French login form to be detected")
+	}
+}
+
+func TestMixedContent_KeepsHeaders(t *testing.T) {
+	headers := http.Header{}
+	for _, policy := range []string{"default-src 'self'", "img-src 'self'", "script-src 'self'"} {
+		headers.Add("Content-Security-Policy", policy)
+	}
+	html := `<html><head><meta http-equiv="Content-Security-Policy" content="upgrade-insecure-requests"></head></html>`
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(html))
+	input := &analyzer.Input{Doc: doc, HTML: html, Headers: headers, URL: "https://example.com", BaseURL: "https://example.com", Logger: slog.Default()}
+
+	pageStats, err := (&PageStatsBuilder{}).Build(context.Background(), input, []string{AnalyzerMixedContent})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	section, _ := analyzer.Section[MixedContentSection](pageStats, AnalyzerMixedContent)
+	if !section.MixedContent.UpgradeInsecureRequests {
+		t.Errorf("Expected the meta policy to be read")
+	}
+	// Appending to the values would write past their length, into the headers' array
+	values := headers.Values("Content-Security-Policy")
+	for _, policy := range values[:cap(values)] {
+		if policy == "upgrade-insecure-requests" {
+			t.Errorf("Expected the response headers to be left unchanged, got %q", values[:cap(values)])
+		}
+	}
+}
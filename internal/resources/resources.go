@@ -0,0 +1,241 @@
+package resources
+
+import (
+	"lt-app/internal/utils"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+type ResourceType string
+
+const (
+	ResourceImage      ResourceType = "image"
+	ResourceScript     ResourceType = "script"
+	ResourceStylesheet ResourceType = "stylesheet"
+	ResourcePreload    ResourceType = "preload"
+	ResourceIcon       ResourceType = "icon"
+	ResourceMedia      ResourceType = "media"
+	ResourceIframe     ResourceType = "iframe"
+	ResourceCSSURL     ResourceType = "css-url"
+)
+
+// Resource is a subresource the page loads, as opposed to a link the user navigates to
+type Resource struct {
+	URL       string       `json:"url"`
+	Type      ResourceType `json:"type"`
+	Element   string       `json:"element"`
+	Attribute string       `json:"attribute"`
+}
+
+type Report struct {
+	Total  int                  `json:"total"`
+	ByType map[ResourceType]int `json:"byType"`
+	// Resources that returned an error or a non 200 status
+	Broken []Resource `json:"broken"`
+	// Unique URLs beyond ASSETS_CHECK_MAX_CAP that were not checked
+	Unchecked int        `json:"unchecked"`
+	Resources []Resource `json:"resources"`
+}
+
+var cssURLPattern = regexp.MustCompile(`(?i)url\(\s*(?:'([^']*)'|"([^"]*)"|([^)'"]*))\s*\)`)
+
+// Collect returns every subresource referenced by the document, resolved
+// against baseUrl. Inline data and script URLs are skipped.
+func Collect(doc *goquery.Document, baseUrl string) []Resource {
+	c := &collector{baseUrl: baseUrl, resources: []Resource{}}
+
+	doc.Find("img").Each(func(_ int, s *goquery.Selection) {
+		c.addAttr(s, "src", ResourceImage)
+		c.addSrcset(s, ResourceImage)
+	})
+
+	doc.Find("picture source").Each(func(_ int, s *goquery.Selection) {
+		c.addSrcset(s, ResourceImage)
+	})
+
+	doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+		c.addAttr(s, "src", ResourceScript)
+	})
+
+	doc.Find("link[href][rel]").Each(func(_ int, s *goquery.Selection) {
+		for _, rel := range strings.Fields(strings.ToLower(s.AttrOr("rel", ""))) {
+			switch rel {
+			case "stylesheet":
+				c.addAttr(s, "href", ResourceStylesheet)
+			case "preload", "modulepreload":
+				c.addAttr(s, "href", ResourcePreload)
+			case "icon", "apple-touch-icon", "apple-touch-icon-precomposed", "mask-icon":
+				c.addAttr(s, "href", ResourceIcon)
+			default:
+				continue
+			}
+			return
+		}
+	})
+
+	doc.Find("video, audio").Each(func(_ int, s *goquery.Selection) {
+		c.addAttr(s, "src", ResourceMedia)
+		c.addAttr(s, "poster", ResourceImage)
+	})
+
+	doc.Find("video source, audio source, video track, audio track").Each(func(_ int, s *goquery.Selection) {
+		c.addAttr(s, "src", ResourceMedia)
+	})
+
+	doc.Find("iframe[src]").Each(func(_ int, s *goquery.Selection) {
+		c.addAttr(s, "src", ResourceIframe)
+	})
+
+	doc.Find("[style]").Each(func(_ int, s *goquery.Selection) {
+		c.addCSSURLs(s, s.AttrOr("style", ""), "style")
+	})
+
+	doc.Find("style").Each(func(_ int, s *goquery.Selection) {
+		c.addCSSURLs(s, s.Text(), "")
+	})
+
+	return c.resources
+}
+
+// UniqueURLs returns the distinct resource URLs in the order they were found
+func UniqueURLs(list []Resource) []string {
+	seen := make(map[string]bool, len(list))
+	urls := make([]string, 0, len(list))
+
+	for _, resource := range list {
+		if !seen[resource.URL] {
+			seen[resource.URL] = true
+			urls = append(urls, resource.URL)
+		}
+	}
+
+	return urls
+}
+
+// BuildReport summarises the resources given the URLs found to be inaccessible
+func BuildReport(list []Resource, inaccessibleUrls []string, unchecked int) *Report {
+	report := &Report{
+		Total:     len(list),
+		ByType:    make(map[ResourceType]int),
+		Broken:    []Resource{},
+		Unchecked: unchecked,
+		Resources: list,
+	}
+
+	inaccessible := make(map[string]bool, len(inaccessibleUrls))
+	for _, u := range inaccessibleUrls {
+		inaccessible[u] = true
+	}
+
+	for _, resource := range list {
+		report.ByType[resource.Type]++
+		if inaccessible[resource.URL] {
+			report.Broken = append(report.Broken, resource)
+		}
+	}
+
+	return report
+}
+
+type collector struct {
+	baseUrl   string
+	resources []Resource
+}
+
+func (c *collector) add(s *goquery.Selection, ref string, attribute string, resourceType ResourceType) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || isInlineURL(ref) {
+		return
+	}
+
+	resolved, err := utils.ResolveURL(c.baseUrl, ref)
+	if err != nil {
+		return
+	}
+
+	c.resources = append(c.resources, Resource{
+		URL:       utils.StripFragment(resolved),
+		Type:      resourceType,
+		Element:   goquery.NodeName(s),
+		Attribute: attribute,
+	})
+}
+
+func (c *collector) addAttr(s *goquery.Selection, attribute string, resourceType ResourceType) {
+	if value, exists := s.Attr(attribute); exists {
+		c.add(s, value, attribute, resourceType)
+	}
+}
+
+// addSrcset adds every image candidate of a srcset attribute, e.g. "a.png 1x, b.png 2x"
+func (c *collector) addSrcset(s *goquery.Selection, resourceType ResourceType) {
+	srcset, exists := s.Attr("srcset")
+	if !exists {
+		return
+	}
+
+	for _, ref := range srcsetURLs(srcset) {
+		c.add(s, ref, "srcset", resourceType)
+	}
+}
+
+// srcsetURLs returns the URL of every candidate of a srcset attribute as browsers
+// parse it. A URL ends at whitespace, so it may contain commas, e.g. CDN options
+// such as "w_100,h_100" or data URIs. A comma ends a candidate when it follows
+// the URL or its descriptors, outside of parentheses.
+func srcsetURLs(srcset string) []string {
+	urls := []string{}
+	for i := 0; i < len(srcset); {
+		if isSrcsetSpace(srcset[i]) || srcset[i] == ',' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(srcset) && !isSrcsetSpace(srcset[i]) {
+			i++
+		}
+		ref := srcset[start:i]
+		if strings.HasSuffix(ref, ",") {
+			// The candidate has no descriptors
+			urls = append(urls, strings.TrimRight(ref, ","))
+			continue
+		}
+		urls = append(urls, ref)
+
+		// Descriptors, e.g. "2x" or "1200w", run until the next comma
+		inParens := false
+		for ; i < len(srcset) && (inParens || srcset[i] != ','); i++ {
+			switch srcset[i] {
+			case '(':
+				inParens = true
+			case ')':
+				inParens = false
+			}
+		}
+	}
+	return urls
+}
+
+// isSrcsetSpace reports ASCII whitespace as defined by HTML
+func isSrcsetSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\f' || b == '\r'
+}
+
+func (c *collector) addCSSURLs(s *goquery.Selection, css string, attribute string) {
+	for _, match := range cssURLPattern.FindAllStringSubmatch(css, -1) {
+		c.add(s, match[1]+match[2]+match[3], attribute, ResourceCSSURL)
+	}
+}
+
+func isInlineURL(ref string) bool {
+	lower := strings.ToLower(ref)
+	for _, scheme := range []string{"data:", "blob:", "javascript:", "about:"} {
+		if strings.HasPrefix(lower, scheme) {
+			return true
+		}
+	}
+	return strings.HasPrefix(ref, "#")
+}
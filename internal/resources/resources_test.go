@@ -0,0 +1,118 @@
+package resources
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestCollect(t *testing.T) {
+	htmlSource := `<html><head>
+		<link rel="stylesheet" href="/css/site.css">
+		<link rel="preload" href="/fonts/a.woff2" as="font">
+		<link rel="shortcut icon" href="/favicon.ico">
+		<link rel="canonical" href="/page">
+		<script src="https://cdn.example.org/lib.js"></script>
+		<script>var inline = true;</script>
+		<style>.hero { background: url('/img/hero.jpg') }</style>
+	</head><body>
+		<img src="logo.png" srcset="logo-1x.png 1x, logo-2x.png 2x">
+		<img src="data:image/png;base64,AAAA">
+		<picture><source srcset="/img/wide.webp 1200w"><img src="/img/narrow.jpg"></picture>
+		<video poster="/img/poster.jpg"><source src="/media/clip.mp4"><track src="/media/subs.vtt"></video>
+		<audio src="/media/song.mp3"></audio>
+		<iframe src="https://www.youtube.com/embed/xyz"></iframe>
+		<div style="background-image: url(&quot;/img/bg.png&quot;)"></div>
+	</body></html>`
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlSource))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	expected := []Resource{
+		{URL: "https://example.com/docs/logo.png", Type: ResourceImage, Element: "img", Attribute: "src"},
+		{URL: "https://example.com/docs/logo-1x.png", Type: ResourceImage, Element: "img", Attribute: "srcset"},
+		{URL: "https://example.com/docs/logo-2x.png", Type: ResourceImage, Element: "img", Attribute: "srcset"},
+		{URL: "https://example.com/img/narrow.jpg", Type: ResourceImage, Element: "img", Attribute: "src"},
+		{URL: "https://example.com/img/wide.webp", Type: ResourceImage, Element: "source", Attribute: "srcset"},
+		{URL: "https://cdn.example.org/lib.js", Type: ResourceScript, Element: "script", Attribute: "src"},
+		{URL: "https://example.com/css/site.css", Type: ResourceStylesheet, Element: "link", Attribute: "href"},
+		{URL: "https://example.com/fonts/a.woff2", Type: ResourcePreload, Element: "link", Attribute: "href"},
+		{URL: "https://example.com/favicon.ico", Type: ResourceIcon, Element: "link", Attribute: "href"},
+		{URL: "https://example.com/img/poster.jpg", Type: ResourceImage, Element: "video", Attribute: "poster"},
+		{URL: "https://example.com/media/song.mp3", Type: ResourceMedia, Element: "audio", Attribute: "src"},
+		{URL: "https://example.com/media/clip.mp4", Type: ResourceMedia, Element: "source", Attribute: "src"},
+		{URL: "https://example.com/media/subs.vtt", Type: ResourceMedia, Element: "track", Attribute: "src"},
+		{URL: "https://www.youtube.com/embed/xyz", Type: ResourceIframe, Element: "iframe", Attribute: "src"},
+		{URL: "https://example.com/img/bg.png", Type: ResourceCSSURL, Element: "div", Attribute: "style"},
+		{URL: "https://example.com/img/hero.jpg", Type: ResourceCSSURL, Element: "style", Attribute: ""},
+	}
+
+	result := Collect(doc, "https://example.com/docs/")
+
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected resources\n%v\ngot\n%v", expected, result)
+	}
+}
+
+func TestUniqueURLs(t *testing.T) {
+	list := []Resource{
+		{URL: "https://example.com/a.png"},
+		{URL: "https://example.com/b.js"},
+		{URL: "https://example.com/a.png"},
+	}
+
+	expected := []string{"https://example.com/a.png", "https://example.com/b.js"}
+	if result := UniqueURLs(list); !reflect.DeepEqual(result, expected) {
+		t.Errorf("Expected %v, got %v", expected, result)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	list := []Resource{
+		{URL: "https://example.com/a.png", Type: ResourceImage},
+		{URL: "https://example.com/b.js", Type: ResourceScript},
+		{URL: "https://example.com/c.png", Type: ResourceImage},
+	}
+
+	report := BuildReport(list, []string{"https://example.com/c.png"}, 2)
+
+	if report.Total != 3 || report.Unchecked != 2 {
+		t.Errorf("Unexpected totals %+v", report)
+	}
+	if report.ByType[ResourceImage] != 2 || report.ByType[ResourceScript] != 1 {
+		t.Errorf("Unexpected counts by type %v", report.ByType)
+	}
+	if len(report.Broken) != 1 || report.Broken[0].URL != "https://example.com/c.png" {
+		t.Errorf("Expected c.png to be broken, got %v", report.Broken)
+	}
+}
+
+func TestSrcsetURLs(t *testing.T) {
+	tests := []struct {
+		name     string
+		srcset   string
+		expected []string
+	}{
+		{"descriptors", "a.png 1x, b.png 2x", []string{"a.png", "b.png"}},
+		{"no descriptors", "a.png, b.png ,c.png", []string{"a.png", "b.png", "c.png"}},
+		{"comma without whitespace", "a.png,b.png 2x", []string{"a.png,b.png"}},
+		{"commas in URLs", "/img/w_100,h_100/a.jpg 100w, /img/w_200,h_200/a.jpg 200w", []string{"/img/w_100,h_100/a.jpg", "/img/w_200,h_200/a.jpg"}},
+		{"data URI", "data:image/png;base64,iVBORw0KGgo= 1x, b.png 2x", []string{"data:image/png;base64,iVBORw0KGgo=", "b.png"}},
+		{"commas in parentheses", "a.png (x, y) 1x, b.png", []string{"a.png", "b.png"}},
+		{"whitespace", "\n\ta.png\n\t1x,\n\tb.png\n", []string{"a.png", "b.png"}},
+		{"empty candidates", " , ,a.png,, ", []string{"a.png"}},
+		{"empty", "", []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if urls := srcsetURLs(test.srcset); !reflect.DeepEqual(urls, test.expected) {
+				t.Errorf("Expected %q, got %q", test.expected, urls)
+			}
+		})
+	}
+}
@@ -12,6 +12,23 @@ func buildApiRoutes(app *fiber.App) {
 	api := app.Group("/api")
 
 	api.Post("/analyze", handlers.AnalyzeWebPage)
+	api.Get("/analyzers", handlers.ListAnalyzers)
+	api.Get("/history", handlers.ListHistory)
+	api.Get("/history/:id", handlers.GetHistoryRun)
+	api.Get("/diff", handlers.DiffRuns)
+	api.Get("/monitors", handlers.ListMonitors)
+	api.Post("/monitors", handlers.CreateMonitor)
+	api.Get("/monitors/:id", handlers.GetMonitor)
+	api.Put("/monitors/:id", handlers.UpdateMonitor)
+	api.Delete("/monitors/:id", handlers.DeleteMonitor)
+	api.Post("/monitors/:id/run", handlers.RunMonitor)
+	api.Get("/monitors/:id/alerts", handlers.ListMonitorAlerts)
+	api.Get("/webhooks", handlers.ListWebhooks)
+	api.Post("/webhooks", handlers.CreateWebhook)
+	api.Get("/webhooks/:id", handlers.GetWebhook)
+	api.Put("/webhooks/:id", handlers.UpdateWebhook)
+	api.Delete("/webhooks/:id", handlers.DeleteWebhook)
+	api.Get("/webhooks/:id/deliveries", handlers.ListWebhookDeliveries)
 }
 
 func SetupRoutes(app *fiber.App) {
@@ -0,0 +1,204 @@
+package rules
+
+import (
+	"errors"
+	"fmt"
+	"lt-app/internal/findings"
+	"regexp"
+
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// Match modes of attribute and text assertions
+const (
+	// At least one element matches
+	MatchAny = "any"
+	// Every element matches, which holds when no element is selected
+	MatchAll = "all"
+	// No element matches
+	MatchNone = "none"
+)
+
+// The rule file is YAML. JSON is valid YAML, so rule files can also be written in JSON.
+type rawRuleFile struct {
+	Version string    `yaml:"version"`
+	Rules   []rawRule `yaml:"rules"`
+}
+
+// rawRule has a selector and exactly one assertion
+type rawRule struct {
+	ID          string        `yaml:"id"`
+	Description string        `yaml:"description"`
+	Severity    string        `yaml:"severity"`
+	Category    string        `yaml:"category"`
+	Selector    string        `yaml:"selector"`
+	Exists      *bool         `yaml:"exists"`
+	Count       *rawCount     `yaml:"count"`
+	Attribute   *rawAttribute `yaml:"attribute"`
+	Text        *rawText      `yaml:"text"`
+}
+
+type rawCount struct {
+	Min *int `yaml:"min"`
+	Max *int `yaml:"max"`
+}
+
+type rawAttribute struct {
+	Name    string `yaml:"name"`
+	Pattern string `yaml:"pattern"`
+	Match   string `yaml:"match"`
+}
+
+type rawText struct {
+	Pattern string `yaml:"pattern"`
+	Match   string `yaml:"match"`
+}
+
+type assertionKind int
+
+const (
+	assertExists assertionKind = iota
+	assertCount
+	assertAttribute
+	assertText
+)
+
+type rule struct {
+	id          string
+	description string
+	severity    findings.Severity
+	category    findings.Category
+	// The selector as written, for messages
+	selectorText string
+	selector     cascadia.Selector
+
+	kind      assertionKind
+	exists    bool
+	min, max  *int
+	attribute string
+	pattern   *regexp.Regexp
+	match     string
+}
+
+type ruleSet struct {
+	version string
+	rules   []*rule
+}
+
+// parseRules compiles every rule of the file and fails on the first invalid one
+func parseRules(content []byte) (*ruleSet, error) {
+	var raw rawRuleFile
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	set := &ruleSet{version: raw.Version}
+	ids := make(map[string]bool, len(raw.Rules))
+
+	for i, rawRule := range raw.Rules {
+		if rawRule.ID == "" {
+			return nil, fmt.Errorf("rule #%d has no id", i+1)
+		}
+		if ids[rawRule.ID] {
+			return nil, fmt.Errorf("rule %s is defined twice", rawRule.ID)
+		}
+		ids[rawRule.ID] = true
+
+		compiled, err := compileRule(rawRule)
+		if err != nil {
+			return nil, fmt.Errorf("rule %s: %w", rawRule.ID, err)
+		}
+		set.rules = append(set.rules, compiled)
+	}
+
+	return set, nil
+}
+
+func compileRule(raw rawRule) (*rule, error) {
+	r := &rule{id: raw.ID, description: raw.Description, selectorText: raw.Selector}
+	// Text assertions without a selector match the text of the whole page
+	if r.selectorText == "" && raw.Text != nil {
+		r.selectorText = "body"
+	}
+
+	// Rules without a severity or category are best practice warnings
+	r.severity, r.category = findings.SeverityWarning, findings.CategoryBestPractices
+	var known bool
+	if raw.Severity != "" {
+		if r.severity, known = findings.ParseSeverity(raw.Severity); !known {
+			return nil, fmt.Errorf("unknown severity %q", raw.Severity)
+		}
+	}
+	if raw.Category != "" {
+		if r.category, known = findings.ParseCategory(raw.Category); !known {
+			return nil, fmt.Errorf("unknown category %q", raw.Category)
+		}
+	}
+
+	if r.selectorText == "" {
+		return nil, errors.New("a selector is required")
+	}
+	selector, err := cascadia.Compile(r.selectorText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", r.selectorText, err)
+	}
+	r.selector = selector
+
+	assertions := 0
+	if raw.Exists != nil {
+		assertions++
+		r.kind = assertExists
+		r.exists = *raw.Exists
+	}
+	if raw.Count != nil {
+		assertions++
+		r.kind = assertCount
+		r.min, r.max = raw.Count.Min, raw.Count.Max
+		if r.min == nil && r.max == nil {
+			return nil, errors.New("count needs a min or a max")
+		}
+		if r.min != nil && r.max != nil && *r.min > *r.max {
+			return nil, errors.New("count min is greater than max")
+		}
+	}
+	if raw.Attribute != nil {
+		assertions++
+		r.kind = assertAttribute
+		r.attribute = raw.Attribute.Name
+		if r.attribute == "" {
+			return nil, errors.New("attribute needs a name")
+		}
+		if r.pattern, r.match, err = compileMatch(raw.Attribute.Pattern, raw.Attribute.Match); err != nil {
+			return nil, err
+		}
+	}
+	if raw.Text != nil {
+		assertions++
+		r.kind = assertText
+		if r.pattern, r.match, err = compileMatch(raw.Text.Pattern, raw.Text.Match); err != nil {
+			return nil, err
+		}
+	}
+
+	if assertions != 1 {
+		return nil, errors.New("a rule needs exactly one of exists, count, attribute or text")
+	}
+
+	return r, nil
+}
+
+func compileMatch(pattern string, match string) (*regexp.Regexp, string, error) {
+	if match == "" {
+		match = MatchAny
+	}
+	if match != MatchAny && match != MatchAll && match != MatchNone {
+		return nil, "", fmt.Errorf("unknown match %q, expected any, all or none", match)
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return re, match, nil
+}
@@ -0,0 +1,204 @@
+package rules
+
+import (
+	"fmt"
+	"lt-app/internal/constants"
+	"lt-app/internal/datafile"
+	"lt-app/internal/findings"
+	"lt-app/internal/utils"
+	"path/filepath"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultEvaluator uses the rule file bundled with the application
+var DefaultEvaluator = NewEvaluator(filepath.Join(utils.GetProjectRoot(), constants.RULES_FILE_PATH))
+
+// Result is the outcome of a single rule
+type Result struct {
+	ID          string            `json:"id"`
+	Description string            `json:"description"`
+	Category    findings.Category `json:"category"`
+	Severity    findings.Severity `json:"severity"`
+	Passed      bool              `json:"passed"`
+	Message     string            `json:"message"`
+	// Number of elements matched by the selector
+	Matches int `json:"matches"`
+}
+
+type Report struct {
+	RulesVersion string   `json:"rulesVersion"`
+	Total        int      `json:"total"`
+	Passed       int      `json:"passed"`
+	Failed       int      `json:"failed"`
+	Results      []Result `json:"results"`
+	// A finding for every failed rule
+	Findings []findings.Finding `json:"findings"`
+}
+
+// Evaluator checks pages against the rules of a YAML or JSON file
+type Evaluator struct {
+	file *datafile.File[*ruleSet]
+}
+
+func NewEvaluator(path string) *Evaluator {
+	return &Evaluator{file: datafile.New(path, parseRules)}
+}
+
+func (e *Evaluator) Evaluate(doc *goquery.Document) *Report {
+	report := &Report{Results: []Result{}, Findings: []findings.Finding{}}
+
+	set := e.file.Get()
+	if set == nil || doc == nil {
+		return report
+	}
+	report.RulesVersion = set.version
+
+	for _, r := range set.rules {
+		result := r.evaluate(doc)
+		report.Results = append(report.Results, result)
+		report.Total++
+
+		if result.Passed {
+			report.Passed++
+			continue
+		}
+
+		report.Failed++
+		report.Findings = append(report.Findings, findings.Finding{
+			ID:       "rule-" + r.id,
+			Category: r.category,
+			Severity: r.severity,
+			Message:  result.Message,
+			Target:   r.selectorText,
+		})
+	}
+
+	return report
+}
+
+func (r *rule) evaluate(doc *goquery.Document) Result {
+	selection := doc.FindMatcher(r.selector)
+
+	result := Result{
+		ID:          r.id,
+		Description: r.description,
+		Category:    r.category,
+		Severity:    r.severity,
+		Matches:     selection.Length(),
+	}
+
+	switch r.kind {
+	case assertExists:
+		result.Passed, result.Message = r.checkExists(result.Matches)
+	case assertCount:
+		result.Passed, result.Message = r.checkCount(result.Matches)
+	case assertAttribute:
+		result.Passed, result.Message = r.checkValues(selection, fmt.Sprintf("a %s attribute", r.attribute), func(s *goquery.Selection) (string, bool) {
+			return s.Attr(r.attribute)
+		})
+	case assertText:
+		result.Passed, result.Message = r.checkValues(selection, "text", func(s *goquery.Selection) (string, bool) {
+			return strings.Join(strings.Fields(s.Text()), " "), true
+		})
+	}
+
+	return result
+}
+
+func (r *rule) subject() string {
+	return fmt.Sprintf("%q", r.selectorText)
+}
+
+func (r *rule) checkExists(matches int) (bool, string) {
+	if r.exists && matches == 0 {
+		return false, fmt.Sprintf("No element matches %s", r.subject())
+	}
+	if !r.exists && matches > 0 {
+		return false, fmt.Sprintf("%s %s %s", elements(matches), verb(matches, "matches", "match"), r.subject())
+	}
+	return true, fmt.Sprintf("%s %s %s", elements(matches), verb(matches, "matches", "match"), r.subject())
+}
+
+func (r *rule) checkCount(matches int) (bool, string) {
+	var expected string
+	switch {
+	case r.min != nil && r.max != nil && *r.min == *r.max:
+		expected = fmt.Sprintf("exactly %d", *r.min)
+	case r.min != nil && r.max != nil:
+		expected = fmt.Sprintf("between %d and %d", *r.min, *r.max)
+	case r.min != nil:
+		expected = fmt.Sprintf("at least %d", *r.min)
+	default:
+		expected = fmt.Sprintf("at most %d", *r.max)
+	}
+
+	passed := (r.min == nil || matches >= *r.min) && (r.max == nil || matches <= *r.max)
+	return passed, fmt.Sprintf("Expected %s elements matching %s, found %d", expected, r.subject(), matches)
+}
+
+// checkValues matches the pattern against a value of every selected element.
+// Elements without the value, such as a missing attribute, do not match.
+func (r *rule) checkValues(selection *goquery.Selection, what string, value func(s *goquery.Selection) (string, bool)) (bool, string) {
+	matching := 0
+	var firstMatch string
+	selection.Each(func(_ int, s *goquery.Selection) {
+		v, exists := value(s)
+		if exists && r.pattern.MatchString(v) {
+			if matching == 0 {
+				firstMatch = v
+			}
+			matching++
+		}
+	})
+	total := selection.Length()
+	pattern := r.pattern.String()
+
+	switch r.match {
+	case MatchAll:
+		// Whether elements are present is left to exists and count
+		if total == 0 {
+			return true, fmt.Sprintf("No element matches %s", r.subject())
+		}
+		if matching < total {
+			missing := total - matching
+			return false, fmt.Sprintf("%d of %s matching %s %s not have %s matching %q", missing, elements(total), r.subject(), verb(missing, "does", "do"), what, pattern)
+		}
+		return true, fmt.Sprintf("All %s matching %s have %s matching %q", elements(total), r.subject(), what, pattern)
+	case MatchNone:
+		if matching > 0 {
+			return false, fmt.Sprintf("%s matching %s %s %s matching %q, e.g. %q", elements(matching), r.subject(), verb(matching, "has", "have"), what, pattern, truncate(firstMatch))
+		}
+		return true, fmt.Sprintf("No element matching %s has %s matching %q", r.subject(), what, pattern)
+	default:
+		if matching == 0 {
+			return false, fmt.Sprintf("No element matching %s has %s matching %q", r.subject(), what, pattern)
+		}
+		return true, fmt.Sprintf("%s matching %s %s %s matching %q", elements(matching), r.subject(), verb(matching, "has", "have"), what, pattern)
+	}
+}
+
+func elements(count int) string {
+	if count == 1 {
+		return "1 element"
+	}
+	return fmt.Sprintf("%d elements", count)
+}
+
+func verb(count int, singular string, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+// truncate keeps messages short when a whole paragraph of text matched
+func truncate(value string) string {
+	const maxLength = 80
+	runes := []rune(value)
+	if len(runes) <= maxLength {
+		return value
+	}
+	return string(runes[:maxLength]) + "..."
+}
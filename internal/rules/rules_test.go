@@ -0,0 +1,238 @@
+package rules
+
+import (
+	"lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/findings"
+	"lt-app/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const testPage = `<html><head>
+	<title>Rules</title>
+	<meta name="author" content="Jane">
+	<link rel="canonical" href="/relative">
+</head><body>
+	<h1>One</h1>
+	<h1>Two</h1>
+	<a href="https://example.com/">Home</a>
+	<a href="https://staging.example.com/beta">Beta</a>
+	<a>No href</a>
+	<p>Copyright 2026 Example Ltd.</p>
+</body></html>`
+
+func parseDoc(t *testing.T, htmlSource string) *goquery.Document {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlSource))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	return doc
+}
+
+func writeRules(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write rules: %v", err)
+	}
+	return path
+}
+
+func evaluate(t *testing.T, ruleFile string) map[string]Result {
+	report := NewEvaluator(writeRules(t, ruleFile)).Evaluate(parseDoc(t, testPage))
+
+	results := make(map[string]Result)
+	for _, result := range report.Results {
+		results[result.ID] = result
+	}
+	return results
+}
+
+func TestEvaluate_Assertions(t *testing.T) {
+	results := evaluate(t, `
+version: "1"
+rules:
+  - { id: author, selector: "meta[name=author]", exists: true }
+  - { id: no-author, selector: "meta[name=author]", exists: false }
+  - { id: robots, selector: "meta[name=robots]", exists: true }
+  - { id: one-h1, selector: h1, count: { min: 1, max: 1 } }
+  - { id: some-h1, selector: h1, count: { min: 1 } }
+  - { id: few-links, selector: "a[href]", count: { max: 5 } }
+  - id: no-staging
+    selector: a
+    attribute: { name: href, pattern: "^https?://staging\\.example\\.com", match: none }
+  - id: https-links
+    selector: a
+    attribute: { name: href, pattern: "^https://", match: all }
+  - id: absolute-canonical
+    selector: "link[rel=canonical]"
+    attribute: { name: href, pattern: "^https?://" }
+  - id: absolute-alternates
+    selector: "link[rel=alternate]"
+    attribute: { name: href, pattern: "^https?://", match: all }
+  - { id: copyright, text: { pattern: "Copyright \\d{4}" } }
+  - { id: no-lorem, text: { pattern: "(?i)lorem ipsum", match: none } }
+  - { id: headings, selector: h1, text: { pattern: "^(One|Two)$", match: all } }
+`)
+
+	tests := []struct {
+		id      string
+		passed  bool
+		matches int
+		message string
+	}{
+		{"author", true, 1, `1 element matches "meta[name=author]"`},
+		{"no-author", false, 1, `1 element matches "meta[name=author]"`},
+		{"robots", false, 0, `No element matches "meta[name=robots]"`},
+		{"one-h1", false, 2, `Expected exactly 1 elements matching "h1", found 2`},
+		{"some-h1", true, 2, `Expected at least 1 elements matching "h1", found 2`},
+		{"few-links", true, 2, `Expected at most 5 elements matching "a[href]", found 2`},
+		{"no-staging", false, 3, `1 element matching "a" has a href attribute matching "^https?://staging\\.example\\.com", e.g. "https://staging.example.com/beta"`},
+		{"https-links", false, 3, `1 of 3 elements matching "a" does not have a href attribute matching "^https://"`},
+		{"absolute-canonical", false, 1, `No element matching "link[rel=canonical]" has a href attribute matching "^https?://"`},
+		{"absolute-alternates", true, 0, `No element matches "link[rel=alternate]"`},
+		{"copyright", true, 1, `1 element matching "body" has text matching "Copyright \\d{4}"`},
+		{"no-lorem", true, 1, `No element matching "body" has text matching "(?i)lorem ipsum"`},
+		{"headings", true, 2, `All 2 elements matching "h1" have text matching "^(One|Two)$"`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			result, found := results[test.id]
+			if !found {
+				t.Fatalf("Expected a result for %s", test.id)
+			}
+			if result.Passed != test.passed {
+				t.Errorf("Expected passed to be %v, got %v (%s)", test.passed, result.Passed, result.Message)
+			}
+			if result.Matches != test.matches {
+				t.Errorf("Expected %d matches, got %d", test.matches, result.Matches)
+			}
+			if result.Message != test.message {
+				t.Errorf("Expected message %q, got %q", test.message, result.Message)
+			}
+		})
+	}
+}
+
+func TestEvaluate_ReportAndFindings(t *testing.T) {
+	ruleFile := `{
+  "version": "json-1",
+  "rules": [
+    { "id": "author", "selector": "meta[name=author]", "exists": true },
+    { "id": "robots", "description": "Robots are told what to do", "severity": "error", "category": "seo", "selector": "meta[name=robots]", "exists": true },
+    { "id": "title", "selector": "title", "count": { "max": 1 } }
+  ]
+}`
+
+	report := NewEvaluator(writeRules(t, ruleFile)).Evaluate(parseDoc(t, testPage))
+
+	if report.RulesVersion != "json-1" || report.Total != 3 || report.Passed != 2 || report.Failed != 1 {
+		t.Errorf("Expected version json-1 with 2 of 3 rules passed, got %+v", report)
+	}
+
+	if len(report.Findings) != 1 {
+		t.Fatalf("Expected 1 finding, got %v", report.Findings)
+	}
+	expected := findings.Finding{
+		ID:       "rule-robots",
+		Category: findings.CategorySEO,
+		Severity: findings.SeverityError,
+		Message:  `No element matches "meta[name=robots]"`,
+		Target:   "meta[name=robots]",
+	}
+	if report.Findings[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, report.Findings[0])
+	}
+
+	if result := report.Results[0]; result.Severity != findings.SeverityWarning || result.Category != findings.CategoryBestPractices {
+		t.Errorf("Expected the default severity and category, got %+v", result)
+	}
+}
+
+func TestParseRules_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"missing id":          `rules: [{ selector: h1, exists: true }]`,
+		"duplicate id":        `rules: [{ id: a, selector: h1, exists: true }, { id: a, selector: h2, exists: true }]`,
+		"no assertion":        `rules: [{ id: a, selector: h1 }]`,
+		"two assertions":      `rules: [{ id: a, selector: h1, exists: true, count: { min: 1 } }]`,
+		"missing selector":    `rules: [{ id: a, exists: true }]`,
+		"invalid selector":    `rules: [{ id: a, selector: "h1[", exists: true }]`,
+		"invalid pattern":     `rules: [{ id: a, selector: a, attribute: { name: href, pattern: "(" } }]`,
+		"missing attribute":   `rules: [{ id: a, selector: a, attribute: { pattern: "x" } }]`,
+		"unknown match":       `rules: [{ id: a, selector: a, text: { pattern: "x", match: some } }]`,
+		"empty count":         `rules: [{ id: a, selector: a, count: {} }]`,
+		"inverted count":      `rules: [{ id: a, selector: a, count: { min: 2, max: 1 } }]`,
+		"unknown severity":    `rules: [{ id: a, selector: a, exists: true, severity: fatal }]`,
+		"unknown category":    `rules: [{ id: a, selector: a, exists: true, category: style }]`,
+		"malformed file":      `rules: [`,
+		"rules is not a list": `rules: 3`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseRules([]byte(content)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestEvaluator_Reload(t *testing.T) {
+	applogger.InitLogger()
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	evaluator := NewEvaluator(path)
+	doc := parseDoc(t, testPage)
+
+	if report := evaluator.Evaluate(doc); report.Total != 0 {
+		t.Errorf("Expected no results without a rule file, got %+v", report.Results)
+	}
+
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write the rules: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set the modification time: %v", err)
+		}
+	}
+
+	now := time.Now()
+	write(`rules: [{ id: first, selector: h1, exists: true }]`, now)
+
+	if report := evaluator.Evaluate(doc); report.Total != 1 || report.Results[0].ID != "first" {
+		t.Errorf("Expected the first rule, got %+v", report.Results)
+	}
+
+	write(`rules: [{ id: second, selector: h2, exists: true }]`, now.Add(time.Second))
+
+	if report := evaluator.Evaluate(doc); report.Total != 1 || report.Results[0].ID != "second" || report.Failed != 1 {
+		t.Errorf("Expected the updated rules to be used, got %+v", report.Results)
+	}
+
+	write(`rules: [{ id: broken, selector: "h1[", exists: true }]`, now.Add(2*time.Second))
+
+	if report := evaluator.Evaluate(doc); report.Total != 1 || report.Results[0].ID != "second" {
+		t.Errorf("Expected the previous rules to be kept when the file is invalid, got %+v", report.Results)
+	}
+}
+
+func TestParseRules_BundledFile(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join(utils.GetProjectRoot(), constants.RULES_FILE_PATH))
+	if err != nil {
+		t.Fatalf("Failed to read the bundled rules: %v", err)
+	}
+
+	set, err := parseRules(content)
+	if err != nil {
+		t.Fatalf("Expected the bundled rules to be valid, got %v", err)
+	}
+	if len(set.rules) == 0 {
+		t.Errorf("Expected bundled rules")
+	}
+}
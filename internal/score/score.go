@@ -0,0 +1,151 @@
+package score
+
+import (
+	"lt-app/internal/constants"
+	"lt-app/internal/datafile"
+	"lt-app/internal/findings"
+	"lt-app/internal/utils"
+	"math"
+	"path/filepath"
+	"sort"
+)
+
+// DefaultScorer uses the weights bundled with the application
+var DefaultScorer = NewScorer(filepath.Join(utils.GetProjectRoot(), constants.SCORE_WEIGHTS_FILE_PATH))
+
+// Categories in the order they are reported
+var Categories = []findings.Category{
+	findings.CategorySEO,
+	findings.CategoryAccessibility,
+	findings.CategoryLinks,
+	findings.CategorySecurity,
+	findings.CategoryBestPractices,
+}
+
+type CategoryScore struct {
+	Category findings.Category `json:"category"`
+	Score    int               `json:"score"`
+	// Share of the category in the overall score
+	Weight   float64 `json:"weight"`
+	Findings int     `json:"findings"`
+}
+
+// Contribution is a finding and every repeat of it, e.g. one per broken link
+type Contribution struct {
+	ID       string            `json:"id"`
+	Category findings.Category `json:"category"`
+	// The highest severity of the repeats
+	Severity findings.Severity `json:"severity"`
+	Message  string            `json:"message"`
+	Count    int               `json:"count"`
+	// Targets of the first repeats
+	Targets []string `json:"targets"`
+	// Points taken from the category score
+	Penalty float64 `json:"penalty"`
+}
+
+type Report struct {
+	WeightsVersion string          `json:"weightsVersion"`
+	Score          int             `json:"score"`
+	Grade          string          `json:"grade"`
+	Categories     []CategoryScore `json:"categories"`
+	// Sorted by penalty, largest first
+	Contributions []Contribution `json:"contributions"`
+}
+
+// Scorer scores pages with the weights of a YAML file
+type Scorer struct {
+	file *datafile.File[*Weights]
+}
+
+func NewScorer(path string) *Scorer {
+	return &Scorer{file: datafile.New(path, parseWeights)}
+}
+
+// Score starts every category at 100 and takes the penalty of each finding
+// from its category. The overall score is the weighted mean of the categories.
+func (s *Scorer) Score(pageFindings []findings.Finding) *Report {
+	weights := s.file.Get()
+	if weights == nil {
+		weights = DefaultWeights()
+	}
+
+	report := &Report{WeightsVersion: weights.Version, Contributions: []Contribution{}}
+
+	byID := make(map[string]*Contribution)
+	var order []string
+	for _, finding := range pageFindings {
+		key := string(finding.Category) + "/" + finding.ID
+		contribution, exists := byID[key]
+		if !exists {
+			contribution = &Contribution{ID: finding.ID, Category: finding.Category, Severity: finding.Severity, Message: finding.Message, Targets: []string{}}
+			byID[key] = contribution
+			order = append(order, key)
+		}
+
+		contribution.Count++
+		if weights.Severities[finding.Severity] > weights.Severities[contribution.Severity] {
+			contribution.Severity = finding.Severity
+			contribution.Message = finding.Message
+		}
+		if finding.Target != "" && len(contribution.Targets) < constants.SCORE_TARGETS_MAX_CAP {
+			contribution.Targets = append(contribution.Targets, finding.Target)
+		}
+	}
+
+	penalties := make(map[findings.Category]float64)
+	counts := make(map[findings.Category]int)
+	for _, key := range order {
+		contribution := byID[key]
+		contribution.Penalty = weights.penalty(contribution.Severity, contribution.Count)
+		penalties[contribution.Category] += contribution.Penalty
+		counts[contribution.Category] += contribution.Count
+		report.Contributions = append(report.Contributions, *contribution)
+	}
+	sort.SliceStable(report.Contributions, func(i, j int) bool {
+		return report.Contributions[i].Penalty > report.Contributions[j].Penalty
+	})
+
+	var weighted, totalWeight float64
+	for _, category := range Categories {
+		categoryScore := math.Max(0, 100-penalties[category])
+		weight := weights.Categories[category]
+		report.Categories = append(report.Categories, CategoryScore{
+			Category: category,
+			Score:    int(math.Round(categoryScore)),
+			Weight:   weight,
+			Findings: counts[category],
+		})
+		weighted += categoryScore * weight
+		totalWeight += weight
+	}
+
+	if totalWeight > 0 {
+		report.Score = int(math.Round(weighted / totalWeight))
+	}
+	report.Grade = grade(report.Score)
+
+	return report
+}
+
+// penalty of a finding and its repeats. Each repeat adds a share of the penalty,
+// so fifty broken links weigh more than one but do not zero the category alone.
+func (w *Weights) penalty(severity findings.Severity, count int) float64 {
+	repeats := min(count-1, w.MaxRepeats)
+	return w.Severities[severity] * (1 + w.RepeatFactor*float64(repeats))
+}
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 45:
+		return "D"
+	default:
+		return "F"
+	}
+}
@@ -0,0 +1,160 @@
+package score
+
+import (
+	"lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/findings"
+	"lt-app/internal/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func finding(id string, category findings.Category, severity findings.Severity, target string) findings.Finding {
+	return findings.Finding{ID: id, Category: category, Severity: severity, Message: id, Target: target}
+}
+
+func scoreOf(report *Report, category findings.Category) int {
+	for _, categoryScore := range report.Categories {
+		if categoryScore.Category == category {
+			return categoryScore.Score
+		}
+	}
+	return -1
+}
+
+func TestScore(t *testing.T) {
+	applogger.InitLogger()
+	// A missing file falls back to the default weights
+	scorer := NewScorer(filepath.Join(t.TempDir(), "missing.yaml"))
+
+	pageFindings := []findings.Finding{
+		finding("title-missing", findings.CategorySEO, findings.SeverityError, ""),
+		finding("link-generic-text", findings.CategorySEO, findings.SeverityWarning, "a #1"),
+		finding("link-generic-text", findings.CategorySEO, findings.SeverityWarning, "a #2"),
+		finding("link-empty", findings.CategoryAccessibility, findings.SeverityError, "a #3"),
+	}
+	for i := 0; i < 20; i++ {
+		pageFindings = append(pageFindings, finding("link-broken", findings.CategoryLinks, findings.SeverityError, "https://example.com/broken"))
+	}
+
+	report := scorer.Score(pageFindings)
+
+	// SEO: 100 - 15 - 5 * 1.25, accessibility: 100 - 15, links: 100 - 15 * (1 + 0.25 * 8)
+	expectedCategories := map[findings.Category]int{
+		findings.CategorySEO:           79,
+		findings.CategoryAccessibility: 85,
+		findings.CategoryLinks:         55,
+		findings.CategorySecurity:      100,
+		findings.CategoryBestPractices: 100,
+	}
+	for category, expected := range expectedCategories {
+		if got := scoreOf(report, category); got != expected {
+			t.Errorf("Expected %s to score %d, got %d", category, expected, got)
+		}
+	}
+
+	// (78.75 * 25 + 85 * 20 + 55 * 15 + 100 * 25 + 100 * 15) / 100
+	if report.Score != 85 || report.Grade != "B" || report.WeightsVersion != "default" {
+		t.Errorf("Expected a score of 85 (B) with the default weights, got %d (%s) %s", report.Score, report.Grade, report.WeightsVersion)
+	}
+
+	if len(report.Contributions) != 4 {
+		t.Fatalf("Expected 4 contributions, got %+v", report.Contributions)
+	}
+	top := report.Contributions[0]
+	if top.ID != "link-broken" || top.Count != 20 || top.Penalty != 45 || len(top.Targets) != constants.SCORE_TARGETS_MAX_CAP {
+		t.Errorf("Expected the broken links to cost the most, got %+v", top)
+	}
+	if generic := report.Contributions[3]; generic.ID != "link-generic-text" || generic.Count != 2 || !reflect.DeepEqual(generic.Targets, []string{"a #1", "a #2"}) {
+		t.Errorf("Expected the generic link text last, got %+v", generic)
+	}
+}
+
+func TestScore_NoFindings(t *testing.T) {
+	applogger.InitLogger()
+	report := NewScorer(filepath.Join(t.TempDir(), "missing.yaml")).Score(nil)
+
+	if report.Score != 100 || report.Grade != "A" || len(report.Categories) != len(Categories) || len(report.Contributions) != 0 {
+		t.Errorf("Expected a perfect score, got %+v", report)
+	}
+}
+
+func TestScore_HighestSeverityOfRepeats(t *testing.T) {
+	applogger.InitLogger()
+	report := NewScorer(filepath.Join(t.TempDir(), "missing.yaml")).Score([]findings.Finding{
+		{ID: "html-unclosed-tag", Category: findings.CategoryBestPractices, Severity: findings.SeverityWarning, Message: "Unclosed p"},
+		{ID: "html-unclosed-tag", Category: findings.CategoryBestPractices, Severity: findings.SeverityError, Message: "Unclosed div"},
+	})
+
+	contribution := report.Contributions[0]
+	if contribution.Severity != findings.SeverityError || contribution.Message != "Unclosed div" || contribution.Penalty != 18.75 {
+		t.Errorf("Expected the error to be counted with one repeat, got %+v", contribution)
+	}
+}
+
+func TestScorer_CustomWeights(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "score.yaml")
+	content := `
+version: "custom"
+categories: { security: 1 }
+severities: { warning: 50 }
+repeatFactor: 0
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write the weights: %v", err)
+	}
+
+	report := NewScorer(path).Score([]findings.Finding{
+		finding("csp-missing", findings.CategorySecurity, findings.SeverityWarning, ""),
+		finding("csp-missing", findings.CategorySecurity, findings.SeverityWarning, ""),
+		finding("title-missing", findings.CategorySEO, findings.SeverityError, ""),
+	})
+
+	// Only security counts, and the repeat adds nothing
+	if report.Score != 50 || report.WeightsVersion != "custom" {
+		t.Errorf("Expected only the security score of 50 to count, got %d", report.Score)
+	}
+	if seo := scoreOf(report, findings.CategorySEO); seo != 85 {
+		t.Errorf("Expected SEO to be scored with the default error weight, got %d", seo)
+	}
+}
+
+func TestParseWeights_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"unknown category":  `categories: { style: 10 }`,
+		"unknown severity":  `severities: { fatal: 10 }`,
+		"negative category": `categories: { seo: -1 }`,
+		"negative severity": `severities: { error: -1 }`,
+		"negative repeats":  `maxRepeats: -1`,
+		"negative factor":   `repeatFactor: -0.5`,
+		"no weight":         `categories: { seo: 0 }`,
+		"malformed":         `categories: [`,
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := parseWeights([]byte(content)); err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestParseWeights_BundledFile(t *testing.T) {
+	content, err := os.ReadFile(filepath.Join(utils.GetProjectRoot(), constants.SCORE_WEIGHTS_FILE_PATH))
+	if err != nil {
+		t.Fatalf("Failed to read the bundled weights: %v", err)
+	}
+
+	weights, err := parseWeights(content)
+	if err != nil {
+		t.Fatalf("Expected the bundled weights to be valid, got %v", err)
+	}
+
+	defaults := DefaultWeights()
+	if !reflect.DeepEqual(weights.Categories, defaults.Categories) || !reflect.DeepEqual(weights.Severities, defaults.Severities) {
+		t.Errorf("Expected the bundled weights to match the defaults, got %+v", weights)
+	}
+}
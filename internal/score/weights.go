@@ -0,0 +1,112 @@
+package score
+
+import (
+	"errors"
+	"fmt"
+	"lt-app/internal/findings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Weights configure how much findings cost and how categories add up
+type Weights struct {
+	Version string
+	// Relative weight of each category in the overall score. Categories that are
+	// left out are still scored but do not count towards the overall score.
+	Categories map[findings.Category]float64
+	// Points a finding of each severity takes from its category
+	Severities map[findings.Severity]float64
+	// Share of the penalty added by each repeat of a finding
+	RepeatFactor float64
+	// Repeats beyond this add nothing
+	MaxRepeats int
+}
+
+type rawWeights struct {
+	Version      string             `yaml:"version"`
+	Categories   map[string]float64 `yaml:"categories"`
+	Severities   map[string]float64 `yaml:"severities"`
+	RepeatFactor *float64           `yaml:"repeatFactor"`
+	MaxRepeats   *int               `yaml:"maxRepeats"`
+}
+
+// DefaultWeights are used when the weights file cannot be read
+func DefaultWeights() *Weights {
+	return &Weights{
+		Version: "default",
+		Categories: map[findings.Category]float64{
+			findings.CategorySEO:           25,
+			findings.CategoryAccessibility: 20,
+			findings.CategoryLinks:         15,
+			findings.CategorySecurity:      25,
+			findings.CategoryBestPractices: 15,
+		},
+		Severities: map[findings.Severity]float64{
+			findings.SeverityError:   15,
+			findings.SeverityWarning: 5,
+			findings.SeverityInfo:    1,
+		},
+		RepeatFactor: 0.25,
+		MaxRepeats:   8,
+	}
+}
+
+// parseWeights rejects unknown categories and severities and negative weights.
+// Values that are left out keep their defaults.
+func parseWeights(content []byte) (*Weights, error) {
+	var raw rawWeights
+	if err := yaml.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	weights := DefaultWeights()
+	weights.Version = raw.Version
+
+	if raw.Categories != nil {
+		weights.Categories = make(map[findings.Category]float64, len(raw.Categories))
+		for name, weight := range raw.Categories {
+			category, known := findings.ParseCategory(name)
+			if !known {
+				return nil, fmt.Errorf("unknown category %q", name)
+			}
+			if weight < 0 {
+				return nil, fmt.Errorf("category %s has a negative weight", name)
+			}
+			weights.Categories[category] = weight
+		}
+	}
+
+	for name, weight := range raw.Severities {
+		severity, known := findings.ParseSeverity(name)
+		if !known {
+			return nil, fmt.Errorf("unknown severity %q", name)
+		}
+		if weight < 0 {
+			return nil, fmt.Errorf("severity %s has a negative weight", name)
+		}
+		weights.Severities[severity] = weight
+	}
+
+	if raw.RepeatFactor != nil {
+		if *raw.RepeatFactor < 0 {
+			return nil, errors.New("repeatFactor must not be negative")
+		}
+		weights.RepeatFactor = *raw.RepeatFactor
+	}
+	if raw.MaxRepeats != nil {
+		if *raw.MaxRepeats < 0 {
+			return nil, errors.New("maxRepeats must not be negative")
+		}
+		weights.MaxRepeats = *raw.MaxRepeats
+	}
+
+	totalWeight := 0.0
+	for _, weight := range weights.Categories {
+		totalWeight += weight
+	}
+	if totalWeight == 0 {
+		return nil, errors.New("at least one category needs a weight above zero")
+	}
+
+	return weights, nil
+}
@@ -0,0 +1,223 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lt-app/internal/constants"
+	"lt-app/internal/linkquality"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"golang.org/x/net/html"
+)
+
+// contextKey is the thread local holding the context of the run, cancelled when the
+// script runs out of time
+const contextKey = "context"
+
+// Page is what scripts can see of the page
+type Page struct {
+	URL         string
+	Title       string
+	HTMLVersion string
+	Headers     http.Header
+	Headings    map[string]int
+	Links       []linkquality.Link
+	Doc         *goquery.Document
+}
+
+// value returns the read-only view of the page that is passed to analyze(page).
+// Every value is frozen, so scripts cannot change it.
+func (p Page) value() starlark.Value {
+	headers := starlark.NewDict(len(p.Headers))
+	for _, name := range sortedKeys(p.Headers) {
+		_ = headers.SetKey(starlark.String(strings.ToLower(name)), stringList(p.Headers[name]))
+	}
+
+	headings := starlark.NewDict(len(p.Headings))
+	for _, tag := range sortedKeys(p.Headings) {
+		_ = headings.SetKey(starlark.String(tag), starlark.MakeInt(p.Headings[tag]))
+	}
+
+	links := make([]starlark.Value, 0, len(p.Links))
+	for _, link := range p.Links {
+		links = append(links, starlarkstruct.FromStringDict(starlark.String("link"), starlark.StringDict{
+			"href":               starlark.String(link.Href),
+			"text":               starlark.String(link.Text),
+			"aria_label":         starlark.String(link.AriaLabel),
+			"title":              starlark.String(link.Title),
+			"rel":                stringList(link.Rel),
+			"target":             starlark.String(link.Target),
+			"images":             starlark.MakeInt(link.Images),
+			"images_without_alt": starlark.MakeInt(link.ImagesWithoutAlt),
+		}))
+	}
+
+	var root *goquery.Selection
+	if p.Doc != nil {
+		root = p.Doc.Selection
+	}
+
+	page := starlarkstruct.FromStringDict(starlark.String("page"), starlark.StringDict{
+		"url":          starlark.String(p.URL),
+		"title":        starlark.String(p.Title),
+		"html_version": starlark.String(p.HTMLVersion),
+		"headers":      headers,
+		"headings":     headings,
+		"links":        starlark.NewList(links),
+		"select":       selectBuiltin(root),
+	})
+	page.Freeze()
+	return page
+}
+
+// selectBuiltin returns select(css), which lists the elements below the selection
+// that match a CSS selector
+func selectBuiltin(root *goquery.Selection) *starlark.Builtin {
+	return starlark.NewBuiltin("select", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var css string
+		if err := starlark.UnpackPositionalArgs(fn.Name(), args, kwargs, 1, &css); err != nil {
+			return nil, err
+		}
+
+		selector, err := cascadia.Compile(css)
+		if err != nil {
+			return nil, fmt.Errorf("select: invalid css selector %q: %w", css, err)
+		}
+
+		// A selection counts as a single step, so the builtin checks the time limit itself
+		ctx, _ := thread.Local(contextKey).(context.Context)
+		elements := []starlark.Value{}
+		if root != nil {
+			root.FindMatcher(selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+				if i >= constants.SCRIPT_SELECT_MAX_ELEMENTS || ctx != nil && ctx.Err() != nil {
+					return false
+				}
+				elements = append(elements, &element{s: s})
+				return true
+			})
+		}
+		if ctx != nil && ctx.Err() != nil {
+			return nil, fmt.Errorf("select: %w", ctx.Err())
+		}
+
+		list := starlark.NewList(elements)
+		list.Freeze()
+		return list, nil
+	})
+}
+
+// element is an element returned by select(css). Its text and html are only built
+// when a script reads them.
+type element struct {
+	s *goquery.Selection
+}
+
+var elementAttrs = []string{"attrs", "html", "select", "tag", "text"}
+
+func (e *element) String() string        { return "<element " + goquery.NodeName(e.s) + ">" }
+func (e *element) Type() string          { return "element" }
+func (e *element) Freeze()               {}
+func (e *element) Truth() starlark.Bool  { return starlark.True }
+func (e *element) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable type: element") }
+func (e *element) AttrNames() []string   { return elementAttrs }
+
+func (e *element) Attr(name string) (starlark.Value, error) {
+	switch name {
+	case "tag":
+		return starlark.String(goquery.NodeName(e.s)), nil
+	case "text":
+		return starlark.String(strings.Join(strings.Fields(limitedText(e.s.Get(0))), " ")), nil
+	case "html":
+		return starlark.String(limitedHTML(e.s.Get(0))), nil
+	case "attrs":
+		attrs := starlark.NewDict(0)
+		if node := e.s.Get(0); node != nil {
+			for _, attr := range node.Attr {
+				_ = attrs.SetKey(starlark.String(attr.Key), starlark.String(attr.Val))
+			}
+		}
+		attrs.Freeze()
+		return attrs, nil
+	case "select":
+		return selectBuiltin(e.s), nil
+	}
+	return nil, nil
+}
+
+// limitedText returns the text below the node, up to SCRIPT_ELEMENT_CONTENT_MAX_BYTES
+func limitedText(node *html.Node) string {
+	var text strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for ; n != nil && text.Len() < constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES; n = n.NextSibling {
+			if n.Type == html.TextNode {
+				text.WriteString(n.Data)
+			}
+			walk(n.FirstChild)
+		}
+	}
+	if node != nil {
+		walk(node.FirstChild)
+	}
+	return truncate(text.String())
+}
+
+// limitedHTML renders the node, stopping once SCRIPT_ELEMENT_CONTENT_MAX_BYTES are written
+func limitedHTML(node *html.Node) string {
+	if node == nil {
+		return ""
+	}
+	w := &limitedWriter{}
+	_ = html.Render(w, node)
+	return truncate(w.String())
+}
+
+type limitedWriter struct {
+	strings.Builder
+}
+
+var errContentLimit = errors.New("content limit reached")
+
+func (w *limitedWriter) Write(p []byte) (int, error) {
+	if w.Len() >= constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES {
+		return 0, errContentLimit
+	}
+	return w.Builder.Write(p)
+}
+
+// truncate cuts content to SCRIPT_ELEMENT_CONTENT_MAX_BYTES without splitting a character
+func truncate(content string) string {
+	if len(content) <= constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES {
+		return content
+	}
+	end := constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES
+	for end > 0 && !utf8.RuneStart(content[end]) {
+		end--
+	}
+	return content[:end]
+}
+
+func stringList(values []string) *starlark.List {
+	list := make([]starlark.Value, 0, len(values))
+	for _, value := range values {
+		list = append(list, starlark.String(value))
+	}
+	return starlark.NewList(list)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,279 @@
+package scripts
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"lt-app/internal/constants"
+	"lt-app/internal/findings"
+	"lt-app/internal/utils"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+// Scripts are Starlark files with this extension
+const scriptExtension = ".star"
+
+// DefaultRunner runs the scripts bundled with the application
+var DefaultRunner = NewRunner(filepath.Join(utils.GetProjectRoot(), constants.SCRIPTS_DIR_PATH))
+
+// Result is the outcome of a single script
+type Result struct {
+	Name     string             `json:"name"`
+	Findings []findings.Finding `json:"findings"`
+	// Lines written with print(), for debugging scripts
+	Output     []string `json:"output"`
+	Steps      uint64   `json:"steps"`
+	DurationMs float64  `json:"durationMs"`
+	// Set when the script could not be compiled, failed or ran out of time or steps
+	Error string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Scripts []Result `json:"scripts"`
+	// The findings of every script
+	Findings []findings.Finding `json:"findings"`
+}
+
+// Runner runs the scripts of a directory against pages. Scripts are compiled again
+// when they change.
+type Runner struct {
+	dir      string
+	timeout  time.Duration
+	maxSteps uint64
+
+	mu       sync.Mutex
+	compiled map[string]*compiledScript
+}
+
+type compiledScript struct {
+	modTime time.Time
+	program *starlark.Program
+	err     error
+}
+
+func NewRunner(dir string) *Runner {
+	return &Runner{
+		dir:      dir,
+		timeout:  constants.SCRIPT_TIMEOUT_MS * time.Millisecond,
+		maxSteps: constants.SCRIPT_MAX_EXECUTION_STEPS,
+		compiled: make(map[string]*compiledScript),
+	}
+}
+
+// Run runs every script concurrently. A failing script is reported in its result
+// and does not affect the others.
+func (r *Runner) Run(ctx context.Context, page Page) *Report {
+	report := &Report{Scripts: []Result{}, Findings: []findings.Finding{}}
+
+	paths, err := filepath.Glob(filepath.Join(r.dir, "*"+scriptExtension))
+	if err != nil || len(paths) == 0 {
+		return report
+	}
+	sort.Strings(paths)
+
+	pageValue := page.value()
+	report.Scripts = make([]Result, len(paths))
+
+	var wg sync.WaitGroup
+	for i, path := range paths {
+		wg.Add(1)
+		go func(i int, path string) {
+			defer wg.Done()
+			report.Scripts[i] = r.runScript(ctx, path, pageValue)
+		}(i, path)
+	}
+	wg.Wait()
+
+	for _, result := range report.Scripts {
+		report.Findings = append(report.Findings, result.Findings...)
+	}
+	return report
+}
+
+func (r *Runner) runScript(ctx context.Context, path string, page starlark.Value) Result {
+	result := Result{
+		Name:     strings.TrimSuffix(filepath.Base(path), scriptExtension),
+		Findings: []findings.Finding{},
+		Output:   []string{},
+	}
+
+	program, err := r.program(path)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	thread := &starlark.Thread{
+		Name: result.Name,
+		Print: func(_ *starlark.Thread, msg string) {
+			if len(result.Output) < constants.SCRIPT_OUTPUT_MAX_LINES {
+				result.Output = append(result.Output, msg)
+			}
+		},
+		// Scripts cannot load other files
+		Load: func(_ *starlark.Thread, module string) (starlark.StringDict, error) {
+			return nil, fmt.Errorf("cannot load %s: scripts cannot load modules", module)
+		},
+	}
+	thread.SetMaxExecutionSteps(r.maxSteps)
+
+	// The thread stops at the next step once it is cancelled
+	runCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	thread.SetLocal(contextKey, runCtx)
+	stop := context.AfterFunc(runCtx, func() {
+		thread.Cancel(fmt.Sprintf("exceeded the %v time limit", r.timeout))
+	})
+	defer stop()
+
+	start := time.Now()
+	result.Findings, err = execute(thread, program, page)
+	result.Steps = thread.ExecutionSteps()
+	result.DurationMs = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		result.Findings = []findings.Finding{}
+		result.Error = describe(err)
+	}
+	return result
+}
+
+// execute runs the top level of the script and then calls analyze(page)
+func execute(thread *starlark.Thread, program *starlark.Program, page starlark.Value) ([]findings.Finding, error) {
+	globals, err := program.Init(thread, predeclared)
+	if err != nil {
+		return nil, err
+	}
+
+	analyze, ok := globals["analyze"].(starlark.Callable)
+	if !ok {
+		return nil, errors.New("the script does not define analyze(page)")
+	}
+
+	returned, err := starlark.Call(thread, analyze, starlark.Tuple{page}, nil)
+	if err != nil {
+		return nil, err
+	}
+	return toFindings(returned)
+}
+
+// program returns the compiled script, compiling it again when the file changed
+func (r *Runner) program(path string) (*starlark.Program, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if cached, exists := r.compiled[path]; exists && cached.modTime.Equal(info.ModTime()) {
+		return cached.program, cached.err
+	}
+
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	options := &syntax.FileOptions{Set: true, While: true, TopLevelControl: true, GlobalReassign: true}
+	_, program, err := starlark.SourceProgramOptions(options, filepath.Base(path), source, predeclared.Has)
+	r.compiled[path] = &compiledScript{modTime: info.ModTime(), program: program, err: err}
+	return program, err
+}
+
+// describe includes where a script failed
+func describe(err error) string {
+	var evalErr *starlark.EvalError
+	if errors.As(err, &evalErr) {
+		return evalErr.Backtrace()
+	}
+	return err.Error()
+}
+
+var findingConstructor = starlark.String("finding")
+
+// predeclared are the names scripts can use besides the Starlark built-ins
+var predeclared = starlark.StringDict{
+	"finding": starlark.NewBuiltin("finding", newFinding),
+}
+
+// newFinding is finding(id, message, severity="warning", category="best-practices", target="")
+func newFinding(_ *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+	var id, message, target string
+	severity, category := string(findings.SeverityWarning), string(findings.CategoryBestPractices)
+	if err := starlark.UnpackArgs(fn.Name(), args, kwargs,
+		"id", &id, "message", &message, "severity?", &severity, "category?", &category, "target?", &target); err != nil {
+		return nil, err
+	}
+
+	if id == "" {
+		return nil, errors.New("finding: id must not be empty")
+	}
+	if _, known := findings.ParseSeverity(severity); !known {
+		return nil, fmt.Errorf("finding: unknown severity %q, expected info, warning or error", severity)
+	}
+	if _, known := findings.ParseCategory(category); !known {
+		return nil, fmt.Errorf("finding: unknown category %q", category)
+	}
+
+	return starlarkstruct.FromStringDict(findingConstructor, starlark.StringDict{
+		"id":       starlark.String(id),
+		"message":  starlark.String(message),
+		"severity": starlark.String(severity),
+		"category": starlark.String(category),
+		"target":   starlark.String(target),
+	}), nil
+}
+
+// toFindings converts what analyze(page) returned, a list of finding() values or None
+func toFindings(value starlark.Value) ([]findings.Finding, error) {
+	if value == starlark.None {
+		return []findings.Finding{}, nil
+	}
+
+	iterable, ok := value.(starlark.Iterable)
+	if !ok {
+		return nil, fmt.Errorf("analyze(page) returned %s, expected a list of findings", value.Type())
+	}
+
+	result := []findings.Finding{}
+	iterator := iterable.Iterate()
+	defer iterator.Done()
+
+	var item starlark.Value
+	for iterator.Next(&item) {
+		s, ok := item.(*starlarkstruct.Struct)
+		if !ok || s.Constructor() != findingConstructor {
+			return nil, fmt.Errorf("analyze(page) returned %s, expected values created with finding()", item.Type())
+		}
+		if len(result) >= constants.SCRIPT_FINDINGS_MAX_CAP {
+			break
+		}
+
+		severity, _ := findings.ParseSeverity(structString(s, "severity"))
+		category, _ := findings.ParseCategory(structString(s, "category"))
+		result = append(result, findings.Finding{
+			ID:       "script-" + structString(s, "id"),
+			Category: category,
+			Severity: severity,
+			Message:  structString(s, "message"),
+			Target:   structString(s, "target"),
+		})
+	}
+	return result, nil
+}
+
+func structString(s *starlarkstruct.Struct, name string) string {
+	value, _ := s.Attr(name)
+	str, _ := starlark.AsString(value)
+	return str
+}
@@ -0,0 +1,249 @@
+package scripts
+
+import (
+	"context"
+	"lt-app/internal/constants"
+	"lt-app/internal/findings"
+	"lt-app/internal/linkquality"
+	"lt-app/internal/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+	"go.starlark.net/starlark"
+)
+
+func testPage(t *testing.T) Page {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<html><head><title>Shop</title></head><body>
+		<h1>Kettles</h1>
+		<div class="product" data-sku="KT-1"><img src="a.png"><span class="price">39.90</span></div>
+		<div class="product" data-sku="KT-2"><img src="b.png" alt="Kettle"></div>
+	</body></html>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("Content-Type", "text/html")
+	headers.Add("Set-Cookie", "a=1")
+	headers.Add("Set-Cookie", "b=2")
+
+	return Page{
+		URL:         "https://example.com/kettles",
+		Title:       "Shop",
+		HTMLVersion: "html5",
+		Headers:     headers,
+		Headings:    map[string]int{"h1": 1},
+		Links:       []linkquality.Link{{Href: "/", Text: "Home", Rel: []string{"nofollow"}}},
+		Doc:         doc,
+	}
+}
+
+func writeScripts(t *testing.T, scripts map[string]string) string {
+	dir := t.TempDir()
+	for name, source := range scripts {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(source), 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+func TestRun_PageView(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"products.star": `
+def analyze(page):
+    result = []
+    for product in page.select(".product"):
+        images = product.select("img")
+        missing = [img for img in images if "alt" not in img.attrs]
+        if missing:
+            result.append(finding("product-image-alt", "Product image without alt", severity="error",
+                                  category="accessibility", target=product.attrs["data-sku"]))
+        if not product.select(".price"):
+            result.append(finding("product-price", "Product without a price", target=product.attrs["data-sku"]))
+    print(page.title, page.url, page.html_version, page.headings["h1"])
+    print(page.headers["content-type"][0], len(page.headers["set-cookie"]))
+    print(page.links[0].href, page.links[0].text, page.links[0].rel)
+    print(page.select("h1")[0].tag, page.select("h1")[0].text, page.select(".price")[0].html)
+    return result
+`})
+
+	report := NewRunner(dir).Run(context.Background(), testPage(t))
+
+	if len(report.Scripts) != 1 {
+		t.Fatalf("Expected 1 script result, got %+v", report.Scripts)
+	}
+	result := report.Scripts[0]
+	if result.Name != "products" || result.Error != "" || result.Steps == 0 {
+		t.Errorf("Expected the products script to succeed, got %+v", result)
+	}
+
+	expectedFindings := []findings.Finding{
+		{ID: "script-product-image-alt", Category: findings.CategoryAccessibility, Severity: findings.SeverityError, Message: "Product image without alt", Target: "KT-1"},
+		{ID: "script-product-price", Category: findings.CategoryBestPractices, Severity: findings.SeverityWarning, Message: "Product without a price", Target: "KT-2"},
+	}
+	if !reflect.DeepEqual(result.Findings, expectedFindings) || !reflect.DeepEqual(report.Findings, expectedFindings) {
+		t.Errorf("Expected %+v, got %+v", expectedFindings, report.Findings)
+	}
+
+	expectedOutput := []string{
+		"Shop https://example.com/kettles html5 1",
+		"text/html 2",
+		`/ Home ["nofollow"]`,
+		`h1 Kettles <span class="price">39.90</span>`,
+	}
+	if !reflect.DeepEqual(result.Output, expectedOutput) {
+		t.Errorf("Expected output %q, got %q", expectedOutput, result.Output)
+	}
+}
+
+func TestRun_Failures(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"a-good.star":        `def analyze(page): return [finding("ok", "Fine", severity="info")]`,
+		"b-syntax.star":      `def analyze(page) return []`,
+		"c-no-analyze.star":  `x = 1`,
+		"d-fails.star":       "def analyze(page):\n    return 1 // 0",
+		"e-mutates.star":     "def analyze(page):\n    page.links.append(1)",
+		"f-bad-return.star":  `def analyze(page): return ["not a finding"]`,
+		"g-bad-finding.star": `def analyze(page): return [finding("x", "y", severity="fatal")]`,
+		"h-load.star":        "load(\"other.star\", \"x\")\ndef analyze(page): return []",
+		"i-css.star":         `def analyze(page): return page.select("a[")`,
+		"j-none.star":        `def analyze(page): pass`,
+		"not-a-script.txt":   `ignored`,
+	})
+
+	report := NewRunner(dir).Run(context.Background(), testPage(t))
+
+	expectedErrors := map[string]string{
+		"a-good":        "",
+		"b-syntax":      "got return, want ':'",
+		"c-no-analyze":  "does not define analyze(page)",
+		"d-fails":       "division by zero",
+		"e-mutates":     "frozen list",
+		"f-bad-return":  "expected values created with finding()",
+		"g-bad-finding": `unknown severity "fatal"`,
+		"h-load":        "scripts cannot load modules",
+		"i-css":         "invalid css selector",
+		"j-none":        "",
+	}
+
+	if len(report.Scripts) != len(expectedErrors) {
+		t.Fatalf("Expected %d script results, got %d", len(expectedErrors), len(report.Scripts))
+	}
+	for _, result := range report.Scripts {
+		expected, exists := expectedErrors[result.Name]
+		if !exists {
+			t.Errorf("Unexpected script %s", result.Name)
+			continue
+		}
+		if expected == "" && result.Error != "" || !strings.Contains(result.Error, expected) {
+			t.Errorf("%s: expected an error containing %q, got %q", result.Name, expected, result.Error)
+		}
+	}
+
+	// Failing scripts do not affect the others
+	expectedFindings := []findings.Finding{{ID: "script-ok", Category: findings.CategoryBestPractices, Severity: findings.SeverityInfo, Message: "Fine"}}
+	if !reflect.DeepEqual(report.Findings, expectedFindings) {
+		t.Errorf("Expected %+v, got %+v", expectedFindings, report.Findings)
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	dir := writeScripts(t, map[string]string{
+		"loop.star":  "def analyze(page):\n    while True:\n        pass",
+		"steps.star": "def analyze(page):\n    for i in range(1000000):\n        pass",
+	})
+
+	runner := NewRunner(dir)
+	runner.timeout = 100 * time.Millisecond
+	runner.maxSteps = 10000
+
+	start := time.Now()
+	report := runner.Run(context.Background(), testPage(t))
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the scripts to be stopped, they ran for %v", elapsed)
+	}
+
+	for _, result := range report.Scripts {
+		if !strings.Contains(result.Error, "too many steps") && !strings.Contains(result.Error, "time limit") {
+			t.Errorf("%s: expected the script to be stopped, got %+v", result.Name, result)
+		}
+	}
+
+	// Without a step limit the loop runs until the time limit
+	runner.maxSteps = 0
+	report = runner.Run(context.Background(), testPage(t))
+	if !strings.Contains(report.Scripts[0].Error, "exceeded the 100ms time limit") {
+		t.Errorf("Expected the loop to run out of time, got %+v", report.Scripts[0])
+	}
+}
+
+func TestRun_Recompiles(t *testing.T) {
+	dir := writeScripts(t, map[string]string{"check.star": `def analyze(page): return [finding("first", "First")]`})
+	path := filepath.Join(dir, "check.star")
+	runner := NewRunner(dir)
+
+	if report := runner.Run(context.Background(), testPage(t)); len(report.Findings) != 1 || report.Findings[0].ID != "script-first" {
+		t.Fatalf("Expected the first finding, got %+v", report.Findings)
+	}
+
+	if err := os.WriteFile(path, []byte(`def analyze(page): return [finding("second", "Second")]`), 0o644); err != nil {
+		t.Fatalf("Failed to update the script: %v", err)
+	}
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatalf("Failed to set the modification time: %v", err)
+	}
+
+	if report := runner.Run(context.Background(), testPage(t)); len(report.Findings) != 1 || report.Findings[0].ID != "script-second" {
+		t.Errorf("Expected the updated script to run, got %+v", report.Findings)
+	}
+}
+
+func TestRun_BundledScripts(t *testing.T) {
+	report := NewRunner(filepath.Join(utils.GetProjectRoot(), constants.SCRIPTS_DIR_PATH)).Run(context.Background(), testPage(t))
+
+	if len(report.Scripts) == 0 {
+		t.Errorf("Expected bundled scripts")
+	}
+	for _, result := range report.Scripts {
+		if result.Error != "" {
+			t.Errorf("%s: expected no error, got %s", result.Name, result.Error)
+		}
+	}
+}
+
+func TestElement_ContentLimit(t *testing.T) {
+	long := strings.Repeat("é", constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES)
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader("<p>" + long + "</p>"))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+	e := &element{s: doc.Find("p")}
+
+	for _, name := range []string{"text", "html"} {
+		value, _ := e.Attr(name)
+		content := string(value.(starlark.String))
+		if len(content) > constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES || len(content) < constants.SCRIPT_ELEMENT_CONTENT_MAX_BYTES-1 || !utf8.ValidString(content) {
+			t.Errorf("%s: expected valid content truncated to the limit, got %d bytes", name, len(content))
+		}
+	}
+}
+
+func TestSelect_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	thread := &starlark.Thread{}
+	thread.SetLocal(contextKey, ctx)
+
+	_, err := starlark.Call(thread, selectBuiltin(testPage(t).Doc.Selection), starlark.Tuple{starlark.String("div")}, nil)
+	if err == nil || !strings.Contains(err.Error(), "context canceled") {
+		t.Errorf("Expected select to stop once the run is cancelled, got %v", err)
+	}
+}
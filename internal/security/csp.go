@@ -0,0 +1,63 @@
+package security
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ParseCSP splits a Content-Security-Policy into its directives. Directive names are
+// lower cased and, as browsers do, only the first occurrence of a directive is kept.
+func ParseCSP(policy string) map[string][]string {
+	directives := make(map[string][]string)
+
+	for _, part := range strings.Split(policy, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		name := strings.ToLower(fields[0])
+		if _, exists := directives[name]; !exists {
+			directives[name] = fields[1:]
+		}
+	}
+
+	return directives
+}
+
+// SplitCSPPolicies returns the policies of Content-Security-Policy values. A single
+// header may carry several policies separated by commas, each of them enforced.
+func SplitCSPPolicies(values []string) []string {
+	var policies []string
+	for _, value := range values {
+		for _, policy := range strings.Split(value, ",") {
+			if strings.TrimSpace(policy) != "" {
+				policies = append(policies, policy)
+			}
+		}
+	}
+	return policies
+}
+
+// MetaCSPPolicies returns the policies declared with <meta http-equiv="Content-Security-Policy">
+func MetaCSPPolicies(doc *goquery.Document) []string {
+	var policies []string
+
+	doc.Find("meta[http-equiv]").Each(func(_ int, s *goquery.Selection) {
+		if strings.EqualFold(strings.TrimSpace(s.AttrOr("http-equiv", "")), "content-security-policy") {
+			policies = append(policies, s.AttrOr("content", ""))
+		}
+	})
+
+	return policies
+}
+
+func hasDirective(policies []string, directive string) bool {
+	for _, policy := range SplitCSPPolicies(policies) {
+		if _, exists := ParseCSP(policy)[directive]; exists {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,514 @@
+package security
+
+import (
+	"fmt"
+	"lt-app/internal/findings"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Maximum points each check contributes to the header score. They add up to 100.
+const (
+	cspPoints               = 25
+	hstsPoints              = 20
+	contentTypeOptionPoints = 10
+	framingPoints           = 15
+	referrerPolicyPoints    = 10
+	permissionsPolicyPoints = 10
+	cookiePoints            = 10
+)
+
+// HSTS max-age browsers and the preload list consider long enough
+const (
+	hstsMinMaxAge     = 15768000 // 6 months
+	hstsPreloadMaxAge = 31536000 // 1 year
+)
+
+type HeaderCheck struct {
+	Name     string `json:"name"`
+	Present  bool   `json:"present"`
+	Value    string `json:"value"`
+	Score    int    `json:"score"`
+	MaxScore int    `json:"maxScore"`
+}
+
+type CSPReport struct {
+	Present bool `json:"present"`
+	// Only a Content-Security-Policy-Report-Only header was sent, which is not enforced
+	ReportOnly bool                `json:"reportOnly"`
+	Directives map[string][]string `json:"directives"`
+}
+
+type HSTSReport struct {
+	Present           bool  `json:"present"`
+	MaxAge            int64 `json:"maxAge"`
+	IncludeSubDomains bool  `json:"includeSubDomains"`
+	Preload           bool  `json:"preload"`
+}
+
+type CookieReport struct {
+	Name     string `json:"name"`
+	Secure   bool   `json:"secure"`
+	HttpOnly bool   `json:"httpOnly"`
+	SameSite string `json:"sameSite"`
+}
+
+type HeaderReport struct {
+	Score    int                `json:"score"`
+	Grade    string             `json:"grade"`
+	Checks   []HeaderCheck      `json:"checks"`
+	CSP      *CSPReport         `json:"csp"`
+	HSTS     *HSTSReport        `json:"hsts"`
+	Cookies  []CookieReport     `json:"cookies"`
+	Findings []findings.Finding `json:"findings"`
+}
+
+// Referrer policies that leak the full URL to other origins
+var weakReferrerPolicies = map[string]bool{
+	"unsafe-url":                 true,
+	"no-referrer-when-downgrade": true,
+	"origin-when-cross-origin":   true,
+	"origin":                     true,
+}
+
+var validReferrerPolicies = map[string]bool{
+	"no-referrer":                     true,
+	"no-referrer-when-downgrade":      true,
+	"same-origin":                     true,
+	"origin":                          true,
+	"strict-origin":                   true,
+	"origin-when-cross-origin":        true,
+	"strict-origin-when-cross-origin": true,
+	"unsafe-url":                      true,
+}
+
+// AuditHeaders grades the security headers of the response the page was served with
+func AuditHeaders(pageUrl string, headers http.Header) *HeaderReport {
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	parsed, _ := url.Parse(pageUrl)
+	isHTTPS := parsed != nil && strings.EqualFold(parsed.Scheme, "https")
+
+	report := &HeaderReport{Checks: []HeaderCheck{}, Cookies: []CookieReport{}, Findings: []findings.Finding{}}
+
+	report.auditCSP(headers)
+	report.auditHSTS(headers, isHTTPS)
+	report.auditContentTypeOptions(headers)
+	report.auditFraming(headers)
+	report.auditReferrerPolicy(headers)
+	report.auditPermissionsPolicy(headers)
+	report.auditCookies(headers, isHTTPS)
+
+	for _, check := range report.Checks {
+		report.Score += check.Score
+	}
+	report.Grade = grade(report.Score)
+
+	return report
+}
+
+func (r *HeaderReport) addCheck(name string, value string, score int, maxScore int) {
+	r.Checks = append(r.Checks, HeaderCheck{
+		Name:     name,
+		Present:  value != "",
+		Value:    value,
+		Score:    max(score, 0),
+		MaxScore: maxScore,
+	})
+}
+
+func (r *HeaderReport) addFinding(id string, severity findings.Severity, target string, message string) {
+	r.Findings = append(r.Findings, findings.Finding{
+		ID:       id,
+		Category: findings.CategorySecurity,
+		Severity: severity,
+		Message:  message,
+		Target:   target,
+	})
+}
+
+func (r *HeaderReport) auditCSP(headers http.Header) {
+	const name = "Content-Security-Policy"
+	policies := SplitCSPPolicies(headers.Values(name))
+	value := strings.Join(headers.Values(name), ", ")
+	r.CSP = &CSPReport{Present: len(policies) > 0, Directives: map[string][]string{}}
+
+	if len(policies) == 0 {
+		if reportOnly := SplitCSPPolicies(headers.Values("Content-Security-Policy-Report-Only")); len(reportOnly) > 0 {
+			r.CSP.ReportOnly = true
+			for _, policy := range reportOnly {
+				for directive, sources := range ParseCSP(policy) {
+					r.CSP.Directives[directive] = append(r.CSP.Directives[directive], sources...)
+				}
+			}
+			r.addFinding("header-csp-report-only", findings.SeverityWarning, name,
+				"Content-Security-Policy is only sent in report-only mode and is not enforced")
+		} else {
+			r.addFinding("header-csp-missing", findings.SeverityWarning, name,
+				"No Content-Security-Policy header is set")
+		}
+		r.addCheck(name, value, 0, cspPoints)
+		return
+	}
+
+	// Multiple policies are all enforced. Their directives are merged for reporting.
+	for _, policy := range policies {
+		for directive, sources := range ParseCSP(policy) {
+			r.CSP.Directives[directive] = append(r.CSP.Directives[directive], sources...)
+		}
+	}
+
+	// Each policy is evaluated on its own. A resource is only loaded when every
+	// policy allows it, so a weakness counts when every policy has it.
+	weak := evaluateCSP(ParseCSP(policies[0]))
+	for _, policy := range policies[1:] {
+		other := evaluateCSP(ParseCSP(policy))
+		weak.unrestrictedScripts = weak.unrestrictedScripts && other.unrestrictedScripts
+		weak.inlineScripts = weak.inlineScripts && other.inlineScripts
+		weak.eval = weak.eval && other.eval
+		weak.unrestrictedObjects = weak.unrestrictedObjects && other.unrestrictedObjects
+		if other.wildcard == "" {
+			weak.wildcard = ""
+		}
+	}
+
+	score := cspPoints
+	if weak.unrestrictedScripts {
+		score -= 10
+		r.addFinding("header-csp-no-script-restriction", findings.SeverityWarning, name,
+			"Content-Security-Policy has neither a script-src nor a default-src directive, so scripts are not restricted")
+	} else {
+		if weak.inlineScripts {
+			score -= 10
+			r.addFinding("header-csp-unsafe-inline", findings.SeverityWarning, name,
+				"Content-Security-Policy allows inline scripts with 'unsafe-inline'")
+		}
+		if weak.eval {
+			score -= 5
+			r.addFinding("header-csp-unsafe-eval", findings.SeverityWarning, name,
+				"Content-Security-Policy allows eval() with 'unsafe-eval'")
+		}
+	}
+
+	if weak.wildcard != "" {
+		score -= 10
+		r.addFinding("header-csp-wildcard", findings.SeverityWarning, name,
+			"Content-Security-Policy "+weak.wildcard)
+	}
+
+	if weak.unrestrictedObjects {
+		r.addFinding("header-csp-no-object-src", findings.SeverityInfo, name,
+			"Content-Security-Policy does not restrict plugins with object-src")
+	}
+
+	// A policy that is present still earns some points for restricting other resource types
+	r.addCheck(name, value, max(score, 5), cspPoints)
+}
+
+func (r *HeaderReport) auditHSTS(headers http.Header, isHTTPS bool) {
+	const name = "Strict-Transport-Security"
+	value := headers.Get(name)
+	r.HSTS = &HSTSReport{}
+
+	if !isHTTPS {
+		r.addFinding("header-hsts-not-https", findings.SeverityError, name,
+			"The page is not served over HTTPS, so Strict-Transport-Security cannot be used")
+		r.addCheck(name, "", 0, hstsPoints)
+		return
+	}
+
+	if value == "" {
+		r.addFinding("header-hsts-missing", findings.SeverityWarning, name,
+			"No Strict-Transport-Security header is set")
+		r.addCheck(name, value, 0, hstsPoints)
+		return
+	}
+
+	r.HSTS.Present = true
+	for _, directive := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "max-age":
+			r.HSTS.MaxAge, _ = strconv.ParseInt(strings.Trim(strings.TrimSpace(val), `"`), 10, 64)
+		case "includesubdomains":
+			r.HSTS.IncludeSubDomains = true
+		case "preload":
+			r.HSTS.Preload = true
+		}
+	}
+
+	score := 0
+	switch {
+	case r.HSTS.MaxAge <= 0:
+		r.addFinding("header-hsts-disabled", findings.SeverityWarning, name,
+			"Strict-Transport-Security has a max-age of 0, which removes the HSTS policy")
+	case r.HSTS.MaxAge < hstsMinMaxAge:
+		score = 8
+		r.addFinding("header-hsts-short-max-age", findings.SeverityWarning, name,
+			fmt.Sprintf("Strict-Transport-Security max-age of %d seconds is shorter than 6 months", r.HSTS.MaxAge))
+	default:
+		score = 15
+	}
+
+	if score > 0 && r.HSTS.IncludeSubDomains {
+		score += 3
+	}
+	if score > 0 && r.HSTS.Preload {
+		if r.HSTS.IncludeSubDomains && r.HSTS.MaxAge >= hstsPreloadMaxAge {
+			score += 2
+		} else {
+			r.addFinding("header-hsts-preload-ineligible", findings.SeverityInfo, name,
+				"Strict-Transport-Security asks for preloading but preloading requires includeSubDomains and a max-age of at least one year")
+		}
+	}
+
+	r.addCheck(name, value, score, hstsPoints)
+}
+
+func (r *HeaderReport) auditContentTypeOptions(headers http.Header) {
+	const name = "X-Content-Type-Options"
+	value := headers.Get(name)
+
+	if strings.EqualFold(strings.TrimSpace(value), "nosniff") {
+		r.addCheck(name, value, contentTypeOptionPoints, contentTypeOptionPoints)
+		return
+	}
+
+	r.addFinding("header-x-content-type-options-missing", findings.SeverityWarning, name,
+		"X-Content-Type-Options is not set to nosniff, so browsers may guess content types")
+	r.addCheck(name, value, 0, contentTypeOptionPoints)
+}
+
+// auditFraming checks clickjacking protection. CSP frame-ancestors supersedes X-Frame-Options.
+func (r *HeaderReport) auditFraming(headers http.Header) {
+	const name = "X-Frame-Options"
+	value := headers.Get(name)
+
+	if frameAncestors, exists := r.CSP.Directives["frame-ancestors"]; exists && r.CSP.Present {
+		r.addCheck(name, "frame-ancestors "+strings.Join(frameAncestors, " "), framingPoints, framingPoints)
+		return
+	}
+
+	switch strings.ToUpper(strings.TrimSpace(value)) {
+	case "DENY", "SAMEORIGIN":
+		r.addCheck(name, value, framingPoints, framingPoints)
+	case "":
+		r.addFinding("header-framing-missing", findings.SeverityWarning, name,
+			"Neither X-Frame-Options nor CSP frame-ancestors is set, so the page can be framed by any site")
+		r.addCheck(name, value, 0, framingPoints)
+	default:
+		r.addFinding("header-x-frame-options-invalid", findings.SeverityWarning, name,
+			fmt.Sprintf("X-Frame-Options value %q is not supported by current browsers; use CSP frame-ancestors", value))
+		r.addCheck(name, value, 5, framingPoints)
+	}
+}
+
+func (r *HeaderReport) auditReferrerPolicy(headers http.Header) {
+	const name = "Referrer-Policy"
+	value := headers.Get(name)
+
+	// Browsers apply the last policy in the list they recognise
+	policy := ""
+	for _, token := range strings.Split(value, ",") {
+		token = strings.ToLower(strings.TrimSpace(token))
+		if validReferrerPolicies[token] {
+			policy = token
+		}
+	}
+
+	switch {
+	case policy == "":
+		r.addFinding("header-referrer-policy-missing", findings.SeverityInfo, name,
+			"No Referrer-Policy is set; browsers default to strict-origin-when-cross-origin")
+		r.addCheck(name, value, 5, referrerPolicyPoints)
+	case weakReferrerPolicies[policy]:
+		severity := findings.SeverityInfo
+		if policy == "unsafe-url" {
+			severity = findings.SeverityWarning
+		}
+		r.addFinding("header-referrer-policy-weak", severity, name,
+			fmt.Sprintf("Referrer-Policy %q sends referrer information to other origins", policy))
+		r.addCheck(name, value, 3, referrerPolicyPoints)
+	default:
+		r.addCheck(name, value, referrerPolicyPoints, referrerPolicyPoints)
+	}
+}
+
+func (r *HeaderReport) auditPermissionsPolicy(headers http.Header) {
+	const name = "Permissions-Policy"
+
+	if value := headers.Get(name); value != "" {
+		r.addCheck(name, value, permissionsPolicyPoints, permissionsPolicyPoints)
+		return
+	}
+
+	if featurePolicy := headers.Get("Feature-Policy"); featurePolicy != "" {
+		r.addFinding("header-feature-policy-deprecated", findings.SeverityInfo, name,
+			"Feature-Policy is deprecated; use Permissions-Policy")
+		r.addCheck(name, featurePolicy, 5, permissionsPolicyPoints)
+		return
+	}
+
+	r.addFinding("header-permissions-policy-missing", findings.SeverityInfo, name,
+		"No Permissions-Policy is set to restrict browser features")
+	r.addCheck(name, "", 0, permissionsPolicyPoints)
+}
+
+// auditCookies checks the Secure, HttpOnly and SameSite flags of every cookie set
+// by the page. A page that sets no cookies gets the full score.
+func (r *HeaderReport) auditCookies(headers http.Header, isHTTPS bool) {
+	const name = "Set-Cookie"
+	lines := headers.Values(name)
+
+	if len(lines) == 0 {
+		r.addCheck(name, "", cookiePoints, cookiePoints)
+		return
+	}
+
+	flagsSet, flagsExpected := 0, 0
+	for _, line := range lines {
+		cookie, err := http.ParseSetCookie(line)
+		if err != nil {
+			continue
+		}
+
+		cookieReport := CookieReport{
+			Name:     cookie.Name,
+			Secure:   cookie.Secure,
+			HttpOnly: cookie.HttpOnly,
+			SameSite: sameSiteName(cookie.SameSite),
+		}
+		r.Cookies = append(r.Cookies, cookieReport)
+		target := "cookie " + cookie.Name
+
+		flagsExpected += 3
+		if cookie.Secure {
+			flagsSet++
+		} else if isHTTPS {
+			r.addFinding("header-cookie-not-secure", findings.SeverityWarning, target,
+				fmt.Sprintf("Cookie %q is set without the Secure flag", cookie.Name))
+		}
+		if cookie.HttpOnly {
+			flagsSet++
+		} else {
+			r.addFinding("header-cookie-not-httponly", findings.SeverityInfo, target,
+				fmt.Sprintf("Cookie %q is readable by scripts because it lacks the HttpOnly flag", cookie.Name))
+		}
+
+		switch {
+		case cookieReport.SameSite == "none" && !cookie.Secure:
+			r.addFinding("header-cookie-samesite-none-insecure", findings.SeverityError, target,
+				fmt.Sprintf("Cookie %q uses SameSite=None without Secure and is rejected by browsers", cookie.Name))
+		case cookieReport.SameSite == "":
+			r.addFinding("header-cookie-no-samesite", findings.SeverityInfo, target,
+				fmt.Sprintf("Cookie %q has no SameSite attribute; browsers default to Lax", cookie.Name))
+		default:
+			flagsSet++
+		}
+	}
+
+	score := cookiePoints
+	if flagsExpected > 0 {
+		score = cookiePoints * flagsSet / flagsExpected
+	}
+	r.addCheck(name, strings.Join(lines, "\n"), score, cookiePoints)
+}
+
+func sameSiteName(mode http.SameSite) string {
+	switch mode {
+	case http.SameSiteLaxMode:
+		return "lax"
+	case http.SameSiteStrictMode:
+		return "strict"
+	case http.SameSiteNoneMode:
+		return "none"
+	default:
+		return ""
+	}
+}
+
+// cspWeaknesses is what a single policy fails to restrict
+type cspWeaknesses struct {
+	unrestrictedScripts bool
+	// Set as well when scripts are not restricted at all
+	inlineScripts bool
+	eval          bool
+	// Describes the first directive that allows any source, if there is one
+	wildcard            string
+	unrestrictedObjects bool
+}
+
+func evaluateCSP(directives map[string][]string) cspWeaknesses {
+	var weak cspWeaknesses
+	scriptSources, restrictsScripts := directives["script-src"]
+	if !restrictsScripts {
+		scriptSources, restrictsScripts = directives["default-src"]
+	}
+
+	weak.unrestrictedScripts = !restrictsScripts
+	// 'unsafe-inline' is ignored by browsers when a nonce or hash is present
+	weak.inlineScripts = !restrictsScripts || containsSource(scriptSources, "'unsafe-inline'") && !hasNonceOrHash(scriptSources)
+	weak.eval = !restrictsScripts || containsSource(scriptSources, "'unsafe-eval'")
+
+	for _, directive := range []string{"default-src", "script-src", "object-src"} {
+		if wildcard := wildcardSource(directives[directive]); wildcard != "" {
+			weak.wildcard = fmt.Sprintf("%s allows any source with %s", directive, wildcard)
+			break
+		}
+	}
+
+	_, hasObjectSrc := directives["object-src"]
+	_, hasDefaultSrc := directives["default-src"]
+	weak.unrestrictedObjects = !hasObjectSrc && !hasDefaultSrc
+	return weak
+}
+
+func containsSource(sources []string, source string) bool {
+	for _, s := range sources {
+		if strings.EqualFold(s, source) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasNonceOrHash(sources []string) bool {
+	for _, s := range sources {
+		lower := strings.ToLower(s)
+		if strings.HasPrefix(lower, "'nonce-") || strings.HasPrefix(lower, "'sha256-") ||
+			strings.HasPrefix(lower, "'sha384-") || strings.HasPrefix(lower, "'sha512-") {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardSource returns the first source that matches any host, if there is one
+func wildcardSource(sources []string) string {
+	for _, s := range sources {
+		switch strings.ToLower(s) {
+		case "*", "http:", "https:", "data:":
+			return s
+		}
+	}
+	return ""
+}
+
+func grade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 75:
+		return "B"
+	case score >= 60:
+		return "C"
+	case score >= 45:
+		return "D"
+	default:
+		return "F"
+	}
+}
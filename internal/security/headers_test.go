@@ -0,0 +1,235 @@
+package security
+
+import (
+	"net/http"
+	"testing"
+)
+
+func findingIDs(report *HeaderReport) map[string]bool {
+	ids := make(map[string]bool)
+	for _, finding := range report.Findings {
+		ids[finding.ID] = true
+	}
+	return ids
+}
+
+func checkScore(report *HeaderReport, name string) int {
+	for _, check := range report.Checks {
+		if check.Name == name {
+			return check.Score
+		}
+	}
+	return -1
+}
+
+func TestAuditHeaders_StrongHeaders(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Content-Security-Policy", "default-src 'self'; script-src 'self' 'nonce-abc' 'unsafe-inline'; object-src 'none'; frame-ancestors 'none'")
+	headers.Set("Strict-Transport-Security", "max-age=63072000; includeSubDomains; preload")
+	headers.Set("X-Content-Type-Options", "nosniff")
+	headers.Set("Referrer-Policy", "no-referrer, strict-origin-when-cross-origin")
+	headers.Set("Permissions-Policy", "camera=(), microphone=()")
+	headers.Add("Set-Cookie", "session=abc; Path=/; Secure; HttpOnly; SameSite=Lax")
+
+	report := AuditHeaders("https://example.com/", headers)
+
+	if report.Score != 100 || report.Grade != "A" {
+		t.Errorf("Expected a score of 100 and grade A, got %d and %s (findings %v)", report.Score, report.Grade, report.Findings)
+	}
+	if len(report.Findings) != 0 {
+		t.Errorf("Expected no findings, got %v", report.Findings)
+	}
+	if !report.HSTS.Present || report.HSTS.MaxAge != 63072000 || !report.HSTS.IncludeSubDomains || !report.HSTS.Preload {
+		t.Errorf("Unexpected HSTS report %+v", report.HSTS)
+	}
+	if len(report.Cookies) != 1 || report.Cookies[0].SameSite != "lax" {
+		t.Errorf("Unexpected cookies %+v", report.Cookies)
+	}
+}
+
+func TestAuditHeaders_MissingHeaders(t *testing.T) {
+	report := AuditHeaders("https://example.com/", nil)
+
+	expectedIDs := []string{
+		"header-csp-missing",
+		"header-hsts-missing",
+		"header-x-content-type-options-missing",
+		"header-framing-missing",
+		"header-referrer-policy-missing",
+		"header-permissions-policy-missing",
+	}
+
+	ids := findingIDs(report)
+	for _, id := range expectedIDs {
+		if !ids[id] {
+			t.Errorf("Expected finding %q, got %v", id, report.Findings)
+		}
+	}
+
+	// Only the default referrer policy and the absence of cookies earn points
+	if report.Score != 15 || report.Grade != "F" {
+		t.Errorf("Expected a score of 15 and grade F, got %d and %s", report.Score, report.Grade)
+	}
+}
+
+func TestAuditHeaders_WeakCSP(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("Content-Security-Policy", "default-src *; script-src 'self' 'unsafe-inline' 'unsafe-eval'")
+
+	report := AuditHeaders("https://example.com/", headers)
+	ids := findingIDs(report)
+
+	for _, id := range []string{"header-csp-unsafe-inline", "header-csp-unsafe-eval", "header-csp-wildcard"} {
+		if !ids[id] {
+			t.Errorf("Expected finding %q, got %v", id, report.Findings)
+		}
+	}
+	if ids["header-csp-no-object-src"] {
+		t.Errorf("Expected default-src to cover object-src")
+	}
+	if score := checkScore(report, "Content-Security-Policy"); score != 5 {
+		t.Errorf("Expected the minimum CSP score of 5, got %d", score)
+	}
+}
+
+func TestAuditHeaders_MultipleCSP(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []string
+		expected []string
+		score    int
+	}{
+		{
+			name:     "Stricter policy blocks inline scripts",
+			policies: []string{"script-src 'self' 'unsafe-inline'", "default-src 'self'"},
+			expected: []string{},
+			score:    25,
+		},
+		{
+			name:     "Weakness of every policy",
+			policies: []string{"script-src 'self' 'unsafe-inline'", "img-src 'self'"},
+			expected: []string{"header-csp-unsafe-inline", "header-csp-no-object-src"},
+			score:    15,
+		},
+		{
+			name:     "Wildcard allowed by one policy only",
+			policies: []string{"default-src *", "default-src 'self'; script-src 'self' 'unsafe-eval'"},
+			expected: []string{},
+			score:    25,
+		},
+		{
+			name:     "Policies in a single header",
+			policies: []string{"script-src 'self', object-src 'none'"},
+			expected: []string{},
+			score:    25,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := http.Header{}
+			for _, policy := range test.policies {
+				headers.Add("Content-Security-Policy", policy)
+			}
+
+			report := AuditHeaders("https://example.com/", headers)
+
+			var cspFindings []string
+			for _, finding := range report.Findings {
+				if finding.Target == "Content-Security-Policy" {
+					cspFindings = append(cspFindings, finding.ID)
+				}
+			}
+			if len(cspFindings) != len(test.expected) {
+				t.Errorf("Expected findings %v, got %v", test.expected, cspFindings)
+			}
+			ids := findingIDs(report)
+			for _, id := range test.expected {
+				if !ids[id] {
+					t.Errorf("Expected finding %q, got %v", id, cspFindings)
+				}
+			}
+			if score := checkScore(report, "Content-Security-Policy"); score != test.score {
+				t.Errorf("Expected CSP score %d, got %d", test.score, score)
+			}
+		})
+	}
+}
+
+func TestAuditHeaders_HSTS(t *testing.T) {
+	tests := []struct {
+		name       string
+		pageUrl    string
+		value      string
+		expectedID string
+		score      int
+	}{
+		{"Short max-age", "https://example.com", "max-age=3600", "header-hsts-short-max-age", 8},
+		{"Disabled", "https://example.com", "max-age=0", "header-hsts-disabled", 0},
+		{"Preload without subdomains", "https://example.com", "max-age=31536000; preload", "header-hsts-preload-ineligible", 15},
+		{"Plain HTTP page", "http://example.com", "max-age=31536000", "header-hsts-not-https", 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := http.Header{}
+			headers.Set("Strict-Transport-Security", test.value)
+
+			report := AuditHeaders(test.pageUrl, headers)
+
+			if !findingIDs(report)[test.expectedID] {
+				t.Errorf("Expected finding %q, got %v", test.expectedID, report.Findings)
+			}
+			if score := checkScore(report, "Strict-Transport-Security"); score != test.score {
+				t.Errorf("Expected HSTS score %d, got %d", test.score, score)
+			}
+		})
+	}
+}
+
+func TestAuditHeaders_Framing(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		score   int
+	}{
+		{"X-Frame-Options DENY", map[string]string{"X-Frame-Options": "DENY"}, 15},
+		{"Deprecated ALLOW-FROM", map[string]string{"X-Frame-Options": "ALLOW-FROM https://example.org"}, 5},
+		{"CSP frame-ancestors", map[string]string{"Content-Security-Policy": "frame-ancestors 'self'"}, 15},
+		{"Report-only frame-ancestors", map[string]string{"Content-Security-Policy-Report-Only": "frame-ancestors 'self'"}, 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			headers := http.Header{}
+			for name, value := range test.headers {
+				headers.Set(name, value)
+			}
+
+			if score := checkScore(AuditHeaders("https://example.com", headers), "X-Frame-Options"); score != test.score {
+				t.Errorf("Expected framing score %d, got %d", test.score, score)
+			}
+		})
+	}
+}
+
+func TestAuditHeaders_Cookies(t *testing.T) {
+	headers := http.Header{}
+	headers.Add("Set-Cookie", "a=1; Secure; HttpOnly; SameSite=Strict")
+	headers.Add("Set-Cookie", "b=2; SameSite=None")
+	headers.Set("Referrer-Policy", "unsafe-url")
+
+	report := AuditHeaders("https://example.com", headers)
+	ids := findingIDs(report)
+
+	for _, id := range []string{"header-cookie-not-secure", "header-cookie-not-httponly", "header-cookie-samesite-none-insecure", "header-referrer-policy-weak"} {
+		if !ids[id] {
+			t.Errorf("Expected finding %q, got %v", id, report.Findings)
+		}
+	}
+
+	// 3 of the 6 expected cookie flags are set
+	if score := checkScore(report, "Set-Cookie"); score != 5 {
+		t.Errorf("Expected cookie score 5, got %d", score)
+	}
+}
@@ -0,0 +1,142 @@
+package security
+
+import (
+	"fmt"
+	"lt-app/internal/findings"
+	"lt-app/internal/forms"
+	"lt-app/internal/resources"
+	"net/url"
+	"strings"
+)
+
+type MixedContentKind string
+
+const (
+	// Active content (scripts, stylesheets, frames, ...) is blocked by browsers
+	MixedContentActive MixedContentKind = "active"
+	// Passive content (images, audio, video) is displayed, or upgraded to HTTPS by current browsers
+	MixedContentPassive MixedContentKind = "passive"
+)
+
+type MixedContentItem struct {
+	URL       string           `json:"url"`
+	Kind      MixedContentKind `json:"kind"`
+	Type      string           `json:"type"`
+	Element   string           `json:"element"`
+	Attribute string           `json:"attribute"`
+	// The browser requests the resource over HTTPS instead of loading it over HTTP
+	AutoUpgraded bool `json:"autoUpgraded"`
+}
+
+type MixedContentReport struct {
+	// Mixed content only exists on pages served over HTTPS
+	Applicable              bool               `json:"applicable"`
+	UpgradeInsecureRequests bool               `json:"upgradeInsecureRequests"`
+	ActiveCount             int                `json:"activeCount"`
+	PassiveCount            int                `json:"passiveCount"`
+	Items                   []MixedContentItem `json:"items"`
+	Findings                []findings.Finding `json:"findings"`
+}
+
+// CheckMixedContent reports the subresources and form actions of an HTTPS page that
+// are requested over plain HTTP. cspPolicies are the Content-Security-Policies that
+// apply to the page, used to detect the upgrade-insecure-requests directive.
+func CheckMixedContent(pageUrl string, pageResources []resources.Resource, pageForms []forms.Form, cspPolicies []string) *MixedContentReport {
+	report := &MixedContentReport{Items: []MixedContentItem{}, Findings: []findings.Finding{}}
+
+	parsed, err := url.Parse(pageUrl)
+	if err != nil || !strings.EqualFold(parsed.Scheme, "https") {
+		return report
+	}
+
+	report.Applicable = true
+	report.UpgradeInsecureRequests = hasDirective(cspPolicies, "upgrade-insecure-requests")
+
+	for _, resource := range pageResources {
+		if !isHTTP(resource.URL) {
+			continue
+		}
+
+		kind := MixedContentActive
+		if isUpgradeable(resource) {
+			kind = MixedContentPassive
+		}
+
+		report.addItem(MixedContentItem{
+			URL:          resource.URL,
+			Kind:         kind,
+			Type:         string(resource.Type),
+			Element:      resource.Element,
+			Attribute:    resource.Attribute,
+			AutoUpgraded: report.UpgradeInsecureRequests || kind == MixedContentPassive,
+		})
+	}
+
+	// Submitting a form sends data, so an insecure action is treated as active content
+	for _, form := range pageForms {
+		if !isHTTP(form.Action) {
+			continue
+		}
+
+		report.addItem(MixedContentItem{
+			URL:          form.Action,
+			Kind:         MixedContentActive,
+			Type:         "form",
+			Element:      "form",
+			Attribute:    "action",
+			AutoUpgraded: report.UpgradeInsecureRequests,
+		})
+	}
+
+	return report
+}
+
+func (r *MixedContentReport) addItem(item MixedContentItem) {
+	r.Items = append(r.Items, item)
+	source := fmt.Sprintf("<%s %s>", item.Element, item.Attribute)
+
+	finding := findings.Finding{Category: findings.CategorySecurity, Target: item.URL}
+
+	switch {
+	case r.UpgradeInsecureRequests:
+		finding.Severity = findings.SeverityInfo
+		finding.Message = fmt.Sprintf("%s uses HTTP but is upgraded to HTTPS by the upgrade-insecure-requests directive", source)
+	case item.Kind == MixedContentActive:
+		finding.Severity = findings.SeverityError
+		finding.Message = fmt.Sprintf("%s is requested over HTTP and blocked by browsers", source)
+	default:
+		finding.Severity = findings.SeverityWarning
+		finding.Message = fmt.Sprintf("%s is requested over HTTP; browsers upgrade it to HTTPS and it breaks if HTTPS is not available", source)
+	}
+
+	if item.Kind == MixedContentActive {
+		r.ActiveCount++
+		finding.ID = "mixed-content-active"
+		finding.Message = "Active mixed content: " + finding.Message
+	} else {
+		r.PassiveCount++
+		finding.ID = "mixed-content-passive"
+		finding.Message = "Passive mixed content: " + finding.Message
+	}
+
+	r.Findings = append(r.Findings, finding)
+}
+
+// isUpgradeable reports the plain HTTP requests browsers upgrade to HTTPS instead of
+// blocking them (the "upgradeable" content of Mixed Content Level 2): images, audio
+// and video. Images chosen from a srcset or a <picture> are requested as an image
+// set, which is blockable.
+func isUpgradeable(resource resources.Resource) bool {
+	switch resource.Type {
+	case resources.ResourceImage:
+		return resource.Attribute != "srcset" && (resource.Element == "img" || resource.Element == "video")
+	case resources.ResourceMedia:
+		// <source> of <video> and <audio>, but not their <track> elements
+		return resource.Element == "video" || resource.Element == "audio" || resource.Element == "source"
+	}
+	return false
+}
+
+func isHTTP(urlStr string) bool {
+	return len(urlStr) >= 7 && strings.EqualFold(urlStr[:7], "http://")
+}
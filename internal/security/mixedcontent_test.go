@@ -0,0 +1,155 @@
+package security
+
+import (
+	"lt-app/internal/findings"
+	"lt-app/internal/forms"
+	"lt-app/internal/resources"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var pageResources = []resources.Resource{
+	{URL: "http://cdn.example.com/app.js", Type: resources.ResourceScript, Element: "script", Attribute: "src"},
+	{URL: "http://cdn.example.com/logo.png", Type: resources.ResourceImage, Element: "img", Attribute: "src"},
+	{URL: "http://cdn.example.com/clip.mp4", Type: resources.ResourceMedia, Element: "source", Attribute: "src"},
+	{URL: "http://cdn.example.com/bg.png", Type: resources.ResourceCSSURL, Element: "div", Attribute: "style"},
+	{URL: "https://cdn.example.com/site.css", Type: resources.ResourceStylesheet, Element: "link", Attribute: "href"},
+}
+
+var pageForms = []forms.Form{
+	{Action: "http://example.com/subscribe"},
+	{Action: "https://example.com/login"},
+}
+
+func TestCheckMixedContent(t *testing.T) {
+	report := CheckMixedContent("https://example.com/", pageResources, pageForms, nil)
+
+	if !report.Applicable || report.UpgradeInsecureRequests {
+		t.Errorf("Unexpected report flags %+v", report)
+	}
+
+	expectedItems := []MixedContentItem{
+		{URL: "http://cdn.example.com/app.js", Kind: MixedContentActive, Type: "script", Element: "script", Attribute: "src"},
+		{URL: "http://cdn.example.com/logo.png", Kind: MixedContentPassive, Type: "image", Element: "img", Attribute: "src", AutoUpgraded: true},
+		{URL: "http://cdn.example.com/clip.mp4", Kind: MixedContentPassive, Type: "media", Element: "source", Attribute: "src", AutoUpgraded: true},
+		{URL: "http://cdn.example.com/bg.png", Kind: MixedContentActive, Type: "css-url", Element: "div", Attribute: "style"},
+		{URL: "http://example.com/subscribe", Kind: MixedContentActive, Type: "form", Element: "form", Attribute: "action"},
+	}
+
+	if !reflect.DeepEqual(report.Items, expectedItems) {
+		t.Errorf("Expected items\n%v\ngot\n%v", expectedItems, report.Items)
+	}
+
+	if report.ActiveCount != 3 || report.PassiveCount != 2 {
+		t.Errorf("Expected 3 active and 2 passive items, got %d and %d", report.ActiveCount, report.PassiveCount)
+	}
+
+	if len(report.Findings) != 5 || report.Findings[0].ID != "mixed-content-active" || report.Findings[0].Severity != findings.SeverityError {
+		t.Errorf("Unexpected findings %v", report.Findings)
+	}
+	if report.Findings[1].ID != "mixed-content-passive" || report.Findings[1].Severity != findings.SeverityWarning {
+		t.Errorf("Unexpected passive finding %v", report.Findings[1])
+	}
+}
+
+func TestCheckMixedContent_ImageSets(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource resources.Resource
+		kind     MixedContentKind
+	}{
+		{"img src", resources.Resource{Type: resources.ResourceImage, Element: "img", Attribute: "src"}, MixedContentPassive},
+		{"img srcset", resources.Resource{Type: resources.ResourceImage, Element: "img", Attribute: "srcset"}, MixedContentActive},
+		{"picture source", resources.Resource{Type: resources.ResourceImage, Element: "source", Attribute: "srcset"}, MixedContentActive},
+		{"video poster", resources.Resource{Type: resources.ResourceImage, Element: "video", Attribute: "poster"}, MixedContentPassive},
+		{"audio src", resources.Resource{Type: resources.ResourceMedia, Element: "audio", Attribute: "src"}, MixedContentPassive},
+		{"video track", resources.Resource{Type: resources.ResourceMedia, Element: "track", Attribute: "src"}, MixedContentActive},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.resource.URL = "http://cdn.example.com/file"
+			report := CheckMixedContent("https://example.com/", []resources.Resource{test.resource}, nil, nil)
+
+			if len(report.Items) != 1 || report.Items[0].Kind != test.kind || report.Items[0].AutoUpgraded != (test.kind == MixedContentPassive) {
+				t.Errorf("Expected %s content, got %+v", test.kind, report.Items)
+			}
+		})
+	}
+}
+
+func TestCheckMixedContent_UpgradeInsecureRequests(t *testing.T) {
+	report := CheckMixedContent("https://example.com/", pageResources, pageForms, []string{"default-src 'self'; Upgrade-Insecure-Requests"})
+
+	if !report.UpgradeInsecureRequests {
+		t.Fatalf("Expected the upgrade-insecure-requests directive to be detected")
+	}
+
+	for _, item := range report.Items {
+		if !item.AutoUpgraded {
+			t.Errorf("Expected %s to be upgraded", item.URL)
+		}
+	}
+	for _, finding := range report.Findings {
+		if finding.Severity != findings.SeverityInfo {
+			t.Errorf("Expected info severity, got %v", finding)
+		}
+	}
+}
+
+func TestCheckMixedContent_UpgradeInsecureRequestsInPolicyList(t *testing.T) {
+	// The second policy of the header value has the directive
+	report := CheckMixedContent("https://example.com/", pageResources, pageForms, []string{"default-src 'self', upgrade-insecure-requests"})
+
+	if !report.UpgradeInsecureRequests {
+		t.Errorf("Expected the upgrade-insecure-requests directive to be detected")
+	}
+}
+
+func TestSplitCSPPolicies(t *testing.T) {
+	policies := SplitCSPPolicies([]string{"script-src 'self', object-src 'none'", "img-src https:", " , "})
+	expected := []string{"script-src 'self'", " object-src 'none'", "img-src https:"}
+	if !reflect.DeepEqual(policies, expected) {
+		t.Errorf("Expected %q, got %q", expected, policies)
+	}
+}
+
+func TestCheckMixedContent_HTTPPage(t *testing.T) {
+	report := CheckMixedContent("http://example.com/", pageResources, pageForms, nil)
+
+	if report.Applicable || len(report.Items) != 0 || len(report.Findings) != 0 {
+		t.Errorf("Expected no mixed content on an HTTP page, got %+v", report)
+	}
+}
+
+func TestMetaCSPPolicies(t *testing.T) {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(`<head>
+		<meta http-equiv="Content-Security-Policy" content="upgrade-insecure-requests">
+		<meta http-equiv="refresh" content="30">
+		<meta http-equiv="content-security-policy" content="img-src https:"></head>`))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	expected := []string{"upgrade-insecure-requests", "img-src https:"}
+	if policies := MetaCSPPolicies(doc); !reflect.DeepEqual(policies, expected) {
+		t.Errorf("Expected %v, got %v", expected, policies)
+	}
+}
+
+func TestParseCSP(t *testing.T) {
+	directives := ParseCSP("default-src 'self'; script-src 'self' cdn.example.com;; SCRIPT-SRC *; upgrade-insecure-requests")
+
+	expected := map[string][]string{
+		"default-src":               {"'self'"},
+		"script-src":                {"'self'", "cdn.example.com"},
+		"upgrade-insecure-requests": {},
+	}
+
+	if !reflect.DeepEqual(directives, expected) {
+		t.Errorf("Expected %v, got %v", expected, directives)
+	}
+}
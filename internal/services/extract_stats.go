@@ -1,16 +1,30 @@
 package services
 
 import (
+	"context"
 	"log/slog"
+	"lt-app/internal/analyzer"
+	"lt-app/internal/extract"
 	"lt-app/internal/myhttp"
 	"lt-app/internal/pagedata"
 	"lt-app/internal/pagestats"
 	"lt-app/internal/webfetch"
 )
 
-func FetchWebPageStats(webPageUrl string, RLogger *slog.Logger) (*pagestats.WebPageStats, *webfetch.ErrorResponse) {
-	webfetcher := webfetch.NewWebFetcher(myhttp.NewRestyClient())
-	bodyString, fetchError := webfetcher.Fetch(webPageUrl, RLogger)
+// AnalyzeOptions are the choices a caller can make about an analysis
+type AnalyzeOptions struct {
+	// Analyzers to run. Every analyzer runs when empty.
+	Analyzers []string
+	// Named CSS selectors and XPath expressions whose matches are returned
+	Extractors []extract.Extractor
+}
+
+// FetchWebPageStats fetches the page and runs the selected analyzers on it.
+// Independent analyzers run concurrently.
+func FetchWebPageStats(ctx context.Context, webPageUrl string, options AnalyzeOptions, RLogger *slog.Logger) (*pagestats.WebPageStats, *webfetch.ErrorResponse) {
+	restyClient := myhttp.NewRestyClient()
+	webfetcher := webfetch.NewWebFetcher(restyClient)
+	pageSource, fetchError := webfetcher.Fetch(ctx, webPageUrl, RLogger)
 
 	if fetchError != nil {
 		RLogger.Error("Error loading HTTP response body.", "url", webPageUrl, "error", fetchError)
@@ -18,16 +32,30 @@ func FetchWebPageStats(webPageUrl string, RLogger *slog.Logger) (*pagestats.WebP
 	}
 
 	pgBuilder := &pagedata.PageDataBuilder{}
-	pageData, pdBuildErr := pgBuilder.Build(webPageUrl, bodyString, RLogger)
+	// Analyze the page at the URL it was served from, as redirects may change the scheme or host
+	pageData, pdBuildErr := pgBuilder.Build(pageSource.URL, pageSource.Body, pageSource.Headers, RLogger)
 
 	if pdBuildErr != nil {
 		RLogger.Error("Error building PageData", "error", pdBuildErr)
 		return nil, pdBuildErr
 	}
 
-	// Create an instance of WebPageStats
+	input := &analyzer.Input{
+		Page:    pageData,
+		Doc:     pageData.Doc,
+		HTML:    pageData.HTML,
+		Headers: pageData.Headers,
+		URL:     pageData.WebPageUrl,
+		BaseURL: pageData.BaseURL,
+		Source:  pageSource,
+		Fetcher: webfetcher,
+		Logger:  RLogger,
+
+		Extractors: options.Extractors,
+	}
+
 	psBuilder := &pagestats.PageStatsBuilder{}
-	stats, statBuildErr := psBuilder.Build(pageData, webfetcher, RLogger)
+	stats, statBuildErr := psBuilder.Build(ctx, input, options.Analyzers)
 
 	if statBuildErr != nil {
 		RLogger.Error("Error building WebPageStats", "error", statBuildErr)
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// SeqKey orders records by a sequence number of their bucket
+func SeqKey(seq uint64) []byte {
+	return binary.BigEndian.AppendUint64(nil, seq)
+}
+
+// Key is the key of the record with the ID, the sequence number as a string. IDs
+// that are not numbers in their canonical form, e.g. "007", match no record, as
+// other buckets may be keyed by the ID as given.
+func Key(id string) []byte {
+	seq, err := strconv.ParseUint(id, 10, 64)
+	if err != nil || strconv.FormatUint(seq, 10) != id {
+		return []byte{}
+	}
+	return SeqKey(seq)
+}
+
+// GetJSON decodes the record with the ID into value and reports whether there is one
+func GetJSON(bucket *bolt.Bucket, id string, value any) (bool, error) {
+	encoded := bucket.Get(Key(id))
+	if encoded == nil {
+		return false, nil
+	}
+	return true, json.Unmarshal(encoded, value)
+}
+
+// PutJSON stores value as the record with the ID
+func PutJSON(bucket *bolt.Bucket, id string, value any) error {
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(Key(id), encoded)
+}
+
+// Prune removes the records of the bucket in key order for as long as expired
+// returns true, given the key and the number of records not removed yet, and
+// returns the removed keys
+func Prune(bucket *bolt.Bucket, expired func(key []byte, remaining int) bool) ([][]byte, error) {
+	remaining := 0
+	if err := bucket.ForEach(func(_, _ []byte) error { remaining++; return nil }); err != nil {
+		return nil, err
+	}
+
+	// Keys are collected first, as deleting while moving a cursor skips keys
+	var removed [][]byte
+	cursor := bucket.Cursor()
+	for key, _ := cursor.First(); key != nil && expired(key, remaining); key, _ = cursor.Next() {
+		removed = append(removed, append([]byte{}, key...))
+		remaining--
+	}
+	for _, key := range removed {
+		if err := bucket.Delete(key); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+// KeepNewest removes the oldest records of a bucket ordered by SeqKey beyond max.
+// Every record is kept when max is not positive.
+func KeepNewest(bucket *bolt.Bucket, max int) error {
+	if max <= 0 {
+		return nil
+	}
+	_, err := Prune(bucket, func(_ []byte, remaining int) bool { return remaining > max })
+	return err
+}
@@ -0,0 +1,82 @@
+package storage
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected []byte
+	}{
+		{"7", SeqKey(7)},
+		{"18446744073709551615", SeqKey(18446744073709551615)},
+		{"007", []byte{}},
+		{"+7", []byte{}},
+		{"abc", []byte{}},
+		{"", []byte{}},
+	}
+
+	for _, test := range tests {
+		if got := Key(test.id); !bytes.Equal(got, test.expected) {
+			t.Errorf("%q: expected %v, got %v", test.id, test.expected, got)
+		}
+	}
+}
+
+func TestRecords(t *testing.T) {
+	db, err := Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	defer db.Close()
+
+	type record struct {
+		Name string `json:"name"`
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucket([]byte("records"))
+		if err != nil {
+			return err
+		}
+		for _, id := range []string{"1", "2", "3", "4", "5"} {
+			if err := PutJSON(bucket, id, record{Name: "record " + id}); err != nil {
+				return err
+			}
+		}
+
+		var stored record
+		if found, err := GetJSON(bucket, "2", &stored); !found || err != nil || stored.Name != "record 2" {
+			t.Errorf("Expected record 2, got %+v, %v, %v", stored, found, err)
+		}
+		if found, _ := GetJSON(bucket, "02", &stored); found {
+			t.Errorf("Expected no record for a non-canonical ID")
+		}
+
+		KeepNewest(bucket, 0)
+		if found, _ := GetJSON(bucket, "1", &stored); !found {
+			t.Errorf("Expected every record to be kept without a maximum")
+		}
+		if err := KeepNewest(bucket, 3); err != nil {
+			return err
+		}
+		for id, expected := range map[string]bool{"1": false, "2": false, "3": true, "5": true} {
+			if found, _ := GetJSON(bucket, id, &stored); found != expected {
+				t.Errorf("%s: expected found %v, got %v", id, expected, found)
+			}
+		}
+
+		removed, err := Prune(bucket, func(key []byte, remaining int) bool { return !bytes.Equal(key, SeqKey(5)) })
+		if err != nil || len(removed) != 2 || !bytes.Equal(removed[0], SeqKey(3)) || !bytes.Equal(removed[1], SeqKey(4)) {
+			t.Errorf("Expected records 3 and 4 to be removed, got %v, %v", removed, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+}
@@ -0,0 +1,19 @@
+package storage
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// Open opens the embedded database of the application, creating the file and its
+// directory when needed. bbolt locks the file, so Open fails after the timeout
+// while another process, e.g. the server, has it open.
+func Open(path string) (*bolt.DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+}
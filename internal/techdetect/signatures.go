@@ -0,0 +1,169 @@
+package techdetect
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Signatures use the pattern syntax of Wappalyzer: a regular expression optionally
+// followed by "\;version:\1" to take the version from a capture group.
+type rawTechnology struct {
+	Categories []string          `json:"categories"`
+	Website    string            `json:"website"`
+	HTML       []string          `json:"html"`
+	Scripts    []string          `json:"scripts"`
+	Headers    map[string]string `json:"headers"`
+	Meta       map[string]string `json:"meta"`
+	Cookies    map[string]string `json:"cookies"`
+	Implies    []string          `json:"implies"`
+}
+
+type rawSignatures struct {
+	Version      string                   `json:"version"`
+	Technologies map[string]rawTechnology `json:"technologies"`
+}
+
+type pattern struct {
+	re *regexp.Regexp
+	// Version template such as "\1", empty when the pattern does not expose a version
+	version string
+}
+
+type cookiePattern struct {
+	name  *regexp.Regexp
+	value *pattern
+}
+
+type technology struct {
+	name       string
+	categories []string
+	website    string
+	html       []pattern
+	scripts    []pattern
+	// Keyed by lower case header or meta name
+	headers map[string]*pattern
+	meta    map[string]*pattern
+	cookies []cookiePattern
+	implies []string
+}
+
+type signatureSet struct {
+	version      string
+	technologies []*technology
+	byName       map[string]*technology
+}
+
+// parseSignatures compiles every pattern of the file. A single invalid pattern rejects
+// the whole file.
+func parseSignatures(content []byte) (*signatureSet, error) {
+	var raw rawSignatures
+	if err := json.Unmarshal(content, &raw); err != nil {
+		return nil, err
+	}
+
+	set := &signatureSet{version: raw.Version, byName: make(map[string]*technology, len(raw.Technologies))}
+
+	for name, rawTech := range raw.Technologies {
+		tech := &technology{
+			name:       name,
+			categories: rawTech.Categories,
+			website:    rawTech.Website,
+			headers:    make(map[string]*pattern, len(rawTech.Headers)),
+			meta:       make(map[string]*pattern, len(rawTech.Meta)),
+			implies:    rawTech.Implies,
+		}
+
+		var err error
+		if tech.html, err = compilePatterns(rawTech.HTML); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if tech.scripts, err = compilePatterns(rawTech.Scripts); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		for header, value := range rawTech.Headers {
+			if tech.headers[strings.ToLower(header)], err = compilePattern(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		for metaName, value := range rawTech.Meta {
+			if tech.meta[strings.ToLower(metaName)], err = compilePattern(value); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+
+		for cookieName, value := range rawTech.Cookies {
+			nameRe, err := regexp.Compile(cookieName)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			valuePattern, err := compilePattern(value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+			tech.cookies = append(tech.cookies, cookiePattern{name: nameRe, value: valuePattern})
+		}
+
+		set.technologies = append(set.technologies, tech)
+		set.byName[name] = tech
+	}
+
+	sort.Slice(set.technologies, func(i, j int) bool {
+		return set.technologies[i].name < set.technologies[j].name
+	})
+
+	return set, nil
+}
+
+func compilePatterns(sources []string) ([]pattern, error) {
+	patterns := make([]pattern, 0, len(sources))
+	for _, source := range sources {
+		p, err := compilePattern(source)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, *p)
+	}
+	return patterns, nil
+}
+
+// compilePattern compiles a case insensitive pattern. An empty pattern matches anything.
+func compilePattern(source string) (*pattern, error) {
+	parts := strings.Split(source, `\;`)
+	p := &pattern{}
+
+	for _, option := range parts[1:] {
+		if key, value, found := strings.Cut(option, ":"); found && key == "version" {
+			p.version = value
+		}
+	}
+
+	re, err := regexp.Compile("(?i)" + parts[0])
+	if err != nil {
+		return nil, err
+	}
+	p.re = re
+
+	return p, nil
+}
+
+// match reports whether the pattern matches and the version it captured, if any
+func (p *pattern) match(value string) (bool, string) {
+	groups := p.re.FindStringSubmatch(value)
+	if groups == nil {
+		return false, ""
+	}
+	if p.version == "" {
+		return true, ""
+	}
+
+	version := p.version
+	for i := len(groups) - 1; i >= 1; i-- {
+		version = strings.ReplaceAll(version, fmt.Sprintf(`\%d`, i), groups[i])
+	}
+	return true, strings.TrimSpace(version)
+}
@@ -0,0 +1,216 @@
+package techdetect
+
+import (
+	"lt-app/internal/constants"
+	"lt-app/internal/datafile"
+	"lt-app/internal/utils"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultDetector uses the signatures bundled with the application
+var DefaultDetector = NewDetector(filepath.Join(utils.GetProjectRoot(), constants.TECHNOLOGIES_FILE_PATH))
+
+type Technology struct {
+	Name       string   `json:"name"`
+	Categories []string `json:"categories"`
+	Version    string   `json:"version,omitempty"`
+	Website    string   `json:"website"`
+	// What matched, e.g. "header server" or "implied by WordPress"
+	Evidence []string `json:"evidence"`
+}
+
+type Report struct {
+	SignaturesVersion string       `json:"signaturesVersion"`
+	Technologies      []Technology `json:"technologies"`
+}
+
+// Input is what the page exposes to the signatures
+type Input struct {
+	HTML    string
+	Headers http.Header
+	Doc     *goquery.Document
+}
+
+// Detector matches the page against the signatures of a JSON file
+type Detector struct {
+	file *datafile.File[*signatureSet]
+}
+
+func NewDetector(path string) *Detector {
+	return &Detector{file: datafile.New(path, parseSignatures)}
+}
+
+func (d *Detector) Detect(input Input) *Report {
+	report := &Report{Technologies: []Technology{}}
+
+	set := d.file.Get()
+	if set == nil {
+		return report
+	}
+	report.SignaturesVersion = set.version
+
+	page := collectPageValues(input)
+	detected := make(map[string]*Technology)
+	var order []string
+
+	for _, tech := range set.technologies {
+		if result := tech.detect(page); result != nil {
+			detected[tech.name] = result
+			order = append(order, tech.name)
+		}
+	}
+
+	// Implied technologies are added transitively, e.g. Next.js implies React
+	for i := 0; i < len(order); i++ {
+		for _, impliedName := range set.byName[order[i]].implies {
+			implied, exists := set.byName[impliedName]
+			if !exists {
+				continue
+			}
+			if result, exists := detected[impliedName]; exists {
+				result.Evidence = appendUnique(result.Evidence, "implied by "+order[i])
+				continue
+			}
+			detected[impliedName] = &Technology{
+				Name:       implied.name,
+				Categories: implied.categories,
+				Website:    implied.website,
+				Evidence:   []string{"implied by " + order[i]},
+			}
+			order = append(order, impliedName)
+		}
+	}
+
+	for _, name := range order {
+		report.Technologies = append(report.Technologies, *detected[name])
+	}
+	sort.Slice(report.Technologies, func(i, j int) bool {
+		return report.Technologies[i].Name < report.Technologies[j].Name
+	})
+
+	return report
+}
+
+type pageValues struct {
+	html    string
+	scripts []string
+	headers map[string][]string
+	meta    map[string][]string
+	cookies map[string]string
+}
+
+func collectPageValues(input Input) *pageValues {
+	page := &pageValues{
+		html:    input.HTML,
+		headers: make(map[string][]string),
+		meta:    make(map[string][]string),
+		cookies: make(map[string]string),
+	}
+
+	for name, values := range input.Headers {
+		page.headers[strings.ToLower(name)] = values
+	}
+
+	for _, line := range input.Headers.Values("Set-Cookie") {
+		if cookie, err := http.ParseSetCookie(line); err == nil {
+			page.cookies[cookie.Name] = cookie.Value
+		}
+	}
+
+	if input.Doc != nil {
+		input.Doc.Find("script[src]").Each(func(_ int, s *goquery.Selection) {
+			page.scripts = append(page.scripts, s.AttrOr("src", ""))
+		})
+
+		input.Doc.Find("meta[name][content]").Each(func(_ int, s *goquery.Selection) {
+			name := strings.ToLower(s.AttrOr("name", ""))
+			page.meta[name] = append(page.meta[name], s.AttrOr("content", ""))
+		})
+	}
+
+	return page
+}
+
+// detect returns the technology when any of its signatures match, with the version
+// reported by the first signature that captured one
+func (t *technology) detect(page *pageValues) *Technology {
+	result := &Technology{Name: t.name, Categories: t.categories, Website: t.website, Evidence: []string{}}
+
+	record := func(matched bool, version string, evidence string) {
+		if !matched {
+			return
+		}
+		result.Evidence = appendUnique(result.Evidence, evidence)
+		if result.Version == "" {
+			result.Version = version
+		}
+	}
+
+	// Maps are walked in key order so the reported version does not depend on map order
+	for _, name := range sortedKeys(t.headers) {
+		p := t.headers[name]
+		for _, value := range page.headers[name] {
+			matched, version := p.match(value)
+			record(matched, version, "header "+name)
+		}
+	}
+
+	for _, name := range sortedKeys(t.meta) {
+		p := t.meta[name]
+		for _, value := range page.meta[name] {
+			matched, version := p.match(value)
+			record(matched, version, "meta "+name)
+		}
+	}
+
+	for _, p := range t.scripts {
+		for _, src := range page.scripts {
+			matched, version := p.match(src)
+			record(matched, version, "script "+src)
+		}
+	}
+
+	for _, c := range t.cookies {
+		for _, name := range sortedKeys(page.cookies) {
+			if c.name.MatchString(name) {
+				matched, version := c.value.match(page.cookies[name])
+				record(matched, version, "cookie "+name)
+			}
+		}
+	}
+
+	for _, p := range t.html {
+		matched, version := p.match(page.html)
+		record(matched, version, "html")
+	}
+
+	if len(result.Evidence) == 0 {
+		return nil
+	}
+
+	sort.Strings(result.Evidence)
+	return result
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, existing := range list {
+		if existing == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
@@ -0,0 +1,111 @@
+package techdetect
+
+import (
+	"lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/utils"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func detect(t *testing.T, detector *Detector, htmlSource string, headers http.Header) map[string]Technology {
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(htmlSource))
+	if err != nil {
+		t.Fatalf("Failed to parse HTML: %v", err)
+	}
+
+	technologies := make(map[string]Technology)
+	for _, tech := range detector.Detect(Input{HTML: htmlSource, Headers: headers, Doc: doc}).Technologies {
+		technologies[tech.Name] = tech
+	}
+	return technologies
+}
+
+func TestDetect_BundledSignatures(t *testing.T) {
+	detector := NewDetector(filepath.Join(utils.GetProjectRoot(), constants.TECHNOLOGIES_FILE_PATH))
+
+	htmlSource := `<html><head>
+		<meta name="generator" content="WordPress 6.4.2">
+		<link rel="stylesheet" href="/wp-content/themes/site/style.css">
+		<script src="https://code.jquery.com/jquery-3.7.1.min.js"></script>
+		<script async src="https://www.googletagmanager.com/gtag/js?id=G-XYZ"></script>
+	</head><body></body></html>`
+
+	headers := http.Header{}
+	headers.Set("Server", "nginx/1.25.3")
+	headers.Set("X-Powered-By", "PHP/8.2.1")
+	headers.Add("Set-Cookie", "_ga=GA1.1.123; Path=/")
+
+	technologies := detect(t, detector, htmlSource, headers)
+
+	expectedVersions := map[string]string{
+		"WordPress":        "6.4.2",
+		"jQuery":           "3.7.1",
+		"Nginx":            "1.25.3",
+		"PHP":              "8.2.1",
+		"Google Analytics": "",
+		"MySQL":            "",
+	}
+
+	for name, version := range expectedVersions {
+		tech, found := technologies[name]
+		if !found {
+			t.Errorf("Expected %s to be detected, got %v", name, technologies)
+			continue
+		}
+		if tech.Version != version {
+			t.Errorf("Expected %s version %q, got %q", name, version, tech.Version)
+		}
+	}
+
+	if evidence := technologies["MySQL"].Evidence; len(evidence) != 1 || evidence[0] != "implied by WordPress" {
+		t.Errorf("Expected MySQL to be implied by WordPress, got %v", evidence)
+	}
+	if _, found := technologies["Drupal"]; found {
+		t.Errorf("Expected Drupal not to be detected")
+	}
+}
+
+func TestDetect_CustomSignatures(t *testing.T) {
+	applogger.InitLogger()
+	path := filepath.Join(t.TempDir(), "technologies.json")
+
+	content := `{"version": "test", "technologies": {
+		"Acme CMS": {"categories": ["cms"], "meta": {"Generator": "^Acme ([\\d.]+)\\;version:\\1"}, "implies": ["Acme Runtime"]},
+		"Acme Runtime": {"categories": ["programming-language"], "headers": {"X-Acme": ""}},
+		"Acme Widgets": {"categories": ["js-library"], "scripts": ["acme-widgets\\.js"], "cookies": {"^acme_": ""}}
+	}}`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("Failed to write signatures: %v", err)
+	}
+
+	headers := http.Header{}
+	headers.Set("X-Acme", "1")
+	headers.Add("Set-Cookie", "acme_session=abc")
+
+	technologies := detect(t, NewDetector(path), `<meta name="generator" content="Acme 2.1">`, headers)
+
+	if technologies["Acme CMS"].Version != "2.1" {
+		t.Errorf("Expected Acme CMS 2.1, got %+v", technologies["Acme CMS"])
+	}
+
+	runtime := technologies["Acme Runtime"]
+	if len(runtime.Evidence) != 2 || runtime.Evidence[0] != "header x-acme" || runtime.Evidence[1] != "implied by Acme CMS" {
+		t.Errorf("Expected the runtime to be detected directly and implied, got %v", runtime.Evidence)
+	}
+
+	if widgets := technologies["Acme Widgets"]; len(widgets.Evidence) != 1 || widgets.Evidence[0] != "cookie acme_session" {
+		t.Errorf("Expected the widgets to be detected by cookie, got %+v", widgets)
+	}
+}
+
+func TestParseSignatures_InvalidPattern(t *testing.T) {
+	if _, err := parseSignatures([]byte(`{"technologies": {"Broken": {"html": ["(unclosed"]}}}`)); err == nil {
+		t.Errorf("Expected an invalid pattern to be rejected")
+	}
+}
@@ -0,0 +1,53 @@
+package thirdparty
+
+import (
+	"encoding/json"
+	"lt-app/internal/datafile"
+	"strings"
+)
+
+type Classification struct {
+	Category string `json:"category"`
+	Owner    string `json:"owner"`
+}
+
+type classificationList struct {
+	Version string                    `json:"version"`
+	Domains map[string]Classification `json:"domains"`
+}
+
+// Classifier tags domains with the category of the service behind them, from the
+// list of a JSON file
+type Classifier struct {
+	file *datafile.File[classificationList]
+}
+
+func NewClassifier(path string) *Classifier {
+	return &Classifier{file: datafile.New(path, func(content []byte) (classificationList, error) {
+		var list classificationList
+		err := json.Unmarshal(content, &list)
+		return list, err
+	})}
+}
+
+// Lookup returns the classification of the host or of its closest listed parent domain
+func (c *Classifier) Lookup(host string) (Classification, bool) {
+	list := c.file.Get()
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+
+	for {
+		if classification, exists := list.Domains[host]; exists {
+			return classification, true
+		}
+
+		dot := strings.IndexByte(host, '.')
+		if dot < 0 {
+			return Classification{}, false
+		}
+		host = host[dot+1:]
+	}
+}
+
+func (c *Classifier) Version() string {
+	return c.file.Get().Version
+}
@@ -0,0 +1,130 @@
+package thirdparty
+
+import (
+	"lt-app/internal/constants"
+	"lt-app/internal/utils"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// DefaultClassifier reads the classification list bundled with the application
+var DefaultClassifier = NewClassifier(filepath.Join(utils.GetProjectRoot(), constants.TRACKERS_FILE_PATH))
+
+// Domain groups the external hosts of a registrable domain, e.g. www.google-analytics.com
+// and ssl.google-analytics.com under google-analytics.com
+type Domain struct {
+	Domain string `json:"domain"`
+	// The domain belongs to another site than the page. Other subdomains of the
+	// page's own site are listed but are not third parties.
+	ThirdParty bool     `json:"thirdParty"`
+	Category   string   `json:"category"`
+	Owner      string   `json:"owner"`
+	Hosts      []string `json:"hosts"`
+	// Number of subresources loaded from the domain
+	Resources int `json:"resources"`
+	// Number of links pointing to the domain
+	Links int `json:"links"`
+}
+
+type Inventory struct {
+	ThirdPartyCount int            `json:"thirdPartyCount"`
+	ByCategory      map[string]int `json:"byCategory"`
+	ListVersion     string         `json:"listVersion"`
+	Domains         []Domain       `json:"domains"`
+}
+
+// BuildInventory groups the hosts other than the page's own host that the links and
+// resources of the page point to. Domains are sorted by the number of references.
+func BuildInventory(pageUrl string, linkUrls []string, resourceUrls []string, classifier *Classifier) *Inventory {
+	inventory := &Inventory{ByCategory: map[string]int{}, Domains: []Domain{}, ListVersion: classifier.Version()}
+
+	parsed, err := url.Parse(pageUrl)
+	if err != nil {
+		return inventory
+	}
+	pageHost := strings.ToLower(parsed.Hostname())
+	pageSite := registrableDomain(pageHost)
+
+	domains := make(map[string]*Domain)
+	hosts := make(map[string]map[string]bool)
+
+	add := func(rawUrl string, isResource bool) {
+		u, err := url.Parse(rawUrl)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return
+		}
+
+		host := strings.ToLower(u.Hostname())
+		if host == "" || host == pageHost {
+			return
+		}
+
+		site := registrableDomain(host)
+		domain, exists := domains[site]
+		if !exists {
+			domain = &Domain{Domain: site, ThirdParty: site != pageSite, Hosts: []string{}}
+			domains[site] = domain
+			hosts[site] = make(map[string]bool)
+		}
+
+		if !hosts[site][host] {
+			hosts[site][host] = true
+			domain.Hosts = append(domain.Hosts, host)
+
+			// Hosts of a domain can be listed separately, e.g. adservice.google.com
+			if classification, found := classifier.Lookup(host); found && domain.Category == "" {
+				domain.Category = classification.Category
+				domain.Owner = classification.Owner
+			}
+		}
+
+		if isResource {
+			domain.Resources++
+		} else {
+			domain.Links++
+		}
+	}
+
+	for _, link := range linkUrls {
+		add(link, false)
+	}
+	for _, resource := range resourceUrls {
+		add(resource, true)
+	}
+
+	for _, domain := range domains {
+		sort.Strings(domain.Hosts)
+		inventory.Domains = append(inventory.Domains, *domain)
+
+		if domain.ThirdParty {
+			inventory.ThirdPartyCount++
+			if domain.Category != "" {
+				inventory.ByCategory[domain.Category]++
+			}
+		}
+	}
+
+	sort.Slice(inventory.Domains, func(i, j int) bool {
+		a, b := inventory.Domains[i], inventory.Domains[j]
+		if a.Resources+a.Links != b.Resources+b.Links {
+			return a.Resources+a.Links > b.Resources+b.Links
+		}
+		return a.Domain < b.Domain
+	})
+
+	return inventory
+}
+
+// registrableDomain returns the eTLD+1 of the host, or the host itself for IP
+// addresses, localhost and public suffixes
+func registrableDomain(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
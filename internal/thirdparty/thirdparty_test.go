@@ -0,0 +1,98 @@
+package thirdparty
+
+import (
+	"lt-app/internal/applogger"
+	"lt-app/internal/constants"
+	"lt-app/internal/utils"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestBuildInventory(t *testing.T) {
+	classifier := NewClassifier(filepath.Join(utils.GetProjectRoot(), constants.TRACKERS_FILE_PATH))
+
+	links := []string{
+		"https://www.example.com/about",
+		"https://blog.example.com/post",
+		"https://twitter.com/example",
+		"mailto:info@example.com",
+	}
+	resources := []string{
+		"https://www.googletagmanager.com/gtm.js?id=GTM-1",
+		"https://www.google-analytics.com/analytics.js",
+		"https://ssl.google-analytics.com/ga.js",
+		"https://cdn.jsdelivr.net/npm/lib.js",
+		"https://static.example.com/app.css",
+		"https://tracker.unknown.co.uk/pixel.gif",
+	}
+
+	inventory := BuildInventory("https://www.example.com/", links, resources, classifier)
+
+	expected := []Domain{
+		{Domain: "example.com", ThirdParty: false, Hosts: []string{"blog.example.com", "static.example.com"}, Resources: 1, Links: 1},
+		{Domain: "google-analytics.com", ThirdParty: true, Category: "analytics", Owner: "Google", Hosts: []string{"ssl.google-analytics.com", "www.google-analytics.com"}, Resources: 2},
+		{Domain: "googletagmanager.com", ThirdParty: true, Category: "tag-manager", Owner: "Google", Hosts: []string{"www.googletagmanager.com"}, Resources: 1},
+		{Domain: "jsdelivr.net", ThirdParty: true, Category: "cdn", Owner: "jsDelivr", Hosts: []string{"cdn.jsdelivr.net"}, Resources: 1},
+		{Domain: "twitter.com", ThirdParty: true, Category: "social", Owner: "X", Hosts: []string{"twitter.com"}, Links: 1},
+		{Domain: "unknown.co.uk", ThirdParty: true, Hosts: []string{"tracker.unknown.co.uk"}, Resources: 1},
+	}
+
+	if !reflect.DeepEqual(inventory.Domains, expected) {
+		t.Errorf("Expected domains\n%+v\ngot\n%+v", expected, inventory.Domains)
+	}
+
+	if inventory.ThirdPartyCount != 5 {
+		t.Errorf("Expected 5 third parties, got %d", inventory.ThirdPartyCount)
+	}
+
+	expectedCategories := map[string]int{"analytics": 1, "tag-manager": 1, "cdn": 1, "social": 1}
+	if !reflect.DeepEqual(inventory.ByCategory, expectedCategories) {
+		t.Errorf("Expected categories %v, got %v", expectedCategories, inventory.ByCategory)
+	}
+
+	if inventory.ListVersion == "" {
+		t.Errorf("Expected the version of the classification list")
+	}
+}
+
+func TestClassifier_Reload(t *testing.T) {
+	applogger.InitLogger()
+	path := filepath.Join(t.TempDir(), "trackers.json")
+
+	classifier := NewClassifier(path)
+	if _, found := classifier.Lookup("tracker.example.org"); found {
+		t.Errorf("Expected no classification without a list")
+	}
+
+	write := func(content string, modTime time.Time) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatalf("Failed to write the list: %v", err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatalf("Failed to set the modification time: %v", err)
+		}
+	}
+
+	now := time.Now()
+	write(`{"version": "1", "domains": {"example.org": {"category": "analytics", "owner": "Example"}}}`, now)
+
+	classification, found := classifier.Lookup("Tracker.Example.org")
+	if !found || classification.Category != "analytics" || classifier.Version() != "1" {
+		t.Errorf("Expected the parent domain to be matched, got %+v", classification)
+	}
+
+	write(`{"version": "2", "domains": {"example.org": {"category": "advertising", "owner": "Example"}}}`, now.Add(time.Second))
+
+	if classification, _ := classifier.Lookup("tracker.example.org"); classification.Category != "advertising" || classifier.Version() != "2" {
+		t.Errorf("Expected the updated list to be used, got %+v", classification)
+	}
+
+	write(`not json`, now.Add(2*time.Second))
+
+	if classification, _ := classifier.Lookup("tracker.example.org"); classification.Category != "advertising" {
+		t.Errorf("Expected the previous list to be kept when the file is invalid, got %+v", classification)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"net/url"
 	"regexp"
 	"strings"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func IsValidURL(webPageUrl string) bool {
@@ -19,25 +21,14 @@ func IsInternalLink(href string) bool {
 	return re.MatchString(href)
 }
 
-// Function to extract the Doctype from the HTML source string
-func ExtractDoctypeFromHtmlSource(htmlSource string) string {
-	// re := regexp.MustCompile(`(?i)<!DOCTYPE\s+([^>]+)>`)
-	re := regexp.MustCompile(`(?i)<!DOCTYPE\s+([^\s>]+)`)
-	matches := re.FindStringSubmatch(htmlSource)
-	if len(matches) > 1 {
-		return strings.ToLower(matches[1])
-	}
-	return "unknown"
-}
-
+// GetOriginFromURL returns the scheme and host of an http or https URL. Unlike
+// IsValidURL it accepts any URL a redirect may lead to, e.g. with a port.
 func GetOriginFromURL(urlStr string) (string, error) {
-	if !IsValidURL(urlStr) {
+	parsedURL, err := url.Parse(urlStr)
+	if err != nil || (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Host == "" {
 		return "", fmt.Errorf("invalid URL: %s", urlStr)
 	}
 
-	// Due to the previous check, the URL is valid. So no err
-	parsedURL, _ := url.Parse(urlStr)
-
 	origin := fmt.Sprintf("%s://%s", parsedURL.Scheme, parsedURL.Host)
 	return origin, nil
 }
@@ -78,32 +69,41 @@ by using the above function CheckLinkAccessibilityWithResty.
 // 	}
 // }
 
-/*
-*This function has not been used in the code. However need a html tag validation
-function as goquery does not validate the html tags.
-*/
-// func ValidateHTMLTags(htmlString string) error {
-// 	reader := strings.NewReader(htmlString)
-// 	tokenizer := html.NewTokenizer(reader)
-
-// 	for {
-// 		tokenType := tokenizer.Next()
-// 		switch tokenType {
-// 		case html.ErrorToken:
-// 			err := tokenizer.Err()
-// 			if err != nil {
-// 				if err.Error() == "EOF" {
-// 					return nil // End of file, valid
-// 				}
-// 				return err // Return other errors
-// 			}
-// 			return nil //EOF with no error
-// 		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
-// 			// You can perform more advanced checks here, such as:
-// 			// - Ensuring tags are properly nested.
-// 			// - Checking for required attributes.
-// 			// - Ensuring tag names are valid.
-// 			// For basic well-formedness, the parsing itself is sufficient.
-// 		}
-// 	}
-// }
+// ResolveURL resolves a possibly relative reference against the base URL
+func ResolveURL(baseUrl string, ref string) (string, error) {
+	base, err := url.Parse(baseUrl)
+	if err != nil {
+		return "", err
+	}
+
+	refUrl, err := url.Parse(strings.TrimSpace(ref))
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(refUrl).String(), nil
+}
+
+// StripFragment removes the #fragment part of a URL
+func StripFragment(urlStr string) string {
+	if index := strings.IndexByte(urlStr, '#'); index != -1 {
+		return urlStr[:index]
+	}
+	return urlStr
+}
+
+// CollectAnchorTargets returns every fragment the document can scroll to.
+// Per the HTML spec that is any element id, or the name of an <a> element.
+func CollectAnchorTargets(doc *goquery.Document) map[string]bool {
+	targets := make(map[string]bool)
+
+	doc.Find("[id]").Each(func(_ int, s *goquery.Selection) {
+		targets[s.AttrOr("id", "")] = true
+	})
+
+	doc.Find("a[name]").Each(func(_ int, s *goquery.Selection) {
+		targets[s.AttrOr("name", "")] = true
+	})
+
+	return targets
+}
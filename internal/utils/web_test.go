@@ -1,7 +1,10 @@
 package utils
 
 import (
+	"strings"
 	"testing"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 func TestIsValidURL(t *testing.T) {
@@ -59,31 +62,6 @@ func TestIsInternalLink(t *testing.T) {
 	}
 }
 
-func TestExtractDoctypeFromHtmlSource(t *testing.T) {
-	tests := []struct {
-		htmlSource string
-		expected   string
-	}{
-		{"<!DOCTYPE html>", "html"},
-		{"<!DOCTYPE HTML>", "html"},
-		{"<!DOCTYPE html PUBLIC \"-//W3C//DTD XHTML 1.0 Transitional//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd\">", "html"},
-		{"<!DOCTYPE HTML PUBLIC \"-//W3C//DTD XHTML 1.0 Transitional//EN\" \"http://www.w3.org/TR/xhtml1/DTD/xhtml1-transitional.dtd\">", "html"},
-		{"<!DOCTYPE svg PUBLIC \"-//W3C//DTD SVG 1.1//EN\" \"http://www.w3.org/Graphics/SVG/1.1/DTD/svg11.dtd\">", "svg"},
-		{"<!DOCTYPE unknown>", "unknown"},
-		{"<html>", "unknown"},
-		{"", "unknown"},
-	}
-
-	for _, test := range tests {
-		t.Run(test.htmlSource, func(t *testing.T) {
-			result := ExtractDoctypeFromHtmlSource(test.htmlSource)
-			if result != test.expected {
-				t.Errorf("ExtractDoctypeFromHtmlSource(%q) = %v; want %v", test.htmlSource, result, test.expected)
-			}
-		})
-	}
-}
-
 func TestGetOriginFromURL(t *testing.T) {
 	tests := []struct {
 		url      string
@@ -100,6 +78,17 @@ func TestGetOriginFromURL(t *testing.T) {
 			expected: "http://example.com",
 			wantErr:  false,
 		},
+		{
+			// Final URL of a redirect, which IsValidURL rejects
+			url:      "https://shop.example.com:8443/caf%C3%A9/~kettles?a=1,2",
+			expected: "https://shop.example.com:8443",
+			wantErr:  false,
+		},
+		{
+			url:      "ftp://example.com/file",
+			expected: "",
+			wantErr:  true,
+		},
 		{
 			url:      "invalid-url",
 			expected: "",
@@ -131,3 +120,65 @@ func TestGetOriginFromURL(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveURL(t *testing.T) {
+	tests := []struct {
+		base     string
+		ref      string
+		expected string
+		wantErr  bool
+	}{
+		{"https://example.com/a/b", "/login", "https://example.com/login", false},
+		{"https://example.com/a/b", "c", "https://example.com/a/c", false},
+		{"https://example.com/a/b", "", "https://example.com/a/b", false},
+		{"https://example.com/a/b", "#top", "https://example.com/a/b#top", false},
+		{"https://example.com/a/b", "//cdn.example.net/x.js", "https://cdn.example.net/x.js", false},
+		{"https://example.com/a/b", "http://other.com/", "http://other.com/", false},
+		{"https://example.com/a/b", "http://[::1", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			resolved, err := ResolveURL(tt.base, tt.ref)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ResolveURL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if resolved != tt.expected {
+				t.Errorf("ResolveURL() got = %v, want %v", resolved, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStripFragment(t *testing.T) {
+	tests := map[string]string{
+		"https://example.com/page#section": "https://example.com/page",
+		"https://example.com/page":         "https://example.com/page",
+		"#top":                             "",
+	}
+
+	for input, expected := range tests {
+		if result := StripFragment(input); result != expected {
+			t.Errorf("StripFragment(%q) = %q; want %q", input, result, expected)
+		}
+	}
+}
+
+func TestCollectAnchorTargets(t *testing.T) {
+	doc, _ := goquery.NewDocumentFromReader(strings.NewReader(
+		`<section id="intro"></section><a name="legacy"></a><div name="ignored"></div><h2 id="faq">FAQ</h2>`))
+
+	targets := CollectAnchorTargets(doc)
+
+	for _, expected := range []string{"intro", "legacy", "faq"} {
+		if !targets[expected] {
+			t.Errorf("Expected %q to be an anchor target", expected)
+		}
+	}
+
+	if targets["ignored"] {
+		t.Errorf("Expected name attributes outside <a> to be ignored")
+	}
+}
@@ -1,11 +1,16 @@
 package webfetch
 
 import (
+	"context"
 	"errors"
 	"lt-app/internal/applogger"
+	"lt-app/internal/constants"
 	"lt-app/internal/myhttp"
 	"net/http"
+	"net/http/httptest"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/jarcoal/httpmock"
 	"github.com/stretchr/testify/assert"
@@ -98,24 +103,39 @@ func TestFetchWebPageSourceContent(t *testing.T) {
 	httpmock.RegisterResponder("GET", "http://example.com/success",
 		httpmock.NewStringResponder(http.StatusOK, "OK"))
 
-	content, err := fetcher.Fetch("http://example.com/success", applogger.Logger)
+	responder := httpmock.NewStringResponder(http.StatusOK, "OK").
+		HeaderSet(http.Header{"Strict-Transport-Security": {"max-age=31536000"}})
+	httpmock.RegisterResponder("GET", "http://example.com/headers", responder)
+
+	source, err := fetcher.Fetch(context.Background(), "http://example.com/success", applogger.Logger)
+	if err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if source.Body != "OK" {
+		t.Errorf("Expected content 'OK', got %s", source.Body)
+	}
+	if source.StatusCode != http.StatusOK || source.URL != "http://example.com/success" {
+		t.Errorf("Unexpected page source %+v", source)
+	}
+
+	source, err = fetcher.Fetch(context.Background(), "http://example.com/headers", applogger.Logger)
 	if err != nil {
 		t.Errorf("Unexpected error: %v", err)
 	}
-	if content != "OK" {
-		t.Errorf("Expected content 'OK', got %s", content)
+	if hsts := source.Headers.Get("Strict-Transport-Security"); hsts != "max-age=31536000" {
+		t.Errorf("Expected response headers to be captured, got %q", hsts)
 	}
 
 	// Test case 2: Inaccessible link (404 Not Found)
 	httpmock.RegisterResponder("GET", "http://example.com/notfound",
 		httpmock.NewStringResponder(http.StatusNotFound, "Not Found"))
 
-	content, err = fetcher.Fetch("http://example.com/notfound", applogger.Logger)
+	source, err = fetcher.Fetch(context.Background(), "http://example.com/notfound", applogger.Logger)
 	if err == nil {
 		t.Errorf("Missing expected error")
 	}
-	if content != "" {
-		t.Errorf("Expected empty content, got %s", content)
+	if source != nil {
+		t.Errorf("Expected no page source, got %+v", source)
 	}
 
 	// Test case 3: Error during request
@@ -124,12 +144,12 @@ func TestFetchWebPageSourceContent(t *testing.T) {
 			return nil, errors.New("simulated error")
 		})
 
-	content, err = fetcher.Fetch("http://example.com/error", applogger.Logger)
+	source, err = fetcher.Fetch(context.Background(), "http://example.com/error", applogger.Logger)
 	if err == nil {
 		t.Errorf("Missing expected error")
 	}
-	if content != "" {
-		t.Errorf("Expected empty content, got %s", content)
+	if source != nil {
+		t.Errorf("Expected no page source, got %+v", source)
 	}
 }
 
@@ -218,15 +238,18 @@ func TestWebFetcher_checkLinkAccessibilityWithResty(t *testing.T) {
 	httpmock.RegisterResponder("GET", "http://example.com/success",
 		httpmock.NewStringResponder(http.StatusOK, "OK"))
 
-	accessible := fetcher.checkLinkAccessibilityWithResty("http://example.com/success")
-	assert.True(t, accessible, "Expected link to be accessible")
+	result := fetcher.checkLinkAccessibilityWithResty(context.Background(), "http://example.com/success")
+	assert.True(t, result.Accessible, "Expected link to be accessible")
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.NotNil(t, result.Timing)
 
 	// Test case 2: Inaccessible link (404 Not Found)
 	httpmock.RegisterResponder("GET", "http://example.com/notfound",
 		httpmock.NewStringResponder(http.StatusNotFound, "Not Found"))
 
-	accessible = fetcher.checkLinkAccessibilityWithResty("http://example.com/notfound")
-	assert.False(t, accessible, "Expected link to be inaccessible")
+	result = fetcher.checkLinkAccessibilityWithResty(context.Background(), "http://example.com/notfound")
+	assert.False(t, result.Accessible, "Expected link to be inaccessible")
+	assert.Equal(t, http.StatusNotFound, result.StatusCode)
 
 	// // Test case 3: Error during request
 	httpmock.RegisterResponder("GET", "http://example.com/error",
@@ -234,6 +257,144 @@ func TestWebFetcher_checkLinkAccessibilityWithResty(t *testing.T) {
 			return nil, errors.New("simulated error")
 		})
 
-	accessible = fetcher.checkLinkAccessibilityWithResty("http://example.com/error")
-	assert.False(t, accessible, "Expected link to be inaccessible due to error")
+	result = fetcher.checkLinkAccessibilityWithResty(context.Background(), "http://example.com/error")
+	assert.False(t, result.Accessible, "Expected link to be inaccessible due to error")
+	assert.NotEmpty(t, result.Error)
+}
+
+func TestCheckLinks(t *testing.T) {
+	applogger.InitLogger()
+	rclient := myhttp.NewRestyClient()
+	fetcher := NewWebFetcher(rclient)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	rclient.SetTransport(httpmock.DefaultTransport)
+
+	httpmock.RegisterResponder("GET", "http://example.com/ok",
+		httpmock.NewStringResponder(http.StatusOK, "OK"))
+	httpmock.RegisterResponder("GET", "http://example.com/gone",
+		httpmock.NewStringResponder(http.StatusGone, "Gone"))
+
+	results := fetcher.CheckLinks(context.Background(), []string{"http://example.com/ok", "http://example.com/gone"})
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, "http://example.com/ok", results[0].URL)
+	assert.True(t, results[0].Accessible)
+	assert.Equal(t, "http://example.com/gone", results[1].URL)
+	assert.False(t, results[1].Accessible)
+	assert.Equal(t, http.StatusGone, results[1].StatusCode)
+	for _, result := range results {
+		assert.NotNil(t, result.Timing)
+		assert.GreaterOrEqual(t, result.Timing.Total, result.Timing.Transfer)
+	}
+}
+
+func TestGetPageAnchors(t *testing.T) {
+	applogger.InitLogger()
+	rclient := myhttp.NewRestyClient()
+	fetcher := NewWebFetcher(rclient)
+
+	httpmock.Activate()
+	defer httpmock.DeactivateAndReset()
+
+	rclient.SetTransport(httpmock.DefaultTransport)
+
+	httpmock.RegisterResponder("GET", "http://example.com/docs",
+		httpmock.NewStringResponder(http.StatusOK, `<h2 id="install">Install</h2><a name="usage"></a>`))
+	httpmock.RegisterResponder("GET", "http://example.com/missing",
+		httpmock.NewStringResponder(http.StatusNotFound, "Not Found"))
+
+	anchors := fetcher.GetPageAnchors(context.Background(), []string{"http://example.com/docs", "http://example.com/missing"})
+
+	assert.Equal(t, map[string]map[string]bool{
+		"http://example.com/docs": {"install": true, "usage": true},
+	}, anchors)
+}
+
+func TestFetcher_Cancelled(t *testing.T) {
+	applogger.InitLogger()
+	fetcher := NewWebFetcher(myhttp.NewRestyClient())
+
+	// A target that hangs until the test ends
+	release := make(chan struct{})
+	requests := 0
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	started := time.Now()
+	source, err := fetcher.Fetch(ctx, srv.URL, applogger.Logger)
+	if source != nil || err == nil || err.StatusCode != http.StatusRequestTimeout {
+		t.Errorf("Expected a 408 error, got %+v, %+v", source, err)
+	}
+	if elapsed := time.Since(started); elapsed > 5*time.Second {
+		t.Errorf("Expected the fetch to end with ctx, took %v", elapsed)
+	}
+
+	// Nothing is requested once ctx is done
+	results := fetcher.CheckLinks(ctx, []string{srv.URL + "/a", srv.URL + "/b"})
+	assert.Len(t, results, 2)
+	for _, result := range results {
+		assert.False(t, result.Accessible)
+		assert.NotEmpty(t, result.Error)
+	}
+	assert.Empty(t, fetcher.GetPageAnchors(ctx, []string{srv.URL + "/docs"}))
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, requests)
+}
+
+func TestFetch_UntrustedCertificate(t *testing.T) {
+	applogger.InitLogger()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("<title>T</title>"))
+	}))
+	defer srv.Close()
+
+	// The test server's certificate is not trusted by the default transport
+	source, err := NewWebFetcher(myhttp.NewRestyClient()).Fetch(context.Background(), srv.URL, applogger.Logger)
+	if err != nil {
+		t.Fatalf("Expected the page to be fetched, got %+v", err)
+	}
+	if source.Body != "<title>T</title>" || source.TLS == nil || source.TLS.Trusted || len(source.TLS.Findings) == 0 {
+		t.Errorf("Expected the page with its certificate findings, got %+v", source.TLS)
+	}
+}
+
+func TestCheckLinks_LargeBody(t *testing.T) {
+	applogger.InitLogger()
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Sends more than is read, then stalls until the test ends
+		w.Header().Set("Content-Length", "10485760")
+		w.Write(make([]byte, 4*constants.LINK_CHECK_MAX_BODY_BYTES))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	results := NewWebFetcher(myhttp.NewRestyClient()).CheckLinks(context.Background(), []string{srv.URL})
+
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Expected the check to stop reading the body, it took %v", elapsed)
+	}
+	assert.True(t, results[0].Accessible)
+	assert.Equal(t, int64(10485760), results[0].ContentLength)
 }
@@ -1,14 +1,18 @@
 package webfetch
 
 import (
+	"context"
 	"io"
 	"log/slog"
 	"lt-app/internal/applogger"
 	"lt-app/internal/constants"
 	"lt-app/internal/myhttp"
+	"lt-app/internal/utils"
 	"net/http"
 	"strings"
 	"sync"
+
+	"github.com/PuerkitoBio/goquery"
 )
 
 // ErrorResponse represents an error response with a status code and message
@@ -17,14 +21,38 @@ type ErrorResponse struct {
 	Error      string `json:"error"`
 }
 
+// PageSource is the fetched web page along with the response metadata
+type PageSource struct {
+	// URL the page was served from after following redirects
+	URL        string
+	StatusCode int
+	Headers    http.Header
+	Body       string
+	Timing     *myhttp.Timing
+	// Connection of the final response, nil for plain HTTP
+	TLS *myhttp.TLSInfo
+}
+
+// LinkResult is the outcome of checking that a link or asset is accessible
+type LinkResult struct {
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+	Accessible bool   `json:"accessible"`
+	Error      string `json:"error,omitempty"`
+	// Size of the body from its Content-Length header, 0 when unknown
+	ContentLength int64          `json:"contentLength,omitempty"`
+	Timing        *myhttp.Timing `json:"timing"`
+}
+
 type FetchPageSourceResult struct {
-	BodyBytes          []byte
+	PageSource         *PageSource
 	FetchErrorResponse *ErrorResponse
 }
 
 type IFetcher interface {
-	Fetch(webPageurl string, RLogger *slog.Logger) (string, *ErrorResponse)
-	GetInaccessibleLinks(urls []string) []string
+	Fetch(ctx context.Context, webPageurl string, RLogger *slog.Logger) (*PageSource, *ErrorResponse)
+	CheckLinks(ctx context.Context, urls []string) []LinkResult
+	GetPageAnchors(ctx context.Context, urls []string) map[string]map[string]bool
 }
 
 type WebFetcher struct {
@@ -35,12 +63,13 @@ func NewWebFetcher(client myhttp.HTTPClient) *WebFetcher {
 	return &WebFetcher{httpClient: client}
 }
 
-func (f *WebFetcher) Fetch(webPageurl string, RLogger *slog.Logger) (string, *ErrorResponse) {
+// Fetch requests the page. A cancelled ctx ends the request with a 408 error.
+func (f *WebFetcher) Fetch(ctx context.Context, webPageurl string, RLogger *slog.Logger) (*PageSource, *ErrorResponse) {
 	var wg sync.WaitGroup
 	fetchResult := make(chan FetchPageSourceResult)
 
 	wg.Add(1)
-	go f.fetchPageSource(webPageurl, &wg, fetchResult, RLogger)
+	go f.fetchPageSource(ctx, webPageurl, &wg, fetchResult, RLogger)
 
 	go func() {
 		wg.Wait()
@@ -50,24 +79,26 @@ func (f *WebFetcher) Fetch(webPageurl string, RLogger *slog.Logger) (string, *Er
 	result := <-fetchResult
 
 	if result.FetchErrorResponse != nil {
-		return "", result.FetchErrorResponse
+		return nil, result.FetchErrorResponse
 	}
 
-	return string(result.BodyBytes), nil
+	return result.PageSource, nil
 }
 
-func (f *WebFetcher) fetchPageSource(webPageurl string, wg *sync.WaitGroup, fetchResult chan<- FetchPageSourceResult, RLogger *slog.Logger) {
+func (f *WebFetcher) fetchPageSource(ctx context.Context, webPageurl string, wg *sync.WaitGroup, fetchResult chan<- FetchPageSourceResult, RLogger *slog.Logger) {
 	defer wg.Done()
 
 	// Use the injected httpClient
-	resp, err := f.httpClient.Get(webPageurl)
+	resp, timing, tlsInfo, err := f.httpClient.GetPage(ctx, webPageurl)
 
 	if err != nil {
 		RLogger.Error("Request Error:", "error", err)
 
 		var errorResponse *ErrorResponse
 
-		if strings.Contains(err.Error(), "no such host") {
+		if ctx.Err() != nil {
+			errorResponse = BuildErrorResponse(http.StatusRequestTimeout, "The analysis was cancelled")
+		} else if strings.Contains(err.Error(), "no such host") {
 			errorResponse = BuildErrorResponse(http.StatusBadRequest, "Domain of the url seems to be invalid.")
 		} else {
 			errorResponse = BuildErrorResponse(http.StatusInternalServerError, "Something went wrong")
@@ -90,11 +121,27 @@ func (f *WebFetcher) fetchPageSource(webPageurl string, wg *sync.WaitGroup, fetc
 
 	if err != nil {
 		RLogger.Error("Error reading response body", "error", err)
-		fetchResult <- FetchPageSourceResult{nil, BuildErrorResponse(http.StatusInternalServerError, "Something went wrong")}
+		errorResponse := BuildErrorResponse(http.StatusInternalServerError, "Something went wrong")
+		if ctx.Err() != nil {
+			errorResponse = BuildErrorResponse(http.StatusRequestTimeout, "The analysis was cancelled")
+		}
+		fetchResult <- FetchPageSourceResult{nil, errorResponse}
 		return
 	}
 
-	fetchResult <- FetchPageSourceResult{bodyBytes, nil}
+	finalUrl := webPageurl
+	if resp.RawResponse != nil && resp.RawResponse.Request != nil {
+		finalUrl = resp.RawResponse.Request.URL.String()
+	}
+
+	fetchResult <- FetchPageSourceResult{&PageSource{
+		URL:        finalUrl,
+		StatusCode: resp.StatusCode(),
+		Headers:    resp.Header(),
+		Body:       string(bodyBytes),
+		Timing:     timing,
+		TLS:        tlsInfo,
+	}, nil}
 }
 
 func BuildErrorResponse(statusCode int, message string) *ErrorResponse {
@@ -118,54 +165,142 @@ func BuildErrorResponse(statusCode int, message string) *ErrorResponse {
 }
 
 func (f *WebFetcher) GetInaccessibleLinks(urls []string) []string {
-	// Count the number of inaccessible links
-	var wg sync.WaitGroup
-	semaphore := make(chan struct{}, constants.CONCURRENT_GOROUTINE_LIMIT) // Limit the number of concurrent requests
-
 	// Reduce reallocation by setting the capacity of the slice
 	var inaccessibleLinks = make([]string, 0, constants.INACC_LINKS_INIT_CAP)
-	var mu sync.Mutex
 
-	wg.Add(len(urls))
+	for _, result := range f.CheckLinks(context.Background(), urls) {
+		if !result.Accessible {
+			inaccessibleLinks = append(inaccessibleLinks, result.URL)
+		}
+	}
 
-	for _, link := range urls {
-		semaphore <- struct{}{} // Acquire a semaphore
+	return inaccessibleLinks
+}
+
+// CheckLinks requests every URL and returns the results in the order of the URLs.
+// Once ctx is cancelled no more requests are started and the remaining URLs are
+// reported with the error of ctx.
+func (f *WebFetcher) CheckLinks(ctx context.Context, urls []string) []LinkResult {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, constants.CONCURRENT_GOROUTINE_LIMIT) // Limit the number of concurrent requests
+
+	// Every goroutine writes to its own index, so no lock is needed
+	results := make([]LinkResult, len(urls))
+
+	for i, link := range urls {
+		select {
+		case semaphore <- struct{}{}: // Acquire a semaphore
+		case <-ctx.Done():
+			results[i] = LinkResult{URL: link, Error: ctx.Err().Error()}
+			continue
+		}
 
-		go func(link string) {
+		wg.Add(1)
+		go func(i int, link string) {
 			defer func() {
 				<-semaphore // Release the semaphore
 				wg.Done()
 			}()
-			if !f.checkLinkAccessibilityWithResty(link) {
-				mu.Lock()
-				inaccessibleLinks = append(inaccessibleLinks, link)
-				mu.Unlock()
-			}
-		}(link)
+			results[i] = f.checkLinkAccessibilityWithResty(ctx, link)
+		}(i, link)
 	}
 
 	wg.Wait()
 
-	return inaccessibleLinks
+	return results
 }
 
 /*
-Function to check the accessibility of a link using the HEAD method with Resty.
+Function to check the accessibility of a link with a GET request using Resty.
+Up to LINK_CHECK_MAX_BODY_BYTES of the body are read to time the start of the
+transfer, and the size of the body is taken from its Content-Length.
 */
-func (f *WebFetcher) checkLinkAccessibilityWithResty(url string) bool {
-	resp, err := f.httpClient.Get(url)
+func (f *WebFetcher) checkLinkAccessibilityWithResty(ctx context.Context, url string) LinkResult {
+	result := LinkResult{URL: url}
+	resp, timing, err := f.httpClient.GetWithTiming(ctx, url)
+	result.Timing = timing
 
 	if err != nil {
 		applogger.Logger.Info("Failed to check link accessibility", "url", url, "error", err)
-		return false
+		result.Error = err.Error()
+		return result
+	}
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.RawBody(), constants.LINK_CHECK_MAX_BODY_BYTES))
+	resp.RawBody().Close()
+
+	result.StatusCode = resp.StatusCode()
+	result.ContentLength = max(resp.RawResponse.ContentLength, 0)
+	result.Accessible = resp.StatusCode() == http.StatusOK
+
+	if !result.Accessible {
+		applogger.Logger.Info("Inaccessible link", "url", url, "statusCode", resp.StatusCode())
+	}
+
+	return result
+}
+
+// GetPageAnchors fetches the pages and returns the fragments each of them can
+// scroll to. Pages that cannot be fetched or parsed, or that were not fetched
+// before ctx was cancelled, are left out of the result.
+func (f *WebFetcher) GetPageAnchors(ctx context.Context, urls []string) map[string]map[string]bool {
+	var wg sync.WaitGroup
+	semaphore := make(chan struct{}, constants.CONCURRENT_GOROUTINE_LIMIT) // Limit the number of concurrent requests
+
+	anchors := make(map[string]map[string]bool, len(urls))
+	var mu sync.Mutex
+
+	for _, pageUrl := range urls {
+		select {
+		case semaphore <- struct{}{}: // Acquire a semaphore
+		case <-ctx.Done():
+			continue
+		}
+
+		wg.Add(1)
+		go func(pageUrl string) {
+			defer func() {
+				<-semaphore // Release the semaphore
+				wg.Done()
+			}()
+
+			targets := f.fetchAnchorTargets(ctx, pageUrl)
+			if targets == nil {
+				return
+			}
+
+			mu.Lock()
+			anchors[pageUrl] = targets
+			mu.Unlock()
+		}(pageUrl)
+	}
+
+	wg.Wait()
+
+	return anchors
+}
+
+func (f *WebFetcher) fetchAnchorTargets(ctx context.Context, pageUrl string) map[string]bool {
+	resp, err := f.httpClient.Get(ctx, pageUrl)
+
+	if err != nil {
+		applogger.Logger.Info("Failed to fetch page for anchors", "url", pageUrl, "error", err)
+		return nil
 	}
 
 	defer resp.RawBody().Close()
 
 	if resp.StatusCode() != http.StatusOK {
-		applogger.Logger.Info("Inaccessible link", "url", url, "statusCode", resp.StatusCode())
-		return false
+		applogger.Logger.Info("Page for anchors is inaccessible", "url", pageUrl, "statusCode", resp.StatusCode())
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(io.LimitReader(resp.RawBody(), constants.MAX_ALLOWED_HTML_SIZE))
+
+	if err != nil {
+		applogger.Logger.Info("Failed to parse page for anchors", "url", pageUrl, "error", err)
+		return nil
 	}
 
-	return true
+	return utils.CollectAnchorTargets(doc)
 }
@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"lt-app/internal/constants"
+	"lt-app/internal/monitor"
+	"lt-app/internal/pagestats"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// MonitorRun is the data of the monitor events
+type MonitorRun struct {
+	Monitor *monitor.Monitor   `json:"monitor"`
+	Run     *monitor.RunStatus `json:"run"`
+	Alerts  []monitor.Alert    `json:"alerts"`
+}
+
+// Analysis is the data of the analysis events
+type Analysis struct {
+	URL   string                  `json:"url"`
+	Stats *pagestats.WebPageStats `json:"stats"`
+}
+
+// Dispatcher posts events to the subscriptions that receive them. Deliveries run in
+// the background and are retried with a doubling delay until they succeed or
+// run out of attempts.
+type Dispatcher struct {
+	store  *Store
+	client *resty.Client
+	logger *slog.Logger
+	now    func() time.Time
+
+	maxAttempts int
+	// Delay before the first retry, doubled after each failed attempt
+	retryDelay time.Duration
+
+	// Cancelled by Stop, which ends the pending retries
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func NewDispatcher(store *Store, logger *slog.Logger) *Dispatcher {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Dispatcher{
+		store:       store,
+		client:      resty.New().SetTimeout(constants.WEBHOOK_TIMEOUT_SECONDS * time.Second),
+		logger:      logger,
+		now:         time.Now,
+		maxAttempts: constants.WEBHOOK_MAX_ATTEMPTS,
+		retryDelay:  constants.WEBHOOK_RETRY_DELAY_SECONDS * time.Second,
+		ctx:         ctx,
+		cancel:      cancel,
+	}
+}
+
+// Publish stores a pending delivery of the event for every subscription that
+// receives it and starts delivering them
+func (d *Dispatcher) Publish(event string, data any) {
+	subscriptions, err := d.store.List()
+	if err != nil {
+		d.logger.Error("Failed to list the webhooks", "event", event, "error", err)
+		return
+	}
+
+	for i := range subscriptions {
+		subscription := subscriptions[i]
+		if !subscription.Receives(event) {
+			continue
+		}
+		delivery := &Delivery{
+			SubscriptionID: subscription.ID,
+			Event:          event,
+			Status:         StatusPending,
+			CreatedAt:      d.now().UTC(),
+			Attempts:       []Attempt{},
+		}
+		if err := d.store.CreateDelivery(delivery); err != nil {
+			d.logger.Error("Failed to store the delivery", "webhookId", subscription.ID, "event", event, "error", err)
+			continue
+		}
+		// The payload holds the delivery ID, so receivers can ignore retries they already handled
+		delivery.Payload, err = json.Marshal(Payload{ID: delivery.ID, Event: event, CreatedAt: delivery.CreatedAt, Data: data})
+		if err != nil {
+			d.logger.Error("Failed to encode the payload", "webhookId", subscription.ID, "event", event, "error", err)
+			continue
+		}
+		d.save(delivery)
+
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(&subscription, delivery)
+		}()
+	}
+}
+
+// PublishMonitorRun publishes the end of a monitor run, and its regressions when
+// it raised alerts. It is registered with monitor.Scheduler.OnRun.
+func (d *Dispatcher) PublishMonitorRun(m *monitor.Monitor, status *monitor.RunStatus, alerts []monitor.Alert) {
+	data := MonitorRun{Monitor: m, Run: status, Alerts: alerts}
+	d.Publish(EventMonitorRunCompleted, data)
+	if len(alerts) > 0 {
+		d.Publish(EventMonitorRegressed, data)
+	}
+}
+
+// PublishAnalysis publishes an analysis made through the API
+func (d *Dispatcher) PublishAnalysis(url string, stats *pagestats.WebPageStats) {
+	d.Publish(EventAnalysisCompleted, Analysis{URL: url, Stats: stats})
+}
+
+// Resume starts delivering again the deliveries left pending when the server
+// stopped, with the attempts they have left. It is called once on start, before
+// any event is published. Deliveries of paused subscriptions stay pending.
+func (d *Dispatcher) Resume() error {
+	deliveries, err := d.store.PendingDeliveries()
+	if err != nil {
+		return err
+	}
+
+	resumed := 0
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		subscription, err := d.store.Get(delivery.SubscriptionID)
+		if err != nil {
+			d.logger.Error("Failed to resume the delivery", "webhookId", delivery.SubscriptionID, "deliveryId", delivery.ID, "error", err)
+			continue
+		}
+		if subscription.Paused {
+			continue
+		}
+
+		resumed++
+		d.wg.Add(1)
+		go func() {
+			defer d.wg.Done()
+			d.deliver(subscription, delivery)
+		}()
+	}
+	d.logger.Info("Resumed the pending webhook deliveries", "deliveries", resumed)
+	return nil
+}
+
+// Wait waits for the deliveries in progress, retries included
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+// Stop cancels the pending retries and waits for the deliveries in progress.
+// Deliveries that were not done stay pending.
+func (d *Dispatcher) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) deliver(subscription *Subscription, delivery *Delivery) {
+	logger := d.logger.With("webhookId", subscription.ID, "deliveryId", delivery.ID, "event", delivery.Event)
+	// Resumed deliveries continue from the attempts they already made
+	for attempt := len(delivery.Attempts) + 1; attempt <= d.maxAttempts; attempt++ {
+		result := d.post(subscription, delivery)
+		delivery.Attempts = append(delivery.Attempts, result)
+		if result.Error == "" {
+			delivery.Status = StatusDelivered
+			d.save(delivery)
+			return
+		}
+		logger.Warn("Webhook delivery failed", "attempt", attempt, "statusCode", result.StatusCode, "error", result.Error)
+
+		if attempt == d.maxAttempts {
+			break
+		}
+		d.save(delivery)
+		select {
+		case <-d.ctx.Done():
+			return
+		case <-time.After(d.retryDelay << (attempt - 1)):
+		}
+	}
+	delivery.Status = StatusFailed
+	d.save(delivery)
+	logger.Error("Webhook delivery failed after every attempt", "attempts", d.maxAttempts)
+}
+
+// post makes one attempt. Each attempt is signed with its own timestamp.
+func (d *Dispatcher) post(subscription *Subscription, delivery *Delivery) Attempt {
+	started := time.Now()
+	sent := d.now()
+	timestamp := sent.Unix()
+	request := d.client.R().
+		SetContext(d.ctx).
+		SetHeader("Content-Type", "application/json").
+		SetHeader(HeaderEvent, delivery.Event).
+		SetHeader(HeaderDelivery, delivery.ID).
+		SetHeader(HeaderTimestamp, strconv.FormatInt(timestamp, 10)).
+		SetBody([]byte(delivery.Payload))
+	if subscription.Secret != "" {
+		request.SetHeader(HeaderSignature, Sign(subscription.Secret, timestamp, delivery.Payload))
+	}
+
+	response, err := request.Post(subscription.URL)
+	attempt := Attempt{At: sent.UTC(), DurationMs: float64(time.Since(started).Microseconds()) / 1000}
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	attempt.StatusCode = response.StatusCode()
+	if !response.IsSuccess() {
+		attempt.Error = "unexpected status " + response.Status()
+	}
+	return attempt
+}
+
+func (d *Dispatcher) save(delivery *Delivery) {
+	if err := d.store.UpdateDelivery(delivery); err != nil {
+		d.logger.Error("Failed to store the delivery", "deliveryId", delivery.ID, "error", err)
+	}
+}
@@ -0,0 +1,301 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"lt-app/internal/monitor"
+	"lt-app/internal/pagestats"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// receiver records the deliveries it gets and fails the first ones
+type receiver struct {
+	mu       sync.Mutex
+	failures int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *receiver) ServeHTTP(w http.ResponseWriter, request *http.Request) {
+	body, _ := io.ReadAll(request.Body)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, request)
+	r.bodies = append(r.bodies, body)
+	if len(r.requests) <= r.failures {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func testDispatcher(t *testing.T, failures int) (*Dispatcher, *Store, *receiver, string) {
+	store := testStore(t, 0)
+	received := &receiver{failures: failures}
+	server := httptest.NewServer(received)
+	t.Cleanup(server.Close)
+
+	dispatcher := NewDispatcher(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	dispatcher.maxAttempts = 3
+	dispatcher.retryDelay = time.Millisecond
+	t.Cleanup(dispatcher.Stop)
+	return dispatcher, store, received, server.URL
+}
+
+func TestDispatcher_Publish(t *testing.T) {
+	dispatcher, store, received, url := testDispatcher(t, 0)
+	store.Create(&Subscription{URL: url + "/hooks", Secret: "0123456789abcdef"})
+	store.Create(&Subscription{URL: url + "/paused", Paused: true})
+	store.Create(&Subscription{URL: url + "/regressions", Events: []string{EventMonitorRegressed}})
+
+	dispatcher.Publish(EventMonitorRunCompleted, map[string]string{"url": "https://example.com"})
+	dispatcher.Wait()
+
+	if len(received.requests) != 1 {
+		t.Fatalf("Expected one delivery, got %d", len(received.requests))
+	}
+	request, body := received.requests[0], received.bodies[0]
+	if request.URL.Path != "/hooks" || request.Header.Get("Content-Type") != "application/json" ||
+		request.Header.Get(HeaderEvent) != EventMonitorRunCompleted || request.Header.Get(HeaderDelivery) != "1" {
+		t.Errorf("Unexpected request %s %+v", request.URL.Path, request.Header)
+	}
+	if !Verify("0123456789abcdef", request.Header.Get(HeaderSignature), request.Header.Get(HeaderTimestamp), body) {
+		t.Errorf("Expected a valid signature, got %q", request.Header.Get(HeaderSignature))
+	}
+
+	var payload struct {
+		ID    string            `json:"id"`
+		Event string            `json:"event"`
+		Data  map[string]string `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.ID != "1" || payload.Event != EventMonitorRunCompleted || payload.Data["url"] != "https://example.com" {
+		t.Errorf("Unexpected payload %s, %v", body, err)
+	}
+
+	deliveries, _ := store.Deliveries("1", 10)
+	if len(deliveries) != 1 || deliveries[0].Status != StatusDelivered || len(deliveries[0].Attempts) != 1 ||
+		deliveries[0].Attempts[0].StatusCode != http.StatusNoContent || string(deliveries[0].Payload) != string(body) {
+		t.Errorf("Expected the delivery to be logged, got %+v", deliveries)
+	}
+	for _, id := range []string{"2", "3"} {
+		if deliveries, _ := store.Deliveries(id, 10); len(deliveries) != 0 {
+			t.Errorf("Expected no deliveries for webhook %s, got %+v", id, deliveries)
+		}
+	}
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	tests := []struct {
+		name     string
+		failures int
+		status   string
+		codes    []int
+	}{
+		{"delivered", 0, StatusDelivered, []int{204}},
+		{"delivered after retries", 2, StatusDelivered, []int{500, 500, 204}},
+		{"failed", 3, StatusFailed, []int{500, 500, 500}},
+	}
+
+	for _, test := range tests {
+		dispatcher, store, received, url := testDispatcher(t, test.failures)
+		store.Create(&Subscription{URL: url, Secret: "0123456789abcdef"})
+
+		dispatcher.Publish(EventMonitorRegressed, nil)
+		dispatcher.Wait()
+
+		deliveries, _ := store.Deliveries("1", 10)
+		if len(deliveries) != 1 || deliveries[0].Status != test.status {
+			t.Fatalf("%s: expected a %s delivery, got %+v", test.name, test.status, deliveries)
+		}
+		codes := []int{}
+		for _, attempt := range deliveries[0].Attempts {
+			codes = append(codes, attempt.StatusCode)
+			if (attempt.StatusCode == 500) != (attempt.Error != "") {
+				t.Errorf("%s: unexpected attempt %+v", test.name, attempt)
+			}
+		}
+		if !reflect.DeepEqual(codes, test.codes) {
+			t.Errorf("%s: expected attempts %v, got %v", test.name, test.codes, codes)
+		}
+
+		// Retries send the same payload, each signed on its own
+		for i, request := range received.requests {
+			if request.Header.Get(HeaderDelivery) != "1" || string(received.bodies[i]) != string(deliveries[0].Payload) ||
+				!Verify("0123456789abcdef", request.Header.Get(HeaderSignature), request.Header.Get(HeaderTimestamp), received.bodies[i]) {
+				t.Errorf("%s: unexpected attempt %d %+v", test.name, i, request.Header)
+			}
+		}
+	}
+}
+
+func TestDispatcher_Unreachable(t *testing.T) {
+	dispatcher, store, _, url := testDispatcher(t, 0)
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	store.Create(&Subscription{URL: server.URL})
+	store.Create(&Subscription{URL: url})
+
+	dispatcher.Publish(EventMonitorRunCompleted, nil)
+	dispatcher.Wait()
+
+	deliveries, _ := store.Deliveries("1", 10)
+	if len(deliveries) != 1 || deliveries[0].Status != StatusFailed || len(deliveries[0].Attempts) != 3 ||
+		deliveries[0].Attempts[0].StatusCode != 0 || deliveries[0].Attempts[0].Error == "" {
+		t.Errorf("Expected a failed delivery, got %+v", deliveries)
+	}
+	if deliveries, _ := store.Deliveries("2", 10); len(deliveries) != 1 || deliveries[0].Status != StatusDelivered {
+		t.Errorf("Expected other webhooks to be delivered, got %+v", deliveries)
+	}
+}
+
+func TestDispatcher_PublishMonitorRun(t *testing.T) {
+	tests := []struct {
+		name     string
+		alerts   []monitor.Alert
+		expected []string
+	}{
+		{"no alerts", []monitor.Alert{}, []string{EventMonitorRunCompleted}},
+		{"alerts", []monitor.Alert{{Kind: monitor.AlertTitleChanged}}, []string{EventMonitorRunCompleted, EventMonitorRegressed}},
+	}
+
+	for _, test := range tests {
+		dispatcher, store, received, url := testDispatcher(t, 0)
+		store.Create(&Subscription{URL: url})
+
+		dispatcher.PublishMonitorRun(&monitor.Monitor{ID: "7", URL: "https://example.com"}, &monitor.RunStatus{HistoryID: "3", Alerts: len(test.alerts)}, test.alerts)
+		dispatcher.Wait()
+
+		events := []string{}
+		deliveries, _ := store.Deliveries("1", 10)
+		for i := len(deliveries) - 1; i >= 0; i-- {
+			events = append(events, deliveries[i].Event)
+		}
+		if !reflect.DeepEqual(events, test.expected) || len(received.requests) != len(test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, events)
+		}
+
+		var payload struct {
+			Data MonitorRun `json:"data"`
+		}
+		json.Unmarshal(received.bodies[0], &payload)
+		if payload.Data.Monitor == nil || payload.Data.Monitor.ID != "7" || payload.Data.Run.HistoryID != "3" || len(payload.Data.Alerts) != len(test.alerts) {
+			t.Errorf("%s: unexpected payload %s", test.name, received.bodies[0])
+		}
+	}
+}
+
+func TestDispatcher_Stop(t *testing.T) {
+	dispatcher, store, received, url := testDispatcher(t, 10)
+	dispatcher.retryDelay = time.Hour
+	store.Create(&Subscription{URL: url})
+
+	dispatcher.Publish(EventMonitorRunCompleted, nil)
+	done := make(chan struct{})
+	go func() {
+		// Waits for the first attempt, then stops during the retry delay
+		for {
+			received.mu.Lock()
+			count := len(received.requests)
+			received.mu.Unlock()
+			if count > 0 {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		dispatcher.Stop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected Stop to cancel the retries")
+	}
+	deliveries, _ := store.Deliveries("1", 10)
+	if len(deliveries) != 1 || deliveries[0].Status != StatusPending || len(deliveries[0].Attempts) != 1 {
+		t.Errorf("Expected a pending delivery, got %+v", deliveries)
+	}
+}
+
+func TestDispatcher_PublishAnalysis(t *testing.T) {
+	dispatcher, store, received, url := testDispatcher(t, 0)
+	store.Create(&Subscription{URL: url, Events: []string{EventAnalysisCompleted}})
+
+	dispatcher.PublishAnalysis("https://example.com", &pagestats.WebPageStats{})
+	dispatcher.Wait()
+
+	var payload struct {
+		Event string `json:"event"`
+		Data  struct {
+			URL   string          `json:"url"`
+			Stats json.RawMessage `json:"stats"`
+		} `json:"data"`
+	}
+	if len(received.bodies) != 1 {
+		t.Fatalf("Expected one delivery, got %d", len(received.bodies))
+	}
+	if err := json.Unmarshal(received.bodies[0], &payload); err != nil || payload.Event != EventAnalysisCompleted ||
+		payload.Data.URL != "https://example.com" || len(payload.Data.Stats) == 0 {
+		t.Errorf("Unexpected payload %s, %v", received.bodies[0], err)
+	}
+}
+
+func TestDispatcher_Resume(t *testing.T) {
+	dispatcher, store, received, url := testDispatcher(t, 0)
+	store.Create(&Subscription{URL: url})
+	store.Create(&Subscription{URL: url, Paused: true})
+
+	// Deliveries as left by a server that stopped during their retries
+	pending := func(subscriptionID string, attempts int, status string) *Delivery {
+		delivery := &Delivery{SubscriptionID: subscriptionID, Event: EventMonitorRegressed, Status: status}
+		store.CreateDelivery(delivery)
+		delivery.Payload = json.RawMessage(`{"id":"` + delivery.ID + `"}`)
+		for range attempts {
+			delivery.Attempts = append(delivery.Attempts, Attempt{StatusCode: http.StatusBadGateway, Error: "unexpected status"})
+		}
+		store.UpdateDelivery(delivery)
+		return delivery
+	}
+	retried := pending("1", 1, StatusPending)
+	pending("1", 1, StatusFailed)
+	paused := pending("2", 1, StatusPending)
+
+	if err := dispatcher.Resume(); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	dispatcher.Wait()
+
+	if len(received.requests) != 1 || received.requests[0].Header.Get(HeaderDelivery) != retried.ID || string(received.bodies[0]) != string(retried.Payload) {
+		t.Fatalf("Expected only the pending delivery to be resumed, got %d requests", len(received.requests))
+	}
+	deliveries, _ := store.Deliveries("1", 10)
+	if deliveries[1].Status != StatusDelivered || len(deliveries[1].Attempts) != 2 {
+		t.Errorf("Expected the resumed delivery to continue its attempts, got %+v", deliveries[1])
+	}
+	if deliveries, _ := store.Deliveries("2", 10); deliveries[0].ID != paused.ID || deliveries[0].Status != StatusPending {
+		t.Errorf("Expected deliveries of paused webhooks to stay pending, got %+v", deliveries)
+	}
+}
+
+func TestDispatcher_Resume_LastAttempt(t *testing.T) {
+	dispatcher, store, received, url := testDispatcher(t, 10)
+	store.Create(&Subscription{URL: url})
+	delivery := &Delivery{SubscriptionID: "1", Event: EventMonitorRegressed, Status: StatusPending}
+	store.CreateDelivery(delivery)
+	delivery.Attempts = []Attempt{{Error: "timeout"}, {Error: "timeout"}}
+	store.UpdateDelivery(delivery)
+
+	dispatcher.Resume()
+	dispatcher.Wait()
+
+	deliveries, _ := store.Deliveries("1", 10)
+	if len(received.requests) != 1 || deliveries[0].Status != StatusFailed || len(deliveries[0].Attempts) != 3 {
+		t.Errorf("Expected a single attempt left, got %d requests and %+v", len(received.requests), deliveries[0])
+	}
+}
@@ -0,0 +1,209 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"lt-app/internal/constants"
+	"lt-app/internal/storage"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// Every subscription by ID
+	subscriptionsBucket = []byte("webhooks")
+	// A bucket of deliveries per subscription, ordered by ID
+	deliveriesBucket = []byte("webhook-deliveries")
+)
+
+// ErrNotFound is returned for subscriptions that do not exist or were deleted
+var ErrNotFound = errors.New("webhook not found")
+
+// Store keeps subscriptions and their deliveries in the embedded database
+type Store struct {
+	db *bolt.DB
+	// Deliveries kept per subscription, the oldest are removed first
+	maxDeliveries int
+}
+
+func NewStore(db *bolt.DB, maxDeliveries int) (*Store, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		for _, name := range [][]byte{subscriptionsBucket, deliveriesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db, maxDeliveries: maxDeliveries}, nil
+}
+
+// Create stores a new subscription and assigns its ID
+func (s *Store) Create(subscription *Subscription) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		subscriptions := tx.Bucket(subscriptionsBucket)
+		if subscriptions.Stats().KeyN >= constants.WEBHOOK_MAX_CAP {
+			return ErrLimitReached
+		}
+		seq, err := subscriptions.NextSequence()
+		if err != nil {
+			return err
+		}
+		subscription.ID = strconv.FormatUint(seq, 10)
+		return storage.PutJSON(subscriptions, subscription.ID, subscription)
+	})
+}
+
+// Update replaces the settings of a subscription, keeping its creation time and,
+// when no new one is given, its secret
+func (s *Store) Update(subscription *Subscription) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		subscriptions := tx.Bucket(subscriptionsBucket)
+		stored, err := get(subscriptions, subscription.ID)
+		if err != nil {
+			return err
+		}
+		subscription.CreatedAt = stored.CreatedAt
+		if subscription.Secret == "" {
+			subscription.Secret = stored.Secret
+		}
+		return storage.PutJSON(subscriptions, subscription.ID, subscription)
+	})
+}
+
+// Delete removes a subscription with its deliveries
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		subscriptions := tx.Bucket(subscriptionsBucket)
+		if _, err := get(subscriptions, id); err != nil {
+			return err
+		}
+		if err := tx.Bucket(deliveriesBucket).DeleteBucket([]byte(id)); err != nil && !errors.Is(err, bolt.ErrBucketNotFound) {
+			return err
+		}
+		return subscriptions.Delete(storage.Key(id))
+	})
+}
+
+func (s *Store) Get(id string) (*Subscription, error) {
+	var subscription *Subscription
+	err := s.db.View(func(tx *bolt.Tx) error {
+		var err error
+		subscription, err = get(tx.Bucket(subscriptionsBucket), id)
+		return err
+	})
+	return subscription, err
+}
+
+// List returns every subscription, oldest first
+func (s *Store) List() ([]Subscription, error) {
+	result := []Subscription{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(subscriptionsBucket).ForEach(func(_, value []byte) error {
+			var subscription Subscription
+			if err := json.Unmarshal(value, &subscription); err != nil {
+				return err
+			}
+			result = append(result, subscription)
+			return nil
+		})
+	})
+	return result, err
+}
+
+// CreateDelivery stores a new delivery of a subscription and assigns its ID
+func (s *Store) CreateDelivery(delivery *Delivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if _, err := get(tx.Bucket(subscriptionsBucket), delivery.SubscriptionID); err != nil {
+			return err
+		}
+		all := tx.Bucket(deliveriesBucket)
+		deliveries, err := all.CreateBucketIfNotExists([]byte(delivery.SubscriptionID))
+		if err != nil {
+			return err
+		}
+		seq, err := all.NextSequence()
+		if err != nil {
+			return err
+		}
+		delivery.ID = strconv.FormatUint(seq, 10)
+		if err := storage.PutJSON(deliveries, delivery.ID, delivery); err != nil {
+			return err
+		}
+		return storage.KeepNewest(deliveries, s.maxDeliveries)
+	})
+}
+
+// UpdateDelivery stores the attempts and status of a delivery. Deliveries of
+// deleted subscriptions, or that were pruned, are not stored again.
+func (s *Store) UpdateDelivery(delivery *Delivery) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		deliveries := tx.Bucket(deliveriesBucket).Bucket([]byte(delivery.SubscriptionID))
+		if deliveries == nil || deliveries.Get(storage.Key(delivery.ID)) == nil {
+			return nil
+		}
+		return storage.PutJSON(deliveries, delivery.ID, delivery)
+	})
+}
+
+// Deliveries returns the deliveries of a subscription, newest first
+func (s *Store) Deliveries(id string, limit int) ([]Delivery, error) {
+	result := []Delivery{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if _, err := get(tx.Bucket(subscriptionsBucket), id); err != nil {
+			return err
+		}
+		deliveries := tx.Bucket(deliveriesBucket).Bucket([]byte(id))
+		if deliveries == nil {
+			return nil
+		}
+
+		cursor := deliveries.Cursor()
+		for k, value := cursor.Last(); k != nil && len(result) < limit; k, value = cursor.Prev() {
+			var delivery Delivery
+			if err := json.Unmarshal(value, &delivery); err != nil {
+				return err
+			}
+			result = append(result, delivery)
+		}
+		return nil
+	})
+	return result, err
+}
+
+// PendingDeliveries returns the deliveries of every subscription that are still
+// waiting for an attempt, oldest first per subscription
+func (s *Store) PendingDeliveries() ([]Delivery, error) {
+	result := []Delivery{}
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(deliveriesBucket).ForEachBucket(func(id []byte) error {
+			return tx.Bucket(deliveriesBucket).Bucket(id).ForEach(func(_, value []byte) error {
+				var delivery Delivery
+				if err := json.Unmarshal(value, &delivery); err != nil {
+					return err
+				}
+				if delivery.Status == StatusPending {
+					result = append(result, delivery)
+				}
+				return nil
+			})
+		})
+	})
+	return result, err
+}
+
+func get(subscriptions *bolt.Bucket, id string) (*Subscription, error) {
+	var subscription Subscription
+	found, err := storage.GetJSON(subscriptions, id, &subscription)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return &subscription, nil
+}
@@ -0,0 +1,132 @@
+package webhook
+
+import (
+	"lt-app/internal/constants"
+	"lt-app/internal/storage"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func testStore(t *testing.T, maxDeliveries int) *Store {
+	db, err := storage.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("Failed to open the database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	store, err := NewStore(db, maxDeliveries)
+	if err != nil {
+		t.Fatalf("Failed to create the store: %v", err)
+	}
+	return store
+}
+
+func TestStore_CRUD(t *testing.T) {
+	store := testStore(t, 0)
+
+	subscription := &Subscription{URL: "https://example.com/hooks", Secret: "0123456789abcdef"}
+	if err := store.Create(subscription); err != nil || subscription.ID != "1" {
+		t.Fatalf("Expected webhook 1, got %q, %v", subscription.ID, err)
+	}
+	store.Create(&Subscription{URL: "https://example.org/hooks"})
+
+	// The secret is kept when none is given
+	if err := store.Update(&Subscription{ID: "1", URL: "https://example.com/other", Paused: true}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	stored, err := store.Get("1")
+	if err != nil || stored.URL != "https://example.com/other" || !stored.Paused || stored.Secret != "0123456789abcdef" {
+		t.Errorf("Expected the updated webhook with its secret, got %+v, %v", stored, err)
+	}
+
+	if err := store.Delete("2"); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	subscriptions, _ := store.List()
+	if len(subscriptions) != 1 || subscriptions[0].ID != "1" {
+		t.Errorf("Expected webhook 1 only, got %+v", subscriptions)
+	}
+
+	for _, id := range []string{"2", "x"} {
+		if _, err := store.Get(id); err != ErrNotFound {
+			t.Errorf("Get(%q): expected ErrNotFound, got %v", id, err)
+		}
+		if err := store.Update(&Subscription{ID: id}); err != ErrNotFound {
+			t.Errorf("Update(%q): expected ErrNotFound, got %v", id, err)
+		}
+		if err := store.Delete(id); err != ErrNotFound {
+			t.Errorf("Delete(%q): expected ErrNotFound, got %v", id, err)
+		}
+	}
+}
+
+func TestStore_Limit(t *testing.T) {
+	store := testStore(t, 0)
+	for range constants.WEBHOOK_MAX_CAP {
+		store.Create(&Subscription{URL: "https://example.com"})
+	}
+
+	if err := store.Create(&Subscription{URL: "https://example.com"}); err != ErrLimitReached {
+		t.Errorf("Expected ErrLimitReached, got %v", err)
+	}
+}
+
+func TestStore_Deliveries(t *testing.T) {
+	store := testStore(t, 3)
+	store.Create(&Subscription{URL: "https://example.com"})
+	store.Create(&Subscription{URL: "https://example.org"})
+
+	for i := range 5 {
+		subscriptionID := []string{"1", "2"}[i%2]
+		if err := store.CreateDelivery(&Delivery{SubscriptionID: subscriptionID, Status: StatusPending}); err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+	}
+	store.CreateDelivery(&Delivery{SubscriptionID: "1", Status: StatusPending})
+	store.UpdateDelivery(&Delivery{ID: "6", SubscriptionID: "1", Status: StatusDelivered})
+
+	// Deliveries 1, 3, 5 and 6 belong to webhook 1, the oldest is pruned
+	deliveries, err := store.Deliveries("1", 10)
+	if err != nil || len(deliveries) != 3 || deliveries[0].ID != "6" || deliveries[0].Status != StatusDelivered || deliveries[2].ID != "3" {
+		t.Errorf("Expected deliveries 6, 5 and 3, got %+v, %v", deliveries, err)
+	}
+	if limited, _ := store.Deliveries("1", 1); len(limited) != 1 || limited[0].ID != "6" {
+		t.Errorf("Expected the newest delivery, got %+v", limited)
+	}
+
+	pendingIDs := func() []string {
+		pending, err := store.PendingDeliveries()
+		if err != nil {
+			t.Fatalf("Expected no error, got %v", err)
+		}
+		ids := []string{}
+		for _, delivery := range pending {
+			ids = append(ids, delivery.ID)
+		}
+		return ids
+	}
+	if ids := pendingIDs(); !reflect.DeepEqual(ids, []string{"3", "5", "2", "4"}) {
+		t.Errorf("Expected pending deliveries 3, 5, 2 and 4, got %v", ids)
+	}
+
+	// Pruned deliveries are not stored again
+	store.UpdateDelivery(&Delivery{ID: "1", SubscriptionID: "1", Status: StatusFailed})
+	if deliveries, _ := store.Deliveries("1", 10); len(deliveries) != 3 {
+		t.Errorf("Expected 3 deliveries, got %+v", deliveries)
+	}
+
+	store.Delete("1")
+	if _, err := store.Deliveries("1", 10); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if err := store.CreateDelivery(&Delivery{SubscriptionID: "1"}); err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound, got %v", err)
+	}
+	if deliveries, _ := store.Deliveries("2", 10); len(deliveries) != 2 {
+		t.Errorf("Expected the deliveries of webhook 2 to be kept, got %+v", deliveries)
+	}
+	if ids := pendingIDs(); !reflect.DeepEqual(ids, []string{"2", "4"}) {
+		t.Errorf("Expected the pending deliveries of webhook 2, got %v", ids)
+	}
+}
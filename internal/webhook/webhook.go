@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lt-app/internal/constants"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Events that subscriptions receive
+const (
+	// A monitor run ended, with or without alerts
+	EventMonitorRunCompleted = "monitor.run.completed"
+	// A monitor run raised alerts
+	EventMonitorRegressed = "monitor.regressed"
+	// An analysis requested through the API returned its stats
+	EventAnalysisCompleted = "analysis.completed"
+)
+
+var Events = []string{EventMonitorRunCompleted, EventMonitorRegressed, EventAnalysisCompleted}
+
+// Headers of every delivery
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	// HMAC-SHA256 of the timestamp, a dot and the body, as "sha256=<hex>"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Statuses of a delivery
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// ErrLimitReached is returned when creating more than WEBHOOK_MAX_CAP subscriptions
+var ErrLimitReached = fmt.Errorf("at most %d webhooks can be created", constants.WEBHOOK_MAX_CAP)
+
+// Subscription posts the events it subscribes to to a URL
+type Subscription struct {
+	ID  string `json:"id"`
+	URL string `json:"url"`
+	// Events to deliver, every event when empty
+	Events []string `json:"events"`
+	// Key of the signature. It is only returned when the subscription is created.
+	Secret    string    `json:"secret,omitempty"`
+	Paused    bool      `json:"paused"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Payload is the body of every delivery
+type Payload struct {
+	// ID of the delivery, which stays the same when it is retried
+	ID        string    `json:"id"`
+	Event     string    `json:"event"`
+	CreatedAt time.Time `json:"createdAt"`
+	Data      any       `json:"data"`
+}
+
+// Delivery records the attempts to post an event to a subscription
+type Delivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscriptionId"`
+	Event          string          `json:"event"`
+	Status         string          `json:"status"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	Attempts       []Attempt       `json:"attempts"`
+	Payload        json.RawMessage `json:"payload"`
+}
+
+type Attempt struct {
+	At time.Time `json:"at"`
+	// Only set when the receiver responded
+	StatusCode int     `json:"statusCode,omitempty"`
+	Error      string  `json:"error,omitempty"`
+	DurationMs float64 `json:"durationMs"`
+}
+
+// Validate checks the URL and events of a subscription
+func (s *Subscription) Validate() error {
+	parsed, err := url.Parse(s.URL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("invalid url format")
+	}
+	for _, event := range s.Events {
+		if !slices.Contains(Events, event) {
+			return fmt.Errorf("unknown event %q, available events: %s", event, strings.Join(Events, ", "))
+		}
+	}
+	if s.Secret != "" && len(s.Secret) < 16 {
+		return errors.New("the secret must have at least 16 characters")
+	}
+	return nil
+}
+
+// Receives reports whether the subscription gets the event
+func (s *Subscription) Receives(event string) bool {
+	return !s.Paused && (len(s.Events) == 0 || slices.Contains(s.Events, event))
+}
+
+// NewSecret returns a random key for signatures
+func NewSecret() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Sign returns the signature header of a body sent at the timestamp
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the signature of a delivery, as a receiver would. Receivers should
+// also reject timestamps that are too old, so that deliveries cannot be replayed.
+func Verify(secret, signature, timestamp string, body []byte) bool {
+	sent, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, sent, body)))
+}
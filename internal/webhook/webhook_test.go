@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSubscription_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription Subscription
+		err          string
+	}{
+		{"every event", Subscription{URL: "https://example.com/hooks"}, ""},
+		{"local receiver", Subscription{URL: "http://127.0.0.1:8080/hooks", Events: []string{EventMonitorRegressed}}, ""},
+		{"secret", Subscription{URL: "https://example.com", Secret: "0123456789abcdef"}, ""},
+		{"no scheme", Subscription{URL: "example.com/hooks"}, "invalid url format"},
+		{"other scheme", Subscription{URL: "ftp://example.com"}, "invalid url format"},
+		{"unknown event", Subscription{URL: "https://example.com", Events: []string{"page.analyzed"}}, `unknown event "page.analyzed"`},
+		{"short secret", Subscription{URL: "https://example.com", Secret: "secret"}, "at least 16 characters"},
+	}
+
+	for _, test := range tests {
+		err := test.subscription.Validate()
+		if test.err == "" && err != nil {
+			t.Errorf("%s: expected no error, got %v", test.name, err)
+		}
+		if test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: expected %q, got %v", test.name, test.err, err)
+		}
+	}
+}
+
+func TestSubscription_Receives(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscription Subscription
+		expected     bool
+	}{
+		{"every event", Subscription{}, true},
+		{"subscribed", Subscription{Events: []string{EventMonitorRunCompleted}}, true},
+		{"other event", Subscription{Events: []string{EventMonitorRegressed}}, false},
+		{"paused", Subscription{Paused: true}, false},
+	}
+
+	for _, test := range tests {
+		if got := test.subscription.Receives(EventMonitorRunCompleted); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestSign(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	signature := Sign("0123456789abcdef", 1760000000, body)
+	// echo -n '1760000000.{"id":"1"}' | openssl dgst -sha256 -hmac 0123456789abcdef
+	if signature != "sha256=454c7c368e9b12b7b0584322a082907e119e103e0acf71d723c66c1a802e7cdc" {
+		t.Fatalf("Unexpected signature %q", signature)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		body      []byte
+		expected  bool
+	}{
+		{"valid", "0123456789abcdef", "1760000000", body, true},
+		{"other secret", "fedcba9876543210", "1760000000", body, false},
+		{"other timestamp", "0123456789abcdef", "1760000001", body, false},
+		{"invalid timestamp", "0123456789abcdef", "now", body, false},
+		{"other body", "0123456789abcdef", "1760000000", []byte(`{"id":"2"}`), false},
+	}
+
+	for _, test := range tests {
+		if got := Verify(test.secret, signature, test.timestamp, test.body); got != test.expected {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, got)
+		}
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	first, err := NewSecret()
+	second, _ := NewSecret()
+	if err != nil || len(first) != 64 || first == second {
+		t.Errorf("Expected two random secrets, got %q, %q, %v", first, second, err)
+	}
+	if err := (&Subscription{URL: "https://example.com", Secret: first}).Validate(); err != nil {
+		t.Errorf("Expected a valid secret, got %v", err)
+	}
+}
@@ -6,6 +6,123 @@ document.addEventListener('DOMContentLoaded', () => {
     const errorDisplay = document.querySelector('.error-display');
     button.disabled = true;
 
+    const renderList = (selector, items, format) => {
+        const list = document.querySelector(selector);
+        list.innerHTML = ""; // Clear existing list
+        for (const item of items) {
+            const listItem = document.createElement("li");
+            listItem.textContent = format(item);
+            list.appendChild(listItem);
+        }
+    };
+
+    const timingPhases = [
+        ["dns", "dnsMs"],
+        ["connect", "connectMs"],
+        ["tls", "tlsHandshakeMs"],
+        ["ttfb", "ttfbMs"],
+        ["transfer", "transferMs"],
+    ];
+
+    // Draws one bar per request, offset from the start of the page request
+    const renderWaterfall = (selector, requests) => {
+        const waterfall = document.querySelector(selector);
+        waterfall.innerHTML = "";
+        const timed = requests.filter((request) => request.timing);
+        if (!timed.length) {
+            return;
+        }
+
+        const start = Math.min(...timed.map((request) => Date.parse(request.timing.startedAt)));
+        const end = Math.max(...timed.map((request) => Date.parse(request.timing.startedAt) + request.timing.totalMs));
+        const scale = 100 / Math.max(end - start, 1);
+
+        for (const request of timed) {
+            const row = document.createElement("div");
+            row.className = "waterfall__row";
+
+            const label = document.createElement("span");
+            label.className = "waterfall__label";
+            label.textContent = request.url;
+            label.title = `${request.url}: ${request.timing.totalMs.toFixed(1)} ms`;
+
+            const track = document.createElement("div");
+            track.className = "waterfall__track";
+
+            let offset = Date.parse(request.timing.startedAt) - start;
+            for (const [phase, key] of timingPhases) {
+                const duration = request.timing[key];
+                if (!duration) {
+                    continue;
+                }
+                const bar = document.createElement("span");
+                bar.className = `waterfall__phase waterfall__phase--${phase}`;
+                bar.style.left = `${offset * scale}%`;
+                bar.style.width = `${Math.max(duration * scale, 0.5)}%`;
+                bar.title = `${phase}: ${duration.toFixed(1)} ms`;
+                track.appendChild(bar);
+                offset += duration;
+            }
+
+            row.append(label, track);
+            waterfall.appendChild(row);
+        }
+    };
+
+    const compareForm = document.querySelector('.compare-form');
+    const diffDisplay = document.querySelector('.diff');
+
+    // Adds a row to the side-by-side diff, marked when the values differ
+    const addDiffRow = (field, before, after, changed) => {
+        const row = document.createElement("tr");
+        if (changed) {
+            row.className = "diff__row--changed";
+        }
+        for (const value of [field, before, after]) {
+            const cell = document.createElement("td");
+            cell.textContent = value;
+            row.appendChild(cell);
+        }
+        document.querySelector("#diff-rows").appendChild(row);
+    };
+
+    const renderDiff = ({a, b, diff}) => {
+        document.querySelector("#diff-rows").innerHTML = "";
+        document.querySelector("#diff-before").textContent = `Run ${a.id} (${new Date(a.analyzedAt).toLocaleString()})`;
+        document.querySelector("#diff-after").textContent = `Run ${b.id} (${new Date(b.analyzedAt).toLocaleString()})`;
+        document.querySelector("#diff-summary").textContent = diff.changed
+            ? `${a.url} changed`
+            : `${a.url} did not change`;
+
+        for (const [field, text] of [["Title", diff.title], ["Description", diff.description]]) {
+            if (text) {
+                addDiffRow(field, text.before, text.after, text.changed);
+            }
+        }
+        if (diff.score) {
+            const scoreChange = diff.score;
+            addDiffRow("Score", `${scoreChange.before} (${scoreChange.gradeBefore})`,
+                `${scoreChange.after} (${scoreChange.gradeAfter}), ${scoreChange.delta >= 0 ? "+" : ""}${scoreChange.delta}`,
+                scoreChange.delta !== 0 || scoreChange.gradeBefore !== scoreChange.gradeAfter);
+            for (const category of scoreChange.categories) {
+                addDiffRow(`Score: ${category.category}`, category.before, category.after, category.delta !== 0);
+            }
+        }
+
+        const headingText = (heading) => `${heading.tag} ${heading.text}`;
+        addDiffRow("Headings", diff.headingsRemoved.map(headingText).join("\n"), diff.headingsAdded.map(headingText).join("\n"),
+            diff.headingsRemoved.length + diff.headingsAdded.length > 0);
+        addDiffRow("Links", diff.linksRemoved.join("\n"), diff.linksAdded.join("\n"),
+            diff.linksRemoved.length + diff.linksAdded.length > 0);
+        addDiffRow("Broken links", diff.newlyFixed.join("\n"), diff.newlyBroken.join("\n"),
+            diff.newlyFixed.length + diff.newlyBroken.length > 0);
+
+        document.querySelector("#diff-not-compared").textContent = diff.notCompared.length
+            ? `Not compared: ${diff.notCompared.join(", ")}`
+            : "";
+        diffDisplay.style.display = "block";
+    };
+
     const displayError = (error) => {
         document.querySelector("#error-message").textContent = error.error;
         document.querySelector("#error-status").textContent = `Status Code: ${error.statusCode || 400}`;
@@ -65,10 +182,148 @@ document.addEventListener('DOMContentLoaded', () => {
                     // Update result container with response data
                     document.querySelector("#title").textContent = data.title;
                     document.querySelector("#htmlVersion").textContent = data.htmlVersion;
-                    document.querySelector("#has-login-form").textContent = data.hasLoginForm ? "Yes" : "No";
+                    document.querySelector("#document-mode").textContent = data.doctype.isFirstToken
+                        ? data.doctype.mode
+                        : `${data.doctype.mode} (doctype is not the first token)`;
+                    document.querySelector("#html-validation").textContent = data.validation.valid
+                        ? `Valid (${data.validation.warningCount} warnings)`
+                        : `${data.validation.errorCount} errors, ${data.validation.warningCount} warnings`;
+
+                    renderList("#html-validation-issues", data.validation.issues,
+                        (issue) => `${issue.line}:${issue.column} [${issue.severity}] ${issue.message}`);
+
+                    const ssoProviders = data.formDetection.ssoProviders;
+                    document.querySelector("#has-login-form").textContent = data.hasLoginForm
+                        ? (ssoProviders.length ? `Yes (SSO: ${ssoProviders.join(", ")})` : "Yes")
+                        : "No";
+
+                    renderList("#form-types", data.formDetection.forms, (form) => {
+                        const formName = form.id || form.name || (form.index < 0 ? "inputs outside forms" : `form #${form.index + 1}`);
+                        return `${formName}: ${form.type} (score ${form.score})`;
+                    });
+                    renderList("#form-findings", data.forms.findings,
+                        (finding) => `[${finding.severity}] ${finding.target}: ${finding.message}`);
                     document.querySelector("#external-links").textContent = data.externalLinks;
                     document.querySelector("#internal-links").textContent = data.internalLinks;
                     document.querySelector("#inacc-links").textContent = data.inaccessibleLinks;
+                    renderList("#broken-link-list", data.brokenLinks, (link) => link);
+                    const linkQuality = data.linkQuality;
+                    document.querySelector("#link-quality").textContent =
+                        `${linkQuality.genericText} generic, ${linkQuality.empty} empty, ` +
+                        `${linkQuality.imageLinksWithoutAlt} image links without alt, ` +
+                        `${linkQuality.unsafeTargetBlank} of ${linkQuality.targetBlank} target="_blank" without noopener, ` +
+                        `nofollow: ${linkQuality.nofollow}, sponsored: ${linkQuality.sponsored}, ugc: ${linkQuality.ugc}`;
+                    renderList("#link-quality-findings", linkQuality.findings,
+                        (finding) => `[${finding.severity}] ${finding.target}: ${finding.message}`);
+                    const resourceCounts = Object.entries(data.resources.byType)
+                        .map(([type, count]) => `${type}: ${count}`).join(", ");
+                    document.querySelector("#resources").textContent =
+                        `${data.resources.total} (${resourceCounts || "none"}), ${data.resources.broken.length} broken` +
+                        (data.resources.unchecked ? `, ${data.resources.unchecked} unchecked` : "");
+                    renderList("#broken-resource-list", data.resources.broken,
+                        (resource) => `[${resource.type}] ${resource.url}`);
+
+                    renderList("#technologies", data.technologies.technologies, (tech) =>
+                        `${tech.name}${tech.version ? ` ${tech.version}` : ""} (${tech.categories.join(", ")})`);
+
+                    const content = data.content;
+                    document.querySelector("#content").textContent =
+                        `${content.wordCount} words, ${content.sentenceCount} sentences, ${content.paragraphCount} paragraphs, ` +
+                        `${content.readingTimeMinutes} min read, text to HTML ${content.textToHtmlRatio}% (from <${content.source}>)`;
+                    document.querySelector("#readability").textContent = content.readability
+                        ? `Flesch reading ease ${content.readability.fleschReadingEase} (${content.readability.level}), ` +
+                            `grade ${content.readability.fleschKincaidGrade}`
+                        : "Readability is only measured for English";
+                    renderList("#keyword-list", content.keywords.concat(content.phrases),
+                        (term) => `${term.term}: ${term.count} (${term.density}%)`);
+
+                    const pageLanguage = data.language;
+                    const declaredLanguages = pageLanguage.declared
+                        .map((declaration) => `${declaration.source}: ${declaration.value}${declaration.valid ? "" : " (invalid)"}`)
+                        .join(", ");
+                    const detectedLanguage = pageLanguage.detected.language
+                        ? `${pageLanguage.detected.language}${pageLanguage.detected.reliable ? "" : ", uncertain"}`
+                        : "undetermined";
+                    document.querySelector("#language").textContent =
+                        `Declared ${declaredLanguages || "nothing"}, detected ${detectedLanguage}`;
+                    renderList("#language-findings", pageLanguage.findings,
+                        (finding) => `[${finding.severity}] ${finding.message}`);
+
+                    const thirdParties = data.thirdParties;
+                    const categoryCounts = Object.entries(thirdParties.byCategory)
+                        .map(([category, count]) => `${category}: ${count}`).join(", ");
+                    document.querySelector("#third-parties").textContent =
+                        `${thirdParties.thirdPartyCount}` + (categoryCounts ? ` (${categoryCounts})` : "");
+                    renderList("#third-party-list", thirdParties.domains.filter((domain) => domain.thirdParty),
+                        (domain) => `${domain.domain}${domain.category ? ` [${domain.category}, ${domain.owner}]` : ""}: ` +
+                            `${domain.resources} resources, ${domain.links} links`);
+
+                    const mixedContent = data.mixedContent;
+                    document.querySelector("#mixed-content").textContent = !mixedContent.applicable
+                        ? "Not applicable (page is not served over HTTPS)"
+                        : `${mixedContent.activeCount} active, ${mixedContent.passiveCount} passive` +
+                            (mixedContent.upgradeInsecureRequests ? " (upgrade-insecure-requests is set)" : "");
+                    renderList("#mixed-content-list", mixedContent.items, (item) =>
+                        `[${item.kind}${item.autoUpgraded ? ", upgraded" : ""}] <${item.element}> ${item.url}`);
+
+                    const securityHeaders = data.securityHeaders;
+                    document.querySelector("#security-headers").textContent =
+                        `Grade ${securityHeaders.grade} (${securityHeaders.score}/100)`;
+                    renderList("#security-header-checks", securityHeaders.checks,
+                        (check) => `${check.name}: ${check.score}/${check.maxScore}`);
+                    renderList("#security-header-findings", securityHeaders.findings,
+                        (finding) => `[${finding.severity}] ${finding.message}`);
+
+                    const tls = data.tls;
+                    document.querySelector("#tls").textContent = tls
+                        ? `${tls.version}, ${tls.cipherSuite}, expires in ${tls.daysRemaining} days` +
+                            (tls.trusted && tls.hostnameMatch ? "" : " (not valid)")
+                        : "Not available";
+                    renderList("#tls-chain", tls ? tls.chain : [],
+                        (cert) => `${cert.subject} issued by ${cert.issuer}`);
+                    renderList("#tls-findings", tls ? tls.findings : [],
+                        (finding) => `[${finding.severity}] ${finding.message}`);
+                    document.querySelector("#broken-anchors").textContent = data.anchors.unverified
+                        ? `${data.anchors.broken.length} (${data.anchors.unverified} unverified)`
+                        : data.anchors.broken.length;
+                    renderList("#broken-anchor-list", data.anchors.broken, (anchor) => anchor.href);
+                    document.querySelector("#rules").textContent =
+                        `${data.rules.passed} of ${data.rules.total} passed`;
+                    renderList("#rule-results", data.rules.results, (rule) =>
+                        `[${rule.passed ? "pass" : rule.severity}] ${rule.description || rule.id}: ${rule.message}`);
+                    const failedScripts = data.scripts.scripts.filter((script) => script.error);
+                    document.querySelector("#scripts").textContent =
+                        `${data.scripts.scripts.length} run, ${data.scripts.findings.length} findings` +
+                        (failedScripts.length ? `, ${failedScripts.length} failed` : "");
+                    renderList("#script-findings", data.scripts.findings.concat(failedScripts), (item) => item.error
+                        ? `[failed] ${item.name}: ${item.error}`
+                        : `[${item.severity}] ${item.target ? item.target + ": " : ""}${item.message}`);
+
+                    const pageScore = data.score;
+                    document.querySelector("#score").textContent = `${pageScore.score}/100 (grade ${pageScore.grade})`;
+                    renderList("#score-categories", pageScore.categories,
+                        (category) => `${category.category}: ${category.score}/100 (${category.findings} findings)`);
+                    renderList("#score-contributions", pageScore.contributions.slice(0, 10), (contribution) =>
+                        `-${contribution.penalty} ${contribution.message}` + (contribution.count > 1 ? ` (${contribution.count}x)` : ""));
+
+                    const verdict = data.budget;
+                    document.querySelector("#budget").textContent =
+                        `${verdict.passed ? "Passed" : "Failed"}, ${verdict.checked.length} checked` +
+                        (verdict.skipped.length ? `, ${verdict.skipped.length} skipped` : "");
+                    renderList("#budget-violations", verdict.violations, (violation) => violation.message);
+
+                    // The previous run becomes the one to compare with
+                    if (data.historyId) {
+                        compareForm.a.value = compareForm.b.value;
+                        compareForm.b.value = data.historyId;
+                    }
+
+                    const timing = data.timing;
+                    document.querySelector("#timing").textContent = timing
+                        ? timingPhases.map(([phase, key]) => `${phase} ${timing[key].toFixed(1)} ms`).join(", ") +
+                            `, total ${timing.totalMs.toFixed(1)} ms`
+                        : "Not available";
+                    renderWaterfall("#timing-waterfall", [{url: webPageUrl, timing}, ...data.linkChecks]);
 
                     // Update headings
                     const headingsList = document.querySelector("#headings");
@@ -93,4 +348,26 @@ document.addEventListener('DOMContentLoaded', () => {
             loader.style.display = 'none';
         });
     });
+
+    compareForm.addEventListener('submit', (event) => {
+        event.preventDefault();
+
+        const params = new URLSearchParams({a: compareForm.a.value.trim(), b: compareForm.b.value.trim()});
+        diffDisplay.style.display = 'none';
+        errorDisplay.style.display = 'none';
+
+        fetch(`/api/diff?${params}`)
+        .then(async (response) => {
+            const data = await response.json();
+            if (!response.ok) {
+                displayError(data);
+            } else {
+                renderDiff(data);
+            }
+        })
+        .catch(error => {
+            console.error('Error:', error);
+            displayError({error: "Something went wrong", statusCode: 500});
+        });
+    });
 });